@@ -2,6 +2,7 @@ import type {
 	CreateNodeRequest,
 	UpdateNodeRequest,
 	Node,
+	NodeList,
 	DashboardMetrics,
 	RoutingRequest,
 } from "../types/api";
@@ -11,10 +12,12 @@ const API_BASE = import.meta.env.VITE_API_URL || "http://localhost:8080";
 export const api = {
 	// Nodes
 	getNodes: (): Promise<Node[]> =>
-		fetch(`${API_BASE}/admin/api/v1/nodes`).then((r) => {
-			if (!r.ok) throw new Error(`HTTP ${r.status}`);
-			return r.json();
-		}),
+		fetch(`${API_BASE}/admin/api/v1/nodes?limit=500`)
+			.then((r) => {
+				if (!r.ok) throw new Error(`HTTP ${r.status}`);
+				return r.json();
+			})
+			.then((list: NodeList) => list.items),
 
 	createNode: (data: CreateNodeRequest): Promise<Node> =>
 		fetch(`${API_BASE}/admin/api/v1/nodes`, {
@@ -58,10 +61,12 @@ export const api = {
 
 	// Public
 	getPublicNodes: (): Promise<Node[]> =>
-		fetch(`${API_BASE}/api/v1/nodes`).then((r) => {
-			if (!r.ok) throw new Error(`HTTP ${r.status}`);
-			return r.json();
-		}),
+		fetch(`${API_BASE}/api/v1/nodes?limit=500`)
+			.then((r) => {
+				if (!r.ok) throw new Error(`HTTP ${r.status}`);
+				return r.json();
+			})
+			.then((list: NodeList) => list.items),
 
 	simulateRoute: (data: { coordinates: { x: number; y: number } }) =>
 		fetch(`${API_BASE}/api/v1/route`, {
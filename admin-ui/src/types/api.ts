@@ -14,6 +14,13 @@ export interface Node {
 	updated_at: string;
 }
 
+export interface NodeList {
+	items: Node[];
+	total: number;
+	limit: number;
+	offset: number;
+}
+
 export interface CreateNodeRequest {
 	name: string;
 	location: { x: number; y: number };
@@ -2,32 +2,73 @@ package main
 
 import (
 	"context"
-	"log"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"arx-supervisor/db/migrations"
+	"arx-supervisor/docs"
 	"arx-supervisor/internal/api"
+	"arx-supervisor/internal/auth"
 	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/cors"
 	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/events"
 	"arx-supervisor/internal/health"
+	"arx-supervisor/internal/logging"
+	"arx-supervisor/internal/metrics"
+	"arx-supervisor/internal/peersync"
+	"arx-supervisor/internal/ratelimit"
+	"arx-supervisor/internal/readiness"
+	"arx-supervisor/internal/retention"
 	"arx-supervisor/internal/routing"
+	"arx-supervisor/internal/rpc/arxpb"
+	"arx-supervisor/internal/state"
+	"arx-supervisor/internal/tracing"
 	"arx-supervisor/internal/websocket"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+// @title Arx Supervisor API
+// @version 1.0
+// @description Routes requests to the nearest healthy edge node and manages the node registry.
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT with the admin role, sent as "Bearer <token>"
 func main() {
-	// Load .env file if exists
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
+	rollback := flag.Bool("rollback", false, "roll back the most recent database migration and exit")
+	flag.Parse()
+
+	// Load .env file if exists; SIGHUP reads it again
+	env := newEnvFile()
+	envErr := env.load()
 
 	// Load configuration
 	cfg := config.Load()
 
+	// Log JSON lines; the stdlib log package is routed through the same handler
+	logger := logging.New(os.Stdout, cfg.Server.LogLevel)
+	slog.SetDefault(logger)
+	fatal := func(msg string, err error) {
+		logger.Error(msg, "error", err)
+		os.Exit(1)
+	}
+
+	if envErr != nil {
+		logger.Info("No .env file found, using environment variables")
+	}
+
 	// Setup graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -35,95 +76,361 @@ func main() {
 	// Initialize database
 	database, err := database.SetupDatabase(ctx, cfg.Database)
 	if err != nil {
-		log.Fatal("Failed to setup database:", err)
+		fatal("Failed to setup database", err)
 	}
 	defer database.Close()
 
-	log.Println("Database connected successfully")
+	logger.Info("Database connected successfully")
+
+	// Apply schema migrations
+	if *rollback {
+		if err := database.RollbackMigration(ctx, migrations.FS); err != nil {
+			fatal("Failed to roll back migration", err)
+		}
+		return
+	}
+	if err := database.Migrate(ctx, migrations.FS); err != nil {
+		fatal("Failed to run migrations", err)
+	}
+
+	// Register Prometheus collectors
+	metrics.Register(prometheus.DefaultRegisterer)
+
+	// Export traces when an OTLP endpoint is configured
+	shutdownTracing, tracingEnabled, err := tracing.Setup(ctx)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	if tracingEnabled {
+		logger.Info("Exporting traces over OTLP")
+	}
+
+	// Shared state such as rate limits, kept in memory unless REDIS_ADDR
+	// points replicas at a common Redis
+	stateBackend := state.New(cfg.State)
+	defer stateBackend.Close()
+	if cfg.State.RedisAddr != "" {
+		pingCtx, cancelPing := context.WithTimeout(ctx, 5*time.Second)
+		err := stateBackend.Ping(pingCtx)
+		cancelPing()
+		if err != nil {
+			fatal("Failed to connect to Redis", err)
+		}
+		logger.Info("Sharing state through Redis", "addr", cfg.State.RedisAddr)
+	}
+	go stateBackend.Run(ctx)
+
+	// Initialize routing service
+	routingService := routing.NewService(database, cfg.Routing, time.Duration(cfg.Health.MaxCheckAge)*time.Second)
+	if err := routingService.LoadConfig(ctx); err != nil {
+		logger.Warn("Using routing config from environment", "error", err)
+	}
+	if err := routing.ValidateConfig(routingService.Config()); err != nil {
+		fatal("Invalid routing configuration", err)
+	}
+	// Nodes stored under another coordinate system would be routed on
+	// meaningless distances, so refuse to start instead
+	if err := routingService.CheckNodeCoordinates(ctx); err != nil {
+		fatal("Nodes do not fit the coordinate system", err)
+	}
 
 	// Initialize WebSocket hub
-	wsHub := websocket.NewHub()
-	go wsHub.Run()
+	if err := websocket.ValidateConfig(cfg.WebSocket); err != nil {
+		fatal("Invalid WebSocket configuration", err)
+	}
+	wsHub := websocket.NewHub(cfg.WebSocket, cfg.Auth.JWTSecret)
+
+	// Share node changes with peer supervisors on the same database
+	if cfg.PeerSync.Enabled {
+		syncer := peersync.New(database.Pool, routingService, wsHub, logger)
+		wsHub.SetRelay(syncer.Relay)
+		go syncer.Run(ctx)
+	}
+
+	hubCtx, stopHub := context.WithCancel(ctx)
+	hubDone := make(chan struct{})
+	go func() {
+		wsHub.Run(hubCtx)
+		close(hubDone)
+	}()
+
+	// Node lifecycle events are stored before they are broadcast
+	recorder := events.NewRecorder(database, wsHub, logger)
 
 	// Initialize health monitor
-	healthMonitor := health.NewMonitor(database, wsHub, time.Duration(cfg.Health.CheckInterval)*time.Second)
+	if err := health.ValidateConfig(cfg.Health); err != nil {
+		fatal("Invalid health configuration", err)
+	}
+	healthMonitor := health.NewMonitor(database, routingService, wsHub, recorder, cfg.Health, logger)
 	go healthMonitor.Start()
-
-	// Initialize routing service
-	routingService := routing.NewService(database)
+	go healthMonitor.RunClusterStats(ctx)
 
 	// Setup router
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery(), tracing.Middleware(), logging.Middleware(logger))
 
-	// Enable CORS for admin dashboard
-	r.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	// Enable CORS for the admin dashboard
+	r.Use(cors.Middleware(cfg.Server.AllowedOrigins))
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// Prometheus metrics
+	r.GET("/metrics", metrics.Handler())
+
+	// Liveness and readiness probes. Readiness needs the database and a
+	// recent health check pass, and turns 503 as soon as shutdown starts.
+	ready := readiness.New()
+	ready.AddCheck("database", database.Pool.Ping)
+	ready.AddCheck("health_monitor", healthMonitor.Ready)
+	r.GET("/livez", readiness.LiveHandler)
+	r.GET("/readyz", ready.Handler)
 
-		c.Next()
-	})
+	// API description and Swagger UI
+	docs.Register(r)
 
 	// WebSocket endpoint
 	r.GET("/admin/api/v1/realtime", wsHub.HandleWebSocket)
 
 	// Public API
-	publicHandler := api.NewPublicHandler(database, routingService, wsHub)
+	capture, err := api.NewPayloadCapture(cfg.Capture)
+	if err != nil {
+		fatal("Invalid payload capture configuration", err)
+	}
+	// Rate limit routing per client; the limiter lets everything through
+	// while RATE_LIMIT_RPS is 0, so a reload can turn it on
+	limiter := ratelimit.New(stateBackend, cfg.RateLimit.RPS, cfg.RateLimit.Burst, logger)
+	publicHandler := api.NewPublicHandler(database, routingService, wsHub, recorder, healthMonitor, logger,
+		time.Duration(cfg.Server.IdempotencyTTL)*time.Second, time.Duration(cfg.Server.RequestTimeout)*time.Millisecond,
+		cfg.Auth.RegistrationToken, capture, limiter)
+	if cfg.Auth.RegistrationToken == "" {
+		logger.Warn("NODE_REGISTRATION_TOKEN is not set, nodes can only register with one-time tokens")
+	}
+	routeLimit := ratelimit.Middleware(limiter)
+
+	// New routes are refused while draining; in-flight ones finish
+	drain := ready.Middleware()
+
 	public := r.Group("/api/v1")
 	{
-		public.POST("/route", publicHandler.RouteRequest)
+		public.POST("/route", drain, routeLimit, publicHandler.RouteRequest)
+		public.POST("/route/preview", drain, routeLimit, publicHandler.PreviewRoute)
+		// Batches are charged per request once their size is known
+		public.POST("/route/batch", drain, publicHandler.RouteBatch)
+		public.GET("/route/:request_id", publicHandler.GetRoutingRequest)
+		public.POST("/route/:request_id/failed", routeLimit, publicHandler.ReportRouteFailure)
 		public.GET("/nodes", publicHandler.GetNodes)
+		public.GET("/nodes/nearby", publicHandler.GetNearbyNodes)
+		public.GET("/nodes/:id", publicHandler.GetNode)
 		public.POST("/nodes/register", publicHandler.RegisterNode)
+		public.POST("/nodes/:id/heartbeat", publicHandler.Heartbeat)
 		public.GET("/health", publicHandler.Health)
+		public.GET("/health/cluster", publicHandler.ClusterHealth)
 	}
 
 	// Admin API
-	adminHandler := api.NewAdminHandler(database, wsHub)
+	// Prune old routing requests and metrics
+	pruner := retention.New(database, cfg.Retention, logger)
+	go pruner.Run(ctx)
+
+	adminHandler := api.NewAdminHandler(database, routingService, wsHub, recorder, healthMonitor, pruner, logger)
+	if cfg.Auth.JWTSecret == "" {
+		logger.Warn("JWT_SECRET is not set, admin API requests will be rejected")
+	}
 	admin := r.Group("/admin/api/v1")
+	admin.Use(auth.AuthRequired(cfg.Auth.JWTSecret))
 	{
 		// Node CRUD operations
 		admin.GET("/nodes", adminHandler.GetAllNodes)
+		admin.GET("/nodes/export.geojson", adminHandler.ExportNodesGeoJSON)
 		admin.POST("/nodes", adminHandler.CreateNode)
-		admin.PUT("/nodes/:id", adminHandler.UpdateNode)
+		admin.POST("/nodes/bulk", adminHandler.BulkCreateNodes)
+		admin.POST("/nodes/registration-tokens", adminHandler.CreateRegistrationToken)
+		admin.PUT("/nodes/:id", adminHandler.ReplaceNode)
+		admin.PATCH("/nodes/:id", adminHandler.UpdateNode)
 		admin.DELETE("/nodes/:id", adminHandler.DeleteNode)
+		admin.POST("/nodes/:id/drain", adminHandler.DrainNode)
+		admin.POST("/nodes/:id/maintenance", adminHandler.SetNodeMaintenance)
+		admin.GET("/nodes/:id/metrics", adminHandler.GetNodeMetrics)
+		admin.GET("/nodes/:id/events", adminHandler.GetNodeEvents)
+
+		// Clusters
+		admin.GET("/clusters", adminHandler.ListClusters)
+		admin.POST("/clusters", adminHandler.CreateCluster)
+		admin.GET("/clusters/:id", adminHandler.GetCluster)
+		admin.PUT("/clusters/:id", adminHandler.UpdateCluster)
+		admin.DELETE("/clusters/:id", adminHandler.DeleteCluster)
+
+		// On-demand health checks
+		admin.POST("/nodes/:id/healthcheck", adminHandler.CheckNodeHealth)
+		admin.POST("/healthcheck", adminHandler.CheckAllNodesHealth)
+
+		// Runtime configuration
+		admin.GET("/config/routing", adminHandler.GetRoutingConfig)
+		admin.PUT("/config/routing", adminHandler.UpdateRoutingConfig)
 
 		// Dashboard and metrics
 		admin.GET("/dashboard/metrics", adminHandler.GetDashboardMetrics)
+		admin.GET("/metrics/latency", adminHandler.GetLatencyMetrics)
+		admin.GET("/capacity", adminHandler.GetCapacity)
 		admin.GET("/requests/export", adminHandler.ExportRequests)
+		admin.GET("/requests/:id", adminHandler.GetRoutingRequest)
+
+		// Maintenance
+		admin.POST("/maintenance/prune", adminHandler.PruneOldRows)
 	}
 
 	// Start server in a goroutine
 	srv := &http.Server{
-		Addr:    cfg.Server.Host + ":" + cfg.Server.Port,
-		Handler: r,
+		Addr:              cfg.Server.Host + ":" + cfg.Server.Port,
+		Handler:           r,
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout) * time.Second,
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout) * time.Second,
+		Protocols:         serverProtocols(cfg.Server),
+	}
+
+	useTLS := cfg.Server.TLSEnabled()
+	if useTLS {
+		minVersion, err := tlsVersion(cfg.Server.TLSMinVersion)
+		if err != nil {
+			fatal("Invalid TLS configuration", err)
+		}
+		srv.TLSConfig = &tls.Config{MinVersion: minVersion}
+	} else if cfg.Server.TLSCertFile != "" || cfg.Server.TLSKeyFile != "" {
+		logger.Warn("TLS_CERT_FILE and TLS_KEY_FILE must both be set, serving plain HTTP")
 	}
 
 	go func() {
-		log.Printf("Server starting on %s", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal("Server failed to start:", err)
+		logger.Info("Server starting", "addr", srv.Addr, "tls", useTLS,
+			"http2", cfg.Server.HTTP2 && useTLS, "h2c", cfg.Server.H2C && !useTLS)
+		var err error
+		if useTLS {
+			err = srv.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Server failed to start", err)
 		}
 	}()
 
+	// gRPC routing API on its own port, sharing the public handler
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort != "0" {
+		opts := []grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(
+				logging.UnaryServerInterceptor(logger),
+				ready.UnaryServerInterceptor(),
+				ratelimit.UnaryServerInterceptor(limiter, arxpb.Routing_Route_FullMethodName),
+			),
+		}
+		if useTLS {
+			cert, err := tls.LoadX509KeyPair(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+			if err != nil {
+				fatal("Failed to load TLS certificate", err)
+			}
+			opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{
+				Certificates: []tls.Certificate{cert},
+				MinVersion:   srv.TLSConfig.MinVersion,
+			})))
+		}
+		grpcServer = grpc.NewServer(opts...)
+		arxpb.RegisterRoutingServer(grpcServer, api.NewGRPCServer(publicHandler))
+
+		lis, err := net.Listen("tcp", cfg.Server.Host+":"+cfg.Server.GRPCPort)
+		if err != nil {
+			fatal("gRPC server failed to start", err)
+		}
+		go func() {
+			logger.Info("gRPC server starting", "addr", lis.Addr().String(), "tls", useTLS)
+			if err := grpcServer.Serve(lis); err != nil {
+				fatal("gRPC server failed", err)
+			}
+		}()
+	}
+
+	// Apply routing, health check and rate limit settings on SIGHUP
+	reload := &reloader{
+		env:     env,
+		started: cfg,
+		router:  routingService,
+		monitor: healthMonitor,
+		limiter: limiter,
+		logger:  logger,
+	}
+	go reload.run(ctx)
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	logger.Info("Shutting down server")
 
-	// Shutdown HTTP server
-	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// Refuse new routes and fail readiness, then keep serving until load
+	// balancers have noticed; a second signal skips the wait
+	ready.Drain()
+	if delay := time.Duration(cfg.Server.ShutdownDrainDelay) * time.Second; delay > 0 {
+		logger.Info("Draining before shutdown", "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-quit:
+		}
+	}
+
+	// Shutdown HTTP server, waiting up to 5s for in-flight requests
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		fatal("Server forced to shutdown", err)
+	}
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+	// Let in-flight gRPC calls finish within the same deadline
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			grpcServer.Stop()
+		}
 	}
 
-	log.Println("Server exited")
+	// With no requests left, stop background workers such as rate limiter
+	// sweeps and pruning, then close WebSocket clients
+	cancel()
+	stopHub()
+	<-hubDone
+
+	// Flush spans still buffered for export
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("Failed to flush traces", "error", err)
+	}
+
+	logger.Info("Server exited")
+}
+
+// tlsVersion maps a TLS_MIN_VERSION value onto its crypto/tls constant.
+func tlsVersion(version string) (uint16, error) {
+	switch version {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unsupported TLS_MIN_VERSION %q, expected 1.2 or 1.3", version)
+}
+
+// serverProtocols returns the protocols the HTTP server accepts: HTTP/1.1
+// always, HTTP/2 over TLS unless disabled and HTTP/2 without TLS when H2C is
+// enabled.
+func serverProtocols(cfg config.ServerConfig) *http.Protocols {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(cfg.HTTP2)
+	protocols.SetUnencryptedHTTP2(cfg.H2C)
+	return protocols
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/health"
+	"arx-supervisor/internal/ratelimit"
+	"arx-supervisor/internal/routing"
+	"github.com/joho/godotenv"
+)
+
+// envFile loads .env into the environment. Variables set before it was
+// first read take precedence, on reloads as at startup, and variables a
+// reload no longer finds in the file are unset again.
+type envFile struct {
+	fixed  map[string]bool
+	loaded map[string]bool
+}
+
+func newEnvFile() *envFile {
+	fixed := make(map[string]bool)
+	for _, entry := range os.Environ() {
+		key, _, _ := strings.Cut(entry, "=")
+		fixed[key] = true
+	}
+	return &envFile{fixed: fixed, loaded: make(map[string]bool)}
+}
+
+func (e *envFile) load() error {
+	values, err := godotenv.Read()
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]bool, len(values))
+	for key, value := range values {
+		if e.fixed[key] {
+			continue
+		}
+		os.Setenv(key, value)
+		loaded[key] = true
+	}
+	for key := range e.loaded {
+		if !loaded[key] {
+			os.Unsetenv(key)
+		}
+	}
+	e.loaded = loaded
+	return nil
+}
+
+// reloader re-reads the configuration on SIGHUP and applies the parts the
+// running services can take without a restart: routing weights, health
+// check settings and rate limits. Changes to anything else are logged as
+// needing a restart.
+type reloader struct {
+	env     *envFile
+	started config.Config
+	router  *routing.Service
+	monitor *health.Monitor
+	limiter *ratelimit.Limiter
+	logger  *slog.Logger
+}
+
+// run reloads on every SIGHUP until ctx is cancelled.
+func (r *reloader) run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload(ctx)
+		}
+	}
+}
+
+// reload applies a new configuration as a whole or, when any reloadable part
+// of it is invalid, not at all.
+func (r *reloader) reload(ctx context.Context) {
+	r.logger.Info("Reloading configuration")
+	if err := r.env.load(); err != nil {
+		r.logger.Info("No .env file found, reloading from environment variables")
+	}
+	cfg := config.Load()
+
+	if err := health.ValidateConfig(cfg.Health); err != nil {
+		r.logger.Error("Configuration reload rejected, keeping the current configuration", "error", err)
+		return
+	}
+	// Routing goes first since it can still fail reading stored weights
+	if err := r.router.ReloadConfig(ctx, cfg.Routing, time.Duration(cfg.Health.MaxCheckAge)*time.Second); err != nil {
+		r.logger.Error("Configuration reload rejected, keeping the current configuration", "error", err)
+		return
+	}
+	r.monitor.Reconfigure(cfg.Health)
+	r.limiter.SetLimits(cfg.RateLimit.RPS, cfg.RateLimit.Burst)
+
+	routingCfg := r.router.Config()
+	r.logger.Info("Configuration reloaded",
+		"k_nearest", routingCfg.KNearest, "strategy", routingCfg.Strategy,
+		"health_check_interval", cfg.Health.CheckInterval, "rate_limit_rps", cfg.RateLimit.RPS)
+	if changed := restartNeeded(r.started, cfg); len(changed) > 0 {
+		r.logger.Warn("Configuration changes need a restart to apply", "settings", changed)
+	}
+}
+
+// restartNeeded names the settings that differ between the configuration
+// the supervisor started with and cfg but cannot be reloaded.
+func restartNeeded(started, cfg config.Config) []string {
+	var changed []string
+	check := func(name string, before, after interface{}) {
+		if !reflect.DeepEqual(before, after) {
+			changed = append(changed, name)
+		}
+	}
+	check("server", started.Server, cfg.Server)
+	check("database", started.Database, cfg.Database)
+	check("auth", started.Auth, cfg.Auth)
+	check("websocket", started.WebSocket, cfg.WebSocket)
+	check("retention", started.Retention, cfg.Retention)
+	check("peer_sync", started.PeerSync, cfg.PeerSync)
+	check("state", started.State, cfg.State)
+	check("capture", started.Capture, cfg.Capture)
+	check("routing_coordinates", coordinateSettings(started.Routing), coordinateSettings(cfg.Routing))
+	check("health", fixedHealthSettings(started.Health), fixedHealthSettings(cfg.Health))
+	return changed
+}
+
+// coordinateSettings are the routing settings only read at startup.
+func coordinateSettings(cfg config.RoutingConfig) config.RoutingConfig {
+	return config.RoutingConfig{
+		CoordinateSystem:   cfg.CoordinateSystem,
+		DistanceMode:       cfg.DistanceMode,
+		ProjectionLatitude: cfg.ProjectionLatitude,
+	}
+}
+
+// fixedHealthSettings clears the health settings health.Monitor.Reconfigure
+// applies, leaving those only read at startup.
+func fixedHealthSettings(cfg config.HealthConfig) config.HealthConfig {
+	cfg.CheckInterval = 0
+	cfg.Timeout = 0
+	cfg.FailureThreshold = 0
+	cfg.MaxBackoff = 0
+	cfg.CapacityWarningThreshold = 0
+	cfg.MaxCheckAge = 0
+	return cfg
+}
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE UNIQUE INDEX idx_nodes_endpoint ON nodes(endpoint);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_nodes_endpoint;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Single-row table holding routing weights tuned at runtime
+CREATE TABLE routing_config (
+    id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    k_nearest INTEGER NOT NULL,
+    max_distance FLOAT NOT NULL,
+    load_weight FLOAT NOT NULL,
+    distance_weight FLOAT NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS routing_config;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Set when a node is drained; the node is removed once the drain period passes
+ALTER TABLE nodes ADD COLUMN draining_since TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE nodes DROP COLUMN IF EXISTS draining_since;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Set when a node pushes its own load; recent heartbeats replace pull checks
+ALTER TABLE nodes ADD COLUMN last_heartbeat TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE nodes DROP COLUMN IF EXISTS last_heartbeat;
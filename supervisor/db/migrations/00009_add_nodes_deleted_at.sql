@@ -0,0 +1,12 @@
+-- +goose Up
+-- Deleted nodes are kept for their routing history; only live nodes need a
+-- unique endpoint so a deleted node's endpoint can be registered again
+ALTER TABLE nodes ADD COLUMN deleted_at TIMESTAMP;
+DROP INDEX IF EXISTS idx_nodes_endpoint;
+CREATE UNIQUE INDEX idx_nodes_endpoint ON nodes(endpoint) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DELETE FROM nodes WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_nodes_endpoint;
+CREATE UNIQUE INDEX idx_nodes_endpoint ON nodes(endpoint);
+ALTER TABLE nodes DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Heavier nodes attract proportionally more traffic; weight 0 is a standby
+-- only used when no other node qualifies
+ALTER TABLE nodes ADD COLUMN weight INTEGER NOT NULL DEFAULT 1 CHECK (weight >= 0);
+
+-- +goose Down
+ALTER TABLE nodes DROP COLUMN IF EXISTS weight;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Logical zone or region used for zone-aware routing; empty when untagged
+ALTER TABLE nodes ADD COLUMN zone VARCHAR(100) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE nodes DROP COLUMN IF EXISTS zone;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- How the health monitor probes a node: an HTTP(S) GET of health_path, or a
+-- plain TCP connect
+ALTER TABLE nodes ADD COLUMN health_path VARCHAR(255) NOT NULL DEFAULT '/health';
+ALTER TABLE nodes ADD COLUMN health_protocol VARCHAR(10) NOT NULL DEFAULT 'http'
+    CHECK (health_protocol IN ('http', 'https', 'tcp'));
+
+-- Existing https endpoints were already probed over TLS
+UPDATE nodes SET health_protocol = 'https' WHERE endpoint LIKE 'https://%';
+
+-- +goose Down
+ALTER TABLE nodes DROP COLUMN IF EXISTS health_protocol;
+ALTER TABLE nodes DROP COLUMN IF EXISTS health_path;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Nodes in maintenance are health-checked and listed but not routed to
+ALTER TABLE nodes ADD COLUMN maintenance BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE nodes DROP COLUMN IF EXISTS maintenance;
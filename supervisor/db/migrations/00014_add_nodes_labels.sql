@@ -0,0 +1,9 @@
+-- +goose Up
+-- Arbitrary key/value tags such as {"gpu": "true"} used for label-based
+-- routing; the GIN index serves containment (@>) filters
+ALTER TABLE nodes ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+CREATE INDEX idx_nodes_labels ON nodes USING GIN (labels);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_nodes_labels;
+ALTER TABLE nodes DROP COLUMN IF EXISTS labels;
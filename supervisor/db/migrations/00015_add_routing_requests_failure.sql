@@ -0,0 +1,12 @@
+-- +goose Up
+-- Failures clients report against the node a request was routed to
+ALTER TABLE routing_requests
+    ADD COLUMN failed_node_id UUID REFERENCES nodes(id) ON DELETE SET NULL,
+    ADD COLUMN failure_reason VARCHAR(255),
+    ADD COLUMN failure_reported_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE routing_requests
+    DROP COLUMN IF EXISTS failure_reported_at,
+    DROP COLUMN IF EXISTS failure_reason,
+    DROP COLUMN IF EXISTS failed_node_id;
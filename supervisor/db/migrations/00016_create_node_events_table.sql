@@ -0,0 +1,17 @@
+-- +goose Up
+-- Node lifecycle history. node_id has no foreign key so a node's events
+-- outlive a hard delete.
+CREATE TABLE node_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    node_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_node_events_node_id_created_at ON node_events(node_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS node_events;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- One-time node registration tokens. Only a SHA-256 hash of each token is
+-- stored; node_id records the node that spent it.
+CREATE TABLE registration_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_by VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    node_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS registration_tokens;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Clusters group nodes into routing domains. Requests naming a cluster are
+-- only routed to its nodes.
+CREATE TABLE clusters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Soft-deleted nodes keep their row, so deleting a cluster detaches them
+-- rather than being blocked by them
+ALTER TABLE nodes ADD COLUMN cluster_id UUID REFERENCES clusters(id) ON DELETE SET NULL;
+CREATE INDEX idx_nodes_cluster_id ON nodes(cluster_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_nodes_cluster_id;
+ALTER TABLE nodes DROP COLUMN IF EXISTS cluster_id;
+DROP TABLE IF EXISTS clusters;
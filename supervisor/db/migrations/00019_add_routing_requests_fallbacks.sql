@@ -0,0 +1,9 @@
+-- +goose Up
+-- The fallback nodes returned with a routed request, which clients may also
+-- report as failing it
+ALTER TABLE routing_requests
+    ADD COLUMN fallback_node_ids UUID[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE routing_requests
+    DROP COLUMN IF EXISTS fallback_node_ids;
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL schema migrations so the supervisor can
+// apply them at startup.
+package migrations
+
+import "embed"
+
+// FS holds the numbered goose-style migration files.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,27 @@
+-- name: CreateCluster :one
+INSERT INTO clusters (name, description)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetClusterByID :one
+SELECT * FROM clusters WHERE id = $1;
+
+-- name: ListClusters :many
+SELECT * FROM clusters ORDER BY name;
+
+-- name: UpdateCluster :one
+UPDATE clusters
+SET name = $2, description = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteCluster :execrows
+DELETE FROM clusters WHERE id = $1;
+
+-- name: CountClusterNodes :many
+SELECT cluster_id, COUNT(*) AS node_count FROM nodes
+WHERE cluster_id IS NOT NULL AND deleted_at IS NULL
+GROUP BY cluster_id;
+
+-- name: CountNodesInCluster :one
+SELECT COUNT(*) FROM nodes WHERE cluster_id = $1 AND deleted_at IS NULL;
@@ -0,0 +1,17 @@
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE key = $1 AND expires_at > NOW();
+
+-- name: SaveIdempotencyKey :execrows
+INSERT INTO idempotency_keys (key, request_hash, status_code, response_body, expires_at)
+VALUES (
+    sqlc.arg(key), sqlc.arg(request_hash), sqlc.arg(status_code), sqlc.arg(response_body),
+    NOW() + sqlc.arg(ttl_seconds)::int * INTERVAL '1 second'
+)
+ON CONFLICT (key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash, status_code = EXCLUDED.status_code,
+    response_body = EXCLUDED.response_body, created_at = NOW(), expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at <= NOW();
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys WHERE expires_at <= NOW();
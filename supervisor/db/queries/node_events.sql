@@ -0,0 +1,13 @@
+-- name: CreateNodeEvent :one
+INSERT INTO node_events (node_id, event_type, actor, before, after)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListNodeEvents :many
+SELECT * FROM node_events
+WHERE node_id = sqlc.arg(node_id)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountNodeEvents :one
+SELECT COUNT(*) FROM node_events WHERE node_id = $1;
@@ -1,31 +1,128 @@
 -- name: CreateNode :one
-INSERT INTO nodes (name, location_x, location_y, endpoint, capacity, status)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO nodes (name, location_x, location_y, endpoint, capacity, status, weight, zone,
+    health_path, health_protocol, labels, cluster_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 RETURNING *;
 
 -- name: GetNodeByID :one
-SELECT * FROM nodes WHERE id = $1;
+SELECT * FROM nodes WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: GetAllNodes :many
-SELECT * FROM nodes ORDER BY created_at DESC;
+SELECT * FROM nodes WHERE deleted_at IS NULL ORDER BY created_at DESC;
 
 -- name: GetHealthyNodes :many
-SELECT * FROM nodes WHERE status = 'healthy' ORDER BY created_at DESC;
+SELECT * FROM nodes
+WHERE status = 'healthy'
+  AND (sqlc.narg(checked_since)::timestamp IS NULL OR last_health_check > sqlc.narg(checked_since))
+ORDER BY created_at DESC;
 
 -- name: UpdateNode :one
 UPDATE nodes 
 SET name = $2, location_x = $3, location_y = $4, endpoint = $5, capacity = $6, status = $7,
     cpu_usage = $8, memory_usage = $9, active_connections = $10,
-    last_health_check = $11, updated_at = NOW()
-WHERE id = $1
+    last_health_check = $11, weight = $12, zone = $13,
+    health_path = $14, health_protocol = $15, labels = $16, cluster_id = $17, updated_at = NOW(),
+    draining_since = CASE WHEN $7 = 'draining' THEN COALESCE(draining_since, NOW()) END
+WHERE id = $1 AND deleted_at IS NULL
 RETURNING *;
 
 -- name: UpdateNodeHealth :one
 UPDATE nodes 
-SET status = $2, cpu_usage = $3, memory_usage = $4, active_connections = $5,
-    last_health_check = $6, updated_at = NOW()
-WHERE id = $1
+SET status = CASE WHEN status = 'draining' THEN status ELSE sqlc.arg(status)::varchar END,
+    cpu_usage = sqlc.arg(cpu_usage), memory_usage = sqlc.arg(memory_usage),
+    active_connections = sqlc.arg(active_connections),
+    last_health_check = sqlc.arg(last_health_check), updated_at = NOW()
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
 RETURNING *;
 
--- name: DeleteNode :exec
-DELETE FROM nodes WHERE id = $1;
\ No newline at end of file
+-- name: DeleteNode :one
+DELETE FROM nodes WHERE id = $1
+RETURNING *;
+
+-- name: SoftDeleteNode :one
+UPDATE nodes
+SET status = 'deleted', deleted_at = NOW(), draining_since = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DrainNode :one
+UPDATE nodes
+SET status = 'draining',
+    draining_since = CASE WHEN status = 'draining' THEN draining_since ELSE NOW() END,
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SetNodeMaintenance :one
+UPDATE nodes
+SET maintenance = COALESCE(sqlc.narg(maintenance)::boolean, NOT maintenance), updated_at = NOW()
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteDrainedNodes :many
+UPDATE nodes
+SET status = 'deleted', deleted_at = NOW(), draining_since = NULL, updated_at = NOW()
+WHERE status = 'draining' AND draining_since <= $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeactivateUnhealthyNode :one
+UPDATE nodes
+SET status = 'inactive', updated_at = NOW()
+WHERE id = $1 AND status = 'unhealthy' AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SoftDeleteUnhealthyNode :one
+UPDATE nodes
+SET status = 'deleted', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'unhealthy' AND deleted_at IS NULL
+RETURNING *;
+
+-- name: CreateNodeIfAbsent :one
+INSERT INTO nodes (name, location_x, location_y, endpoint, capacity, status, weight, zone,
+    health_path, health_protocol, labels, cluster_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (endpoint) WHERE deleted_at IS NULL DO NOTHING
+RETURNING *;
+
+-- name: CountNodes :one
+SELECT COUNT(*) FROM nodes WHERE deleted_at IS NULL;
+
+-- name: CountHealthyNodes :one
+SELECT COUNT(*) FROM nodes WHERE status = 'healthy';
+
+-- name: RecordNodeHeartbeat :one
+UPDATE nodes
+SET status = CASE WHEN status = 'draining' THEN status ELSE 'healthy' END,
+    cpu_usage = $2, memory_usage = $3, active_connections = $4,
+    last_health_check = $5, last_heartbeat = $5, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL AND status <> 'inactive' AND NOT maintenance
+RETURNING *;
+
+-- name: ListNodes :many
+SELECT * FROM nodes
+WHERE (sqlc.arg(include_deleted)::boolean OR deleted_at IS NULL)
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(zone)::varchar IS NULL OR zone = sqlc.narg(zone))
+  AND (sqlc.narg(labels)::jsonb IS NULL OR labels @> sqlc.narg(labels))
+  AND (sqlc.narg(cluster_id)::uuid IS NULL OR cluster_id = sqlc.narg(cluster_id))
+ORDER BY created_at DESC, id
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountListNodes :one
+SELECT COUNT(*) FROM nodes
+WHERE (sqlc.arg(include_deleted)::boolean OR deleted_at IS NULL)
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(zone)::varchar IS NULL OR zone = sqlc.narg(zone))
+  AND (sqlc.narg(labels)::jsonb IS NULL OR labels @> sqlc.narg(labels))
+  AND (sqlc.narg(cluster_id)::uuid IS NULL OR cluster_id = sqlc.narg(cluster_id));
+
+-- name: GetClusterStats :one
+SELECT COUNT(*) AS total_nodes,
+    COUNT(*) FILTER (WHERE status = 'healthy') AS healthy_nodes,
+    COUNT(*) FILTER (WHERE status = 'degraded') AS degraded_nodes,
+    COUNT(*) FILTER (WHERE status = 'unhealthy') AS unhealthy_nodes,
+    COALESCE(AVG(cpu_usage) FILTER (WHERE status = 'healthy'), 0)::float8 AS avg_cpu_usage,
+    COALESCE(AVG(memory_usage) FILTER (WHERE status = 'healthy'), 0)::float8 AS avg_memory_usage,
+    COALESCE(SUM(active_connections), 0)::bigint AS active_connections
+FROM nodes
+WHERE deleted_at IS NULL;
@@ -0,0 +1,21 @@
+-- name: CreateRegistrationToken :one
+INSERT INTO registration_tokens (token_hash, created_by, expires_at)
+VALUES (
+    sqlc.arg(token_hash), sqlc.arg(created_by),
+    NOW() + sqlc.arg(ttl_seconds)::int * INTERVAL '1 second'
+)
+RETURNING *;
+
+-- name: GetRegistrationToken :one
+SELECT * FROM registration_tokens
+WHERE token_hash = $1 AND expires_at > NOW();
+
+-- name: UseRegistrationToken :one
+UPDATE registration_tokens
+SET used_at = NOW(), node_id = sqlc.arg(node_id)
+WHERE token_hash = sqlc.arg(token_hash) AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+-- name: GetNodeRegistrationToken :one
+SELECT * FROM registration_tokens
+WHERE token_hash = sqlc.arg(token_hash) AND node_id = sqlc.arg(node_id);
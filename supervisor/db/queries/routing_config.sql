@@ -0,0 +1,13 @@
+-- name: GetRoutingConfig :one
+SELECT * FROM routing_config WHERE id = 1;
+
+-- name: UpsertRoutingConfig :one
+INSERT INTO routing_config (id, k_nearest, max_distance, load_weight, distance_weight, updated_at)
+VALUES (1, $1, $2, $3, $4, NOW())
+ON CONFLICT (id) DO UPDATE
+SET k_nearest = EXCLUDED.k_nearest,
+    max_distance = EXCLUDED.max_distance,
+    load_weight = EXCLUDED.load_weight,
+    distance_weight = EXCLUDED.distance_weight,
+    updated_at = EXCLUDED.updated_at
+RETURNING *;
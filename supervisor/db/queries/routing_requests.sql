@@ -1,9 +1,10 @@
 -- name: CreateRoutingRequest :one
 INSERT INTO routing_requests (
     request_id, coordinates_x, coordinates_y, selected_node_id, 
-    distance, load_score, status, request_data, metadata, client_info
+    distance, load_score, status, request_data, metadata, client_info,
+    processing_metrics, response_time_ms, response_data, fallback_node_ids
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 RETURNING *;
 
 -- name: UpdateRoutingResponse :one
@@ -36,4 +37,40 @@ LIMIT $2;
 SELECT * FROM routing_requests 
 WHERE request_data @> $1::jsonb OR metadata @> $2::jsonb
 ORDER BY created_at DESC
-LIMIT $3;
\ No newline at end of file
+LIMIT $3;
+
+-- name: CountRoutingRequestsByNode :one
+SELECT COUNT(*) FROM routing_requests WHERE selected_node_id = $1;
+
+-- name: DeleteRoutingRequestsByNode :execrows
+DELETE FROM routing_requests WHERE selected_node_id = $1;
+
+-- name: GetRoutingRequestsSince :many
+SELECT * FROM routing_requests
+WHERE created_at >= $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: DeleteRoutingRequestsBefore :execrows
+DELETE FROM routing_requests WHERE id IN (
+    SELECT id FROM routing_requests WHERE created_at < $1 LIMIT $2
+);
+
+-- name: ReportRoutingFailure :one
+UPDATE routing_requests
+SET failed_node_id = sqlc.arg(failed_node_id), failure_reason = sqlc.narg(failure_reason),
+    failure_reported_at = NOW()
+WHERE id = (
+    SELECT id FROM routing_requests
+    WHERE request_id = sqlc.arg(request_id)
+    ORDER BY created_at DESC
+    LIMIT 1
+)
+  AND (selected_node_id = sqlc.arg(failed_node_id) OR sqlc.arg(failed_node_id) = ANY(fallback_node_ids))
+RETURNING *;
+
+-- name: GetRoutingRequestByRequestID :one
+SELECT * FROM routing_requests
+WHERE request_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
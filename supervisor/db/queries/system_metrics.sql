@@ -6,4 +6,25 @@ RETURNING *;
 -- name: GetRecentSystemMetrics :many
 SELECT * FROM system_metrics 
 ORDER BY timestamp DESC 
-LIMIT $1;
\ No newline at end of file
+LIMIT $1;
+
+-- name: DeleteSystemMetricsByNode :execrows
+DELETE FROM system_metrics WHERE node_id = $1;
+
+-- name: GetNodeMetricsSince :many
+SELECT * FROM system_metrics
+WHERE node_id = sqlc.arg(node_id) AND timestamp >= sqlc.arg(since)
+ORDER BY timestamp ASC;
+
+-- name: GetLatestSystemMetrics :many
+SELECT * FROM system_metrics
+WHERE (node_id, metric_type, timestamp) IN (
+    SELECT node_id, metric_type, MAX(timestamp) FROM system_metrics
+    GROUP BY node_id, metric_type
+)
+ORDER BY node_id, metric_type;
+
+-- name: DeleteSystemMetricsBefore :execrows
+DELETE FROM system_metrics WHERE id IN (
+    SELECT id FROM system_metrics WHERE timestamp < $1 LIMIT $2
+);
@@ -0,0 +1,44 @@
+// Package docs serves the OpenAPI description of the supervisor API.
+// swagger.json is generated from the handler annotations; run `make docs`
+// after changing them.
+package docs
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:generate swag init --dir .. --generalInfo cmd/main.go --output . --outputTypes json
+
+//go:embed swagger.json
+var spec []byte
+
+// swaggerUI loads Swagger UI from a CDN and points it at the spec.
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Arx Supervisor API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/docs/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// Register serves the spec at /docs/openapi.json and Swagger UI at /docs.
+func Register(r gin.IRoutes) {
+	r.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUI))
+	})
+	r.GET("/docs/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", spec)
+	})
+}
@@ -1,20 +1,40 @@
 package api
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/config"
 	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/events"
+	"arx-supervisor/internal/health"
+	"arx-supervisor/internal/logging"
 	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/retention"
+	"arx-supervisor/internal/routing"
 	"arx-supervisor/internal/websocket"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type AdminHandler struct {
-	db    *database.Database
-	wsHub *websocket.Hub
+	db      *database.Database
+	router  *routing.Service
+	wsHub   *websocket.Hub
+	events  *events.Recorder
+	monitor *health.Monitor
+	pruner  *retention.Pruner
+	logger  *slog.Logger
 }
 
 type CreateNodeRequest struct {
@@ -22,151 +42,1226 @@ type CreateNodeRequest struct {
 	Location models.Location `json:"location" binding:"required"`
 	Endpoint string          `json:"endpoint" binding:"required"`
 	Capacity int             `json:"capacity"`
+	// Weight defaults to 1; 0 makes the node a standby
+	Weight *int   `json:"weight,omitempty" binding:"omitempty,min=0"`
+	Zone   string `json:"zone,omitempty" binding:"max=100"`
+	// HealthProtocol defaults to the endpoint's scheme and HealthPath to
+	// /health; tcp probes only connect and ignore the path
+	HealthPath     string `json:"health_path,omitempty" binding:"omitempty,startswith=/,max=255"`
+	HealthProtocol string `json:"health_protocol,omitempty" binding:"omitempty,oneof=http https tcp"`
+
+	Labels map[string]string `json:"labels,omitempty" binding:"omitempty,max=32,dive,keys,min=1,max=63,endkeys,max=255"`
+	// ClusterID places the node in an existing cluster
+	ClusterID string `json:"cluster_id,omitempty" binding:"omitempty,uuid"`
+}
+
+// BulkCreateNodesResponse lists the nodes created by a bulk request and the
+// items that were rejected, identified by their index in the request.
+type BulkCreateNodesResponse struct {
+	Created []models.Node   `json:"created"`
+	Errors  []BulkItemError `json:"errors"`
+}
+
+type BulkItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
 }
 
+// ImmutableNodeFields are the node fields no update may change. They may be
+// sent back unchanged, so a node fetched from the API can be edited and
+// PUT as a whole, but a different value is rejected.
+type ImmutableNodeFields struct {
+	ID        *uuid.UUID `json:"id,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// ReplaceNodeRequest is the full representation of a node sent with PUT.
+// Omitted fields reset to their defaults as on create. Status is runtime
+// state, like the load stats, and is kept when omitted.
+type ReplaceNodeRequest struct {
+	CreateNodeRequest
+	ImmutableNodeFields
+	Status string `json:"status,omitempty"`
+}
+
+// UpdateNodeRequest changes the fields present and keeps the others, for
+// PATCH.
 type UpdateNodeRequest struct {
+	ImmutableNodeFields
 	Name     *string          `json:"name,omitempty"`
 	Location *models.Location `json:"location,omitempty"`
 	Endpoint *string          `json:"endpoint,omitempty"`
 	Capacity *int             `json:"capacity,omitempty"`
-	Status   *string          `json:"status,omitempty"`
+	Weight   *int             `json:"weight,omitempty" binding:"omitempty,min=0"`
+	Zone     *string          `json:"zone,omitempty" binding:"omitempty,max=100"`
+	// Status is set by health checks, which would overwrite any change on
+	// the next probe, so it may only be sent back unchanged
+	Status *string `json:"status,omitempty"`
+
+	HealthPath     *string `json:"health_path,omitempty" binding:"omitempty,startswith=/,max=255"`
+	HealthProtocol *string `json:"health_protocol,omitempty" binding:"omitempty,oneof=http https tcp"`
+
+	// Labels replaces every label of the node; {} removes them all
+	Labels map[string]string `json:"labels,omitempty" binding:"omitempty,max=32,dive,keys,min=1,max=63,endkeys,max=255"`
+	// ClusterID moves the node to another cluster; "" takes it out of its
+	// cluster
+	ClusterID *string `json:"cluster_id,omitempty"`
+}
+
+// MaintenanceRequest sets a node's maintenance flag; without a body the flag
+// is toggled.
+type MaintenanceRequest struct {
+	Maintenance *bool `json:"maintenance,omitempty"`
+}
+
+// HealthCheckSummary is the outcome of a forced health check of every node.
+type HealthCheckSummary struct {
+	Checked int                  `json:"checked"`
+	Healthy int                  `json:"healthy"`
+	Results []health.CheckResult `json:"results"`
+}
+
+// RoutingConfigRequest updates routing weights; omitted fields keep their
+// current values.
+type RoutingConfigRequest struct {
+	KNearest       *int     `json:"k_nearest,omitempty"`
+	MaxDistance    *float64 `json:"max_distance,omitempty"`
+	LoadWeight     *float64 `json:"load_weight,omitempty"`
+	DistanceWeight *float64 `json:"distance_weight,omitempty"`
+}
+
+type RoutingConfigResponse struct {
+	KNearest       int     `json:"k_nearest"`
+	MaxDistance    float64 `json:"max_distance"`
+	LoadWeight     float64 `json:"load_weight"`
+	DistanceWeight float64 `json:"distance_weight"`
+	DistanceMode   string  `json:"distance_mode"`
+	Strategy       string  `json:"strategy"`
+
+	CoordinateSystem string           `json:"coordinate_system"`
+	LoadScoreWeights LoadScoreWeights `json:"load_score_weights"`
+}
+
+// LoadScoreWeights are the shares of CPU, memory and connections in node load
+// scores, set with the LOAD_SCORE_*_WEIGHT variables.
+type LoadScoreWeights struct {
+	CPU         float64 `json:"cpu"`
+	Memory      float64 `json:"memory"`
+	Connections float64 `json:"connections"`
+}
+
+// defaultLatencyWindow is the window of GET /admin/api/v1/metrics/latency
+// when none is given.
+const defaultLatencyWindow = time.Hour
+
+// LatencyMetrics reports routing response time percentiles, in milliseconds,
+// over the requests created since Since. The percentiles are null when no
+// request in the window has a response time.
+type LatencyMetrics struct {
+	Window string    `json:"window"`
+	Since  time.Time `json:"since"`
+	Count  int64     `json:"count"`
+	P50    *float64  `json:"p50_ms"`
+	P90    *float64  `json:"p90_ms"`
+	P99    *float64  `json:"p99_ms"`
 }
 
+// maxDashboardRequests caps the recent requests returned with dashboard
+// metrics.
+const maxDashboardRequests = 500
+
+// DashboardMetrics summarises the cluster: node counts, the latest routing
+// requests, the newest reading of each metric for every node and the load
+// distribution across healthy nodes.
 type DashboardMetrics struct {
 	TotalNodes     int64                   `json:"total_nodes"`
 	HealthyNodes   int64                   `json:"healthy_nodes"`
 	RecentRequests []models.RoutingRequest `json:"recent_requests"`
 	SystemMetrics  []models.SystemMetric   `json:"system_metrics"`
+	Histograms     NodeHistograms          `json:"histograms"`
 }
 
-func NewAdminHandler(db *database.Database, wsHub *websocket.Hub) *AdminHandler {
+func NewAdminHandler(db *database.Database, router *routing.Service, wsHub *websocket.Hub, recorder *events.Recorder, monitor *health.Monitor, pruner *retention.Pruner, logger *slog.Logger) *AdminHandler {
 	return &AdminHandler{
-		db:    db,
-		wsHub: wsHub,
+		db:      db,
+		router:  router,
+		wsHub:   wsHub,
+		events:  recorder,
+		monitor: monitor,
+		pruner:  pruner,
+		logger:  logger,
 	}
 }
 
 // GET /admin/api/v1/nodes
+//
+// @Summary List nodes
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Maximum nodes to return, at most 500" default(100)
+// @Param offset query int false "Nodes to skip"
+// @Param status query string false "Only return nodes with this status"
+// @Param zone query string false "Only return nodes in this zone"
+// @Param label query []string false "Only return nodes with this key=value label; repeat to require several" collectionFormat(multi)
+// @Param cluster query string false "Only return nodes of the cluster with this ID" format(uuid)
+// @Param include_deleted query bool false "Include soft-deleted nodes"
+// @Success 200 {object} NodeList
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes [get]
 func (h *AdminHandler) GetAllNodes(c *gin.Context) {
-	// This would get all nodes from database
-	// For now, return empty list
-	c.JSON(http.StatusOK, []models.Node{})
+	params, ok := nodeListParams(c, true)
+	if !ok {
+		return
+	}
+
+	list, err := listNodes(c.Request.Context(), h.db.Queries, params)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch nodes")
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
 }
 
 // POST /admin/api/v1/nodes
+//
+// @Summary Create a node
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param node body CreateNodeRequest true "Node to create"
+// @Param skip_probe query bool false "Accept the node without probing its /health endpoint"
+// @Success 201 {object} models.Node
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 409 {object} apierror.Response "Endpoint already registered"
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes [post]
 func (h *AdminHandler) CreateNode(c *gin.Context) {
 	var req CreateNodeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondValidationError(c, err)
+		return
+	}
+	if !validLocation(c, h.router, "location", req.Location) || !normalEndpoint(c, &req.Endpoint) {
+		return
+	}
+	params := createNodeParams(req)
+	if !reachableEndpoint(c, h.monitor, params.Endpoint, params.HealthProtocol, params.HealthPath) {
 		return
 	}
 
-	// Set default capacity if not provided
-	capacity := req.Capacity
-	if capacity == 0 {
-		capacity = 100
+	node, err := createNode(c.Request.Context(), h.db, params)
+	if err != nil {
+		switch {
+		case database.IsUniqueViolation(err):
+			respondError(c, http.StatusConflict, apierror.CodeEndpointConflict, "A node with this endpoint already exists")
+		case database.IsForeignKeyViolation(err):
+			respondError(c, http.StatusNotFound, apierror.CodeClusterNotFound, "Cluster not found")
+		default:
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create node")
+		}
+		return
 	}
 
-	// Create node in database would go here
-	// For now, return a mock response
-	nodeUUID := uuid.New()
-	mockNode := models.Node{
-		ID:        nodeUUID,
-		Name:      req.Name,
-		LocationX: req.Location.X,
-		LocationY: req.Location.Y,
-		Endpoint:  req.Endpoint,
-		Capacity:  capacity,
-		Status:    "inactive",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	h.router.InvalidateIndex()
+
+	// Record and broadcast the change
+	h.events.Record(c.Request.Context(), events.Event{
+		NodeID: node.ID,
+		Type:   "node_created",
+		Actor:  adminActor(c),
+		After:  &node,
+	})
+
+	c.JSON(http.StatusCreated, node)
+}
+
+// POST /admin/api/v1/nodes/bulk
+//
+// @Summary Create nodes in bulk
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param nodes body []CreateNodeRequest true "Nodes to create, at most 100"
+// @Param atomic query bool false "Reject the whole batch if any item fails"
+// @Success 201 {object} BulkCreateNodesResponse "All nodes created"
+// @Success 200 {object} BulkCreateNodesResponse "Some items were rejected"
+// @Failure 400 {object} BulkCreateNodesResponse "Invalid items in an atomic batch"
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 409 {object} BulkCreateNodesResponse "Duplicate endpoint in an atomic batch"
+// @Failure 413 {object} apierror.Response "Batch too large"
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/bulk [post]
+func (h *AdminHandler) BulkCreateNodes(c *gin.Context) {
+	var reqs []CreateNodeRequest
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchBodyBytes)
+	if err := json.NewDecoder(body).Decode(&reqs); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge,
+				fmt.Sprintf("A batch body may be at most %d bytes", maxBatchBodyBytes))
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Request body must be a JSON array of nodes")
+		return
+	}
+	if len(reqs) == 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "No nodes to create")
+		return
+	}
+	if len(reqs) > maxBulkNodes {
+		respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, fmt.Sprintf("At most %d nodes can be created per request", maxBulkNodes))
+		return
 	}
+	atomic := c.Query("atomic") == "true"
 
-	// Broadcast update
-	h.wsHub.Broadcast <- websocket.Message{
-		Type: "node_created",
-		Data: mockNode,
+	// Validate everything up front so an atomic batch fails before touching
+	// the database
+	resp := BulkCreateNodesResponse{Created: []models.Node{}, Errors: []BulkItemError{}}
+	valid := make([]int, 0, len(reqs))
+	geographic := h.router.Geographic()
+	ctx := c.Request.Context()
+	clusters, err := h.clusterIDs(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch clusters")
+		return
+	}
+	for i, req := range reqs {
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			resp.Errors = append(resp.Errors, BulkItemError{Index: i, Error: err.Error()})
+			continue
+		}
+		if err := req.Location.Validate(geographic); err != nil {
+			resp.Errors = append(resp.Errors, BulkItemError{Index: i, Error: "location." + err.Error()})
+			continue
+		}
+		endpoint, err := models.NormalizeEndpoint(req.Endpoint)
+		if err != nil {
+			resp.Errors = append(resp.Errors, BulkItemError{Index: i, Error: err.Error()})
+			continue
+		}
+		// An unknown cluster would abort the whole transaction below
+		if req.ClusterID != "" && !clusters[uuid.MustParse(req.ClusterID)] {
+			resp.Errors = append(resp.Errors, BulkItemError{Index: i, Error: "Cluster not found"})
+			continue
+		}
+		reqs[i].Endpoint = endpoint
+		valid = append(valid, i)
+	}
+	if atomic && len(resp.Errors) > 0 {
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	actor := adminActor(c)
+	err = h.db.WithTx(ctx, func(q db.Querier) error {
+		for _, i := range valid {
+			created, err := q.CreateNodeIfAbsent(ctx, db.CreateNodeIfAbsentParams(createNodeParams(reqs[i])))
+			if database.IsNotFound(err) {
+				// The endpoint is already registered
+				resp.Errors = append(resp.Errors, BulkItemError{Index: i, Error: "A node with this endpoint already exists"})
+				if atomic {
+					return errBulkConflict
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			node := routing.ConvertDBNodeToModel(created)
+			if err := h.events.Store(ctx, q, events.Event{
+				NodeID: node.ID,
+				Type:   "node_created",
+				Actor:  actor,
+				After:  &node,
+			}); err != nil {
+				return err
+			}
+			resp.Created = append(resp.Created, node)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errBulkConflict) {
+			resp.Created = []models.Node{}
+			c.JSON(http.StatusConflict, resp)
+			return
+		}
+		h.logger.ErrorContext(ctx, "Failed to bulk create nodes", "error", err)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create nodes")
+		return
 	}
 
-	c.JSON(http.StatusCreated, mockNode)
+	if len(resp.Created) > 0 {
+		h.router.InvalidateIndex()
+
+		// Each node's event is stored with it; clients get one summary
+		h.wsHub.Publish(websocket.Message{
+			Type: "nodes_bulk_created",
+			Data: gin.H{"count": len(resp.Created)},
+		})
+	}
+
+	status := http.StatusCreated
+	if len(resp.Errors) > 0 {
+		status = http.StatusOK
+	}
+	c.JSON(status, resp)
 }
 
 // PUT /admin/api/v1/nodes/:id
+//
+// @Summary Replace a node
+// @Description Replaces the node with the full representation sent. Omitted
+// @Description fields reset to their defaults; id and created_at cannot be
+// @Description changed. Status and load stats are kept unless status is sent.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Node ID" format(uuid)
+// @Param node body ReplaceNodeRequest true "Node"
+// @Success 200 {object} models.Node
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 409 {object} apierror.Response "Endpoint already registered"
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/{id} [put]
+func (h *AdminHandler) ReplaceNode(c *gin.Context) {
+	nodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
+		return
+	}
+
+	var req ReplaceNodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if !validLocation(c, h.router, "location", req.Location) || !normalEndpoint(c, &req.Endpoint) {
+		return
+	}
+	if req.Status != "" && !models.IsValidNodeStatus(req.Status) {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid status: "+req.Status)
+		return
+	}
+
+	existing, ok := h.nodeForUpdate(c, nodeID, req.ImmutableNodeFields)
+	if !ok {
+		return
+	}
+
+	created := createNodeParams(req.CreateNodeRequest)
+	params := db.UpdateNodeParams{
+		ID:                existing.ID,
+		Name:              created.Name,
+		LocationX:         created.LocationX,
+		LocationY:         created.LocationY,
+		Endpoint:          created.Endpoint,
+		Capacity:          created.Capacity,
+		Status:            existing.Status,
+		CpuUsage:          existing.CpuUsage,
+		MemoryUsage:       existing.MemoryUsage,
+		ActiveConnections: existing.ActiveConnections,
+		LastHealthCheck:   existing.LastHealthCheck,
+		Weight:            created.Weight,
+		Zone:              created.Zone,
+		HealthPath:        created.HealthPath,
+		HealthProtocol:    created.HealthProtocol,
+		Labels:            created.Labels,
+		ClusterID:         created.ClusterID,
+	}
+	if req.Status != "" {
+		params.Status = pgtype.Text{String: req.Status, Valid: true}
+	}
+
+	h.saveNode(c, existing, params)
+}
+
+// PATCH /admin/api/v1/nodes/:id
+//
+// @Summary Update a node
+// @Description Changes the fields sent and keeps the others. id,
+// @Description created_at and status cannot be changed.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Node ID" format(uuid)
+// @Param node body UpdateNodeRequest true "Fields to change"
+// @Success 200 {object} models.Node
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 409 {object} apierror.Response "Endpoint already registered"
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/{id} [patch]
 func (h *AdminHandler) UpdateNode(c *gin.Context) {
 	idStr := c.Param("id")
 	nodeID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid node ID"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
 		return
 	}
 
-	// Check if node exists would go here
-	// For now, just return a mock response
-
 	var req UpdateNodeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondValidationError(c, err)
 		return
 	}
+	if req.Location != nil && !validLocation(c, h.router, "location", *req.Location) {
+		return
+	}
+	if req.Endpoint != nil && !normalEndpoint(c, req.Endpoint) {
+		return
+	}
+
+	var cluster pgtype.UUID
+	if req.ClusterID != nil && *req.ClusterID != "" {
+		id, err := uuid.Parse(*req.ClusterID)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid cluster ID")
+			return
+		}
+		cluster = pgtype.UUID{Bytes: id, Valid: true}
+	}
 
-	// Update node in database would go here
-	// For now, return a mock response
-	mockNode := models.Node{
-		ID:        nodeID,
-		Name:      "Updated Node",
-		LocationX: 0.0,
-		LocationY: 0.0,
-		Endpoint:  "http://updated-endpoint",
-		Capacity:  100,
-		Status:    "active",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	existing, ok := h.nodeForUpdate(c, nodeID, req.ImmutableNodeFields)
+	if !ok {
+		return
+	}
+	if req.Status != nil && *req.Status != existing.Status.String {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "status cannot be changed; drain the node or put it in maintenance instead")
+		return
+	}
+
+	// Only apply the fields present in the request
+	params := db.UpdateNodeParams{
+		ID:                existing.ID,
+		Name:              existing.Name,
+		LocationX:         existing.LocationX,
+		LocationY:         existing.LocationY,
+		Endpoint:          existing.Endpoint,
+		Capacity:          existing.Capacity,
+		Status:            existing.Status,
+		CpuUsage:          existing.CpuUsage,
+		MemoryUsage:       existing.MemoryUsage,
+		ActiveConnections: existing.ActiveConnections,
+		LastHealthCheck:   existing.LastHealthCheck,
+		Weight:            existing.Weight,
+		Zone:              existing.Zone,
+		HealthPath:        existing.HealthPath,
+		HealthProtocol:    existing.HealthProtocol,
+		Labels:            existing.Labels,
+		ClusterID:         existing.ClusterID,
+	}
+	if req.Name != nil {
+		params.Name = *req.Name
+	}
+	if req.Location != nil {
+		params.LocationX = req.Location.X
+		params.LocationY = req.Location.Y
+	}
+	if req.Endpoint != nil {
+		params.Endpoint = *req.Endpoint
+	}
+	if req.Capacity != nil {
+		params.Capacity = pgtype.Int4{Int32: int32(*req.Capacity), Valid: true}
+	}
+	if req.Weight != nil {
+		params.Weight = int32(*req.Weight)
+	}
+	if req.Zone != nil {
+		params.Zone = *req.Zone
+	}
+	if req.HealthPath != nil {
+		params.HealthPath = *req.HealthPath
+	}
+	if req.HealthProtocol != nil {
+		params.HealthProtocol = *req.HealthProtocol
+	}
+	if req.Labels != nil {
+		params.Labels = models.EncodeLabels(req.Labels)
+	}
+	if req.ClusterID != nil {
+		params.ClusterID = cluster
 	}
 
-	// Broadcast update
-	h.wsHub.Broadcast <- websocket.Message{
-		Type: "node_updated",
-		Data: mockNode,
+	h.saveNode(c, existing, params)
+}
+
+// nodeForUpdate fetches the node an update applies to and checks that the
+// update leaves its immutable fields alone, writing a 404 or 400 otherwise.
+func (h *AdminHandler) nodeForUpdate(c *gin.Context, nodeID uuid.UUID, immutable ImmutableNodeFields) (db.Node, bool) {
+	existing, err := h.db.Queries.GetNodeByID(c.Request.Context(), pgtype.UUID{Bytes: nodeID, Valid: true})
+	if err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeNodeNotFound, "Node not found")
+			return db.Node{}, false
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch node")
+		return db.Node{}, false
 	}
 
-	c.JSON(http.StatusOK, mockNode)
+	if immutable.ID != nil && *immutable.ID != nodeID {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "id cannot be changed")
+		return db.Node{}, false
+	}
+	if immutable.CreatedAt != nil && !immutable.CreatedAt.Equal(existing.CreatedAt.Time) {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "created_at cannot be changed")
+		return db.Node{}, false
+	}
+	return existing, true
+}
+
+// saveNode writes an updated node and records the change from existing.
+func (h *AdminHandler) saveNode(c *gin.Context, existing db.Node, params db.UpdateNodeParams) {
+	updated, err := h.db.Queries.UpdateNode(c.Request.Context(), params)
+	if err != nil {
+		switch {
+		case database.IsUniqueViolation(err):
+			respondError(c, http.StatusConflict, apierror.CodeEndpointConflict, "A node with this endpoint already exists")
+		case database.IsForeignKeyViolation(err):
+			respondError(c, http.StatusNotFound, apierror.CodeClusterNotFound, "Cluster not found")
+		default:
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update node")
+		}
+		return
+	}
+	node := routing.ConvertDBNodeToModel(updated)
+	h.router.InvalidateIndex()
+
+	// Record and broadcast the change
+	before := routing.ConvertDBNodeToModel(existing)
+	h.events.Record(c.Request.Context(), events.Event{
+		NodeID: node.ID,
+		Type:   "node_updated",
+		Actor:  adminActor(c),
+		Before: &before,
+		After:  &node,
+	})
+
+	c.JSON(http.StatusOK, node)
 }
 
 // DELETE /admin/api/v1/nodes/:id
+//
+// @Summary Delete a node
+// @Description Soft-deletes the node by default: it gets status deleted and
+// @Description a deleted_at timestamp, leaves listings and routing, and keeps
+// @Description its routing requests. hard=true removes the row instead.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Node ID" format(uuid)
+// @Param hard query bool false "Physically delete the node"
+// @Param cascade query bool false "With hard=true, also delete the node's routing requests and metrics"
+// @Success 204
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 409 {object} apierror.Response "Node is referenced by routing requests"
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/{id} [delete]
 func (h *AdminHandler) DeleteNode(c *gin.Context) {
 	idStr := c.Param("id")
 	nodeID, err := uuid.Parse(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid node ID"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
+		return
+	}
+
+	hard := c.Query("hard") == "true"
+	cascade := c.Query("cascade") == "true"
+	id := pgtype.UUID{Bytes: nodeID, Valid: true}
+
+	ctx := c.Request.Context()
+	if !hard {
+		h.softDeleteNode(c, nodeID)
 		return
 	}
 
-	// Delete node from database would go here
+	var removed db.Node
+	err = h.db.WithTx(ctx, func(q db.Querier) error {
+		if cascade {
+			if _, err := q.DeleteRoutingRequestsByNode(ctx, id); err != nil {
+				return err
+			}
+			if _, err := q.DeleteSystemMetricsByNode(ctx, id); err != nil {
+				return err
+			}
+		} else {
+			references, err := q.CountRoutingRequestsByNode(ctx, id)
+			if err != nil {
+				return err
+			}
+			if references > 0 {
+				return errNodeReferenced
+			}
+		}
 
-	// Broadcast update
-	h.wsHub.Broadcast <- websocket.Message{
-		Type: "node_deleted",
-		Data: gin.H{"node_id": nodeID},
+		removed, err = q.DeleteNode(ctx, id)
+		if database.IsNotFound(err) {
+			return errNodeNotFound
+		}
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errNodeNotFound):
+			respondError(c, http.StatusNotFound, apierror.CodeNodeNotFound, "Node not found")
+		case errors.Is(err, errNodeReferenced), database.IsForeignKeyViolation(err):
+			respondError(c, http.StatusConflict, apierror.CodeNodeReferenced, "Node is referenced by routing requests; retry with ?hard=true&cascade=true to delete them")
+		default:
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete node")
+		}
+		return
 	}
 
+	h.router.InvalidateIndex()
+
+	// Record and broadcast the change
+	before := routing.ConvertDBNodeToModel(removed)
+	h.events.Record(ctx, events.Event{
+		NodeID: nodeID,
+		Type:   "node_deleted",
+		Actor:  adminActor(c),
+		Before: &before,
+		Data:   gin.H{"node_id": nodeID},
+	})
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// softDeleteNode marks the node deleted, keeping its row and history.
+func (h *AdminHandler) softDeleteNode(c *gin.Context, nodeID uuid.UUID) {
+	ctx := c.Request.Context()
+	before := h.nodeSnapshot(ctx, nodeID)
+	deleted, err := h.db.Queries.SoftDeleteNode(ctx, pgtype.UUID{Bytes: nodeID, Valid: true})
+	if err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeNodeNotFound, "Node not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete node")
+		return
+	}
+
+	h.router.InvalidateIndex()
+
+	// Record and broadcast the change
+	after := routing.ConvertDBNodeToModel(deleted)
+	h.events.Record(ctx, events.Event{
+		NodeID: nodeID,
+		Type:   "node_deleted",
+		Actor:  adminActor(c),
+		Before: before,
+		After:  &after,
+		Data:   gin.H{"node_id": nodeID},
+	})
+
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// POST /admin/api/v1/nodes/:id/drain
+//
+// @Summary Drain a node
+// @Tags admin
+// @Produce json
+// @Param id path string true "Node ID" format(uuid)
+// @Success 200 {object} models.Node
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/{id}/drain [post]
+func (h *AdminHandler) DrainNode(c *gin.Context) {
+	nodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
+		return
+	}
+
+	ctx := c.Request.Context()
+	before := h.nodeSnapshot(ctx, nodeID)
+	drained, err := h.db.Queries.DrainNode(ctx, pgtype.UUID{Bytes: nodeID, Valid: true})
+	if err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeNodeNotFound, "Node not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to drain node")
+		return
+	}
+	node := routing.ConvertDBNodeToModel(drained)
+	h.router.InvalidateIndex()
+
+	// Record and broadcast the change
+	h.events.Record(ctx, events.Event{
+		NodeID: nodeID,
+		Type:   "node_draining",
+		Actor:  adminActor(c),
+		Before: before,
+		After:  &node,
+	})
+
+	c.JSON(http.StatusOK, node)
+}
+
+// POST /admin/api/v1/nodes/:id/maintenance
+//
+// @Summary Set or toggle a node's maintenance mode
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Node ID" format(uuid)
+// @Param request body MaintenanceRequest false "Maintenance flag; toggled when omitted"
+// @Success 200 {object} models.Node
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/{id}/maintenance [post]
+func (h *AdminHandler) SetNodeMaintenance(c *gin.Context) {
+	nodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
+		return
+	}
+
+	var req MaintenanceRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondValidationError(c, err)
+			return
+		}
+	}
+
+	params := db.SetNodeMaintenanceParams{ID: pgtype.UUID{Bytes: nodeID, Valid: true}}
+	if req.Maintenance != nil {
+		params.Maintenance = pgtype.Bool{Bool: *req.Maintenance, Valid: true}
+	}
+	ctx := c.Request.Context()
+	before := h.nodeSnapshot(ctx, nodeID)
+	updated, err := h.db.Queries.SetNodeMaintenance(ctx, params)
+	if err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeNodeNotFound, "Node not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update node maintenance")
+		return
+	}
+	node := routing.ConvertDBNodeToModel(updated)
+	h.router.InvalidateIndex()
+
+	// Record and broadcast the change
+	h.events.Record(ctx, events.Event{
+		NodeID: nodeID,
+		Type:   "node_maintenance_changed",
+		Actor:  adminActor(c),
+		Before: before,
+		After:  &node,
+	})
+
+	c.JSON(http.StatusOK, node)
+}
+
+// POST /admin/api/v1/nodes/:id/healthcheck
+//
+// @Summary Health check a node now
+// @Description Probes the node immediately, ignoring its backoff and circuit
+// @Description breaker, and stores the result like a scheduled check. A failed
+// @Description probe is reported in probe_error with HTTP 200.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Node ID" format(uuid)
+// @Success 200 {object} health.CheckResult
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/{id}/healthcheck [post]
+func (h *AdminHandler) CheckNodeHealth(c *gin.Context) {
+	nodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
+		return
+	}
+
+	ctx := c.Request.Context()
+	result, err := h.monitor.CheckNode(ctx, nodeID)
+	if err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeNodeNotFound, "Node not found")
+			return
+		}
+		h.logger.ErrorContext(ctx, "Failed to health check node",
+			"request_id", logging.RequestID(ctx), "node_id", nodeID, "error", err)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to health check node")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /admin/api/v1/healthcheck
+//
+// @Summary Health check every node now
+// @Description Runs a health check pass over all nodes immediately, ignoring
+// @Description backoff and circuit breakers, and waits for it to finish.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} HealthCheckSummary
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/healthcheck [post]
+func (h *AdminHandler) CheckAllNodesHealth(c *gin.Context) {
+	ctx := c.Request.Context()
+	results, err := h.monitor.CheckAll(ctx)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to health check nodes",
+			"request_id", logging.RequestID(ctx), "error", err)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to health check nodes")
+		return
+	}
+
+	summary := HealthCheckSummary{Checked: len(results), Results: results}
+	for _, result := range results {
+		if result.Node.Status == models.NodeStatusHealthy {
+			summary.Healthy++
+		}
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// GET /admin/api/v1/nodes/:id/metrics
+//
+// @Summary Node metric history
+// @Tags admin
+// @Produce json
+// @Param id path string true "Node ID" format(uuid)
+// @Param since query string false "RFC3339 start time; defaults to one hour ago" format(date-time)
+// @Success 200 {array} models.SystemMetric
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/{id}/metrics [get]
+func (h *AdminHandler) GetNodeMetrics(c *gin.Context) {
+	nodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
+		return
+	}
+
+	// Default to the last hour of history
+	since := time.Now().UTC().Add(-time.Hour)
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err = time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid since, expected RFC3339 timestamp")
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	id := pgtype.UUID{Bytes: nodeID, Valid: true}
+	if _, err := h.db.Queries.GetNodeByID(ctx, id); err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeNodeNotFound, "Node not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch node")
+		return
+	}
+
+	rows, err := h.db.Queries.GetNodeMetricsSince(ctx, db.GetNodeMetricsSinceParams{
+		NodeID: id,
+		Since:  pgtype.Timestamp{Time: since.UTC(), Valid: true},
+	})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch node metrics")
+		return
+	}
+
+	metrics := make([]models.SystemMetric, len(rows))
+	for i, row := range rows {
+		metrics[i] = convertDBSystemMetric(row)
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
+// GET /admin/api/v1/config/routing
+//
+// @Summary Get routing configuration
+// @Tags admin
+// @Produce json
+// @Success 200 {object} RoutingConfigResponse
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Router /admin/api/v1/config/routing [get]
+func (h *AdminHandler) GetRoutingConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, newRoutingConfigResponse(h.router.Config()))
+}
+
+// PUT /admin/api/v1/config/routing
+//
+// @Summary Update routing configuration
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param config body RoutingConfigRequest true "Fields to change"
+// @Success 200 {object} RoutingConfigResponse
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/config/routing [put]
+func (h *AdminHandler) UpdateRoutingConfig(c *gin.Context) {
+	var req RoutingConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	cfg := h.router.Config()
+	if req.KNearest != nil {
+		cfg.KNearest = *req.KNearest
+	}
+	if req.MaxDistance != nil {
+		cfg.MaxDistance = *req.MaxDistance
+	}
+	if req.LoadWeight != nil {
+		cfg.LoadWeight = *req.LoadWeight
+	}
+	if req.DistanceWeight != nil {
+		cfg.DistanceWeight = *req.DistanceWeight
+	}
+
+	if err := routing.ValidateConfig(cfg); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, err.Error())
+		return
+	}
+
+	updated, err := h.router.UpdateConfig(c.Request.Context(), cfg)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update routing config")
+		return
+	}
+
+	response := newRoutingConfigResponse(updated)
+	h.wsHub.Publish(websocket.Message{
+		Type: "routing_config_updated",
+		Data: response,
+	})
+
+	c.JSON(http.StatusOK, response)
+}
+
+func newRoutingConfigResponse(cfg config.RoutingConfig) RoutingConfigResponse {
+	return RoutingConfigResponse{
+		KNearest:       cfg.KNearest,
+		MaxDistance:    cfg.MaxDistance,
+		LoadWeight:     cfg.LoadWeight,
+		DistanceWeight: cfg.DistanceWeight,
+		DistanceMode:   cfg.DistanceMode,
+		Strategy:       cfg.Strategy,
+
+		CoordinateSystem: cfg.CoordinateSystem,
+		LoadScoreWeights: LoadScoreWeights{
+			CPU:         cfg.LoadScoreWeights.CPU,
+			Memory:      cfg.LoadScoreWeights.Memory,
+			Connections: cfg.LoadScoreWeights.Connections,
+		},
+	}
+}
+
+// GET /admin/api/v1/metrics/latency
+//
+// @Summary Routing response time percentiles
+// @Tags admin
+// @Produce json
+// @Param window query string false "How far back to look, e.g. 15m or 24h" default(1h)
+// @Success 200 {object} LatencyMetrics
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/metrics/latency [get]
+func (h *AdminHandler) GetLatencyMetrics(c *gin.Context) {
+	window := defaultLatencyWindow
+	if windowStr := c.Query("window"); windowStr != "" {
+		var err error
+		window, err = time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid window, expected a duration such as 15m or 24h")
+			return
+		}
+	}
+
+	since := time.Now().UTC().Add(-window)
+	percentiles, err := h.db.ResponseTimePercentiles(c.Request.Context(), since)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute latency percentiles")
+		return
+	}
+
+	c.JSON(http.StatusOK, LatencyMetrics{
+		Window: window.String(),
+		Since:  since,
+		Count:  percentiles.Count,
+		P50:    percentiles.P50,
+		P90:    percentiles.P90,
+		P99:    percentiles.P99,
+	})
+}
+
 // GET /admin/api/v1/dashboard/metrics
+//
+// @Summary Dashboard metrics
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Maximum recent requests, up to 500" default(50)
+// @Param window query string false "Only include requests from this far back, e.g. 15m or 24h"
+// @Param buckets query int false "Buckets per load histogram, up to 100" default(10)
+// @Success 200 {object} DashboardMetrics
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/dashboard/metrics [get]
 func (h *AdminHandler) GetDashboardMetrics(c *gin.Context) {
-	// Get metrics from database would go here
-	// For now, return mock data
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid limit")
+		return
+	}
+	if limit > maxDashboardRequests {
+		limit = maxDashboardRequests
+	}
+
+	buckets, err := strconv.Atoi(c.DefaultQuery("buckets", strconv.Itoa(defaultHistogramBuckets)))
+	if err != nil || buckets <= 0 || buckets > maxHistogramBuckets {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("Invalid buckets, expected 1 to %d", maxHistogramBuckets))
+		return
+	}
+
+	var window time.Duration
+	if windowStr := c.Query("window"); windowStr != "" {
+		window, err = time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid window, expected a duration such as 15m or 24h")
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	totalNodes, err := h.db.Queries.CountNodes(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count nodes")
+		return
+	}
+	healthyNodes, err := h.db.Queries.CountHealthyNodes(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count nodes")
+		return
+	}
+
+	var requests []db.RoutingRequest
+	if window > 0 {
+		requests, err = h.db.Queries.GetRoutingRequestsSince(ctx, db.GetRoutingRequestsSinceParams{
+			CreatedAt: pgtype.Timestamp{Time: time.Now().UTC().Add(-window), Valid: true},
+			Limit:     int32(limit),
+		})
+	} else {
+		requests, err = h.db.Queries.GetRecentRoutingRequests(ctx, int32(limit))
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch routing requests")
+		return
+	}
+
+	systemMetrics, err := h.db.Queries.GetLatestSystemMetrics(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch system metrics")
+		return
+	}
+
+	// Every healthy node is shown, however long ago it was checked
+	dbHealthy, err := h.db.Queries.GetHealthyNodes(ctx, pgtype.Timestamp{})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch nodes")
+		return
+	}
+	healthy := make([]models.Node, len(dbHealthy))
+	for i, node := range dbHealthy {
+		healthy[i] = routing.ConvertDBNodeToModel(node)
+	}
+
 	metrics := DashboardMetrics{
-		TotalNodes:     5,
-		HealthyNodes:   3,
-		RecentRequests: []models.RoutingRequest{},
-		SystemMetrics:  []models.SystemMetric{},
+		TotalNodes:     totalNodes,
+		HealthyNodes:   healthyNodes,
+		RecentRequests: make([]models.RoutingRequest, len(requests)),
+		SystemMetrics:  make([]models.SystemMetric, len(systemMetrics)),
+		Histograms:     nodeHistograms(healthy, buckets),
+	}
+	for i, request := range requests {
+		metrics.RecentRequests[i] = convertDBRoutingRequest(request)
+	}
+	for i, metric := range systemMetrics {
+		metrics.SystemMetrics[i] = convertDBSystemMetric(metric)
 	}
 
 	c.JSON(http.StatusOK, metrics)
 }
 
 // GET /admin/api/v1/requests/export
+//
+// @Summary Export routing requests
+// @Tags admin
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "Export format" Enums(csv, json) default(csv)
+// @Param limit query int false "Maximum rows" default(1000)
+// @Param from query string false "RFC3339 lower bound on created_at" format(date-time)
+// @Param to query string false "RFC3339 upper bound on created_at" format(date-time)
+// @Success 200 {array} models.RoutingRequest
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/requests/export [get]
 func (h *AdminHandler) ExportRequests(c *gin.Context) {
 	// Parse query parameters
 	limitStr := c.DefaultQuery("limit", "1000")
@@ -175,9 +1270,168 @@ func (h *AdminHandler) ExportRequests(c *gin.Context) {
 		limit = 1000
 	}
 
-	// Get requests from database would go here
-	// For now, return empty list
+	from, ok := parseTimeQuery(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseTimeQuery(c, "to")
+	if !ok {
+		return
+	}
+	filter := database.RoutingRequestFilter{From: from, To: to, Limit: limit}
+
+	switch c.DefaultQuery("format", "csv") {
+	case "csv":
+		h.exportRequestsCSV(c, filter)
+	case "json":
+		h.exportRequestsJSON(c, filter)
+	default:
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid format, expected csv or json")
+	}
+}
+
+// GET /admin/api/v1/requests/:id
+//
+// @Summary Get a routing request
+// @Description Returns a recorded routing request together with the audit of
+// @Description its routing decision: the nodes considered, why each one was
+// @Description passed over and the winning score.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Routing request ID" format(uuid)
+// @Success 200 {object} RoutingRequestDetail
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/requests/{id} [get]
+func (h *AdminHandler) GetRoutingRequest(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid routing request ID")
+		return
+	}
+
+	request, err := h.db.Queries.GetRoutingRequestByID(c.Request.Context(), pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeRequestNotFound, "Routing request not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch routing request")
+		return
+	}
+
+	detail := RoutingRequestDetail{RoutingRequest: convertDBRoutingRequest(request)}
+	if len(request.ProcessingMetrics) > 0 {
+		var decision routing.Decision
+		if err := json.Unmarshal(request.ProcessingMetrics, &decision); err == nil && decision.Outcome != "" {
+			detail.Decision = &decision
+		}
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// exportRequestsCSV streams matching routing requests as a CSV attachment,
+// flushing periodically so large exports are never held in memory.
+func (h *AdminHandler) exportRequestsCSV(c *gin.Context, filter database.RoutingRequestFilter) {
+	const flushEvery = 100
+
+	// Large exports can outlast SERVER_WRITE_TIMEOUT, so lift it for this
+	// response; the client going away still cancels the stream
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.WarnContext(c.Request.Context(), "Failed to lift write deadline for export",
+			"request_id", logging.RequestID(c.Request.Context()), "error", err)
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="routing_requests.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(exportCSVHeader)
+
+	written := 0
+	err := h.db.StreamRoutingRequests(c.Request.Context(), filter, func(request db.RoutingRequest) error {
+		if err := w.Write(exportCSVRecord(request)); err != nil {
+			return err
+		}
+		written++
+		if written%flushEvery == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+		return w.Error()
+	})
+
+	w.Flush()
+	c.Writer.Flush()
+	if err != nil {
+		// Headers are already sent, so the client sees a truncated file
+		h.logger.ErrorContext(c.Request.Context(), "Routing request export aborted",
+			"request_id", logging.RequestID(c.Request.Context()), "rows", written, "error", err)
+	}
+}
+
+func (h *AdminHandler) exportRequestsJSON(c *gin.Context, filter database.RoutingRequestFilter) {
 	requests := []models.RoutingRequest{}
+	err := h.db.StreamRoutingRequests(c.Request.Context(), filter, func(request db.RoutingRequest) error {
+		requests = append(requests, convertDBRoutingRequest(request))
+		return nil
+	})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch routing requests")
+		return
+	}
 
 	c.JSON(http.StatusOK, requests)
 }
+
+// POST /admin/api/v1/maintenance/prune
+//
+// @Summary Prune old routing requests and metrics
+// @Description Deletes routing requests and system metrics older than days,
+// @Description which defaults to RETENTION_DAYS. Rows are deleted in batches.
+// @Tags admin
+// @Produce json
+// @Param days query int false "Retention period in days; required when RETENTION_DAYS is not set"
+// @Success 200 {object} retention.Result
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 409 {object} apierror.Response "A prune is already running"
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/maintenance/prune [post]
+func (h *AdminHandler) PruneOldRows(c *gin.Context) {
+	days := h.pruner.Days()
+	if daysStr := c.Query("days"); daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 1 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid days, expected a positive integer")
+			return
+		}
+	}
+	if days < 1 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "RETENTION_DAYS is not set; pass ?days=")
+		return
+	}
+
+	ctx := c.Request.Context()
+	result, err := h.pruner.Prune(ctx, days)
+	if err != nil {
+		if errors.Is(err, retention.ErrPruneInProgress) {
+			respondError(c, http.StatusConflict, apierror.CodeConflict, "A prune is already running")
+			return
+		}
+		h.logger.ErrorContext(ctx, "Failed to prune old rows",
+			"request_id", logging.RequestID(ctx), "error", err)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to prune old rows")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
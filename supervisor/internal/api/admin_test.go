@@ -0,0 +1,87 @@
+package api
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db/dbtest"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBulkCreateNodesRejectsLargeBatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{
+			name:   "body over the limit",
+			body:   `[{"name": "` + strings.Repeat("a", maxBatchBodyBytes) + `"}]`,
+			status: http.StatusRequestEntityTooLarge,
+			code:   apierror.CodePayloadTooLarge,
+		},
+		{
+			name:   "too many nodes",
+			body:   "[" + strings.Repeat("{},", maxBulkNodes) + "{}]",
+			status: http.StatusRequestEntityTooLarge,
+			code:   apierror.CodePayloadTooLarge,
+		},
+		{
+			name:   "empty batch",
+			body:   "[]",
+			status: http.StatusBadRequest,
+			code:   apierror.CodeValidation,
+		},
+		{
+			name:   "not an array",
+			body:   `{"name": "node"}`,
+			status: http.StatusBadRequest,
+			code:   apierror.CodeValidation,
+		},
+	}
+
+	// Each case is rejected before the database is used
+	h := NewAdminHandler(nil, nil, nil, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	r := gin.New()
+	r.POST("/admin/api/v1/nodes/bulk", h.BulkCreateNodes)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodPost, "/admin/api/v1/nodes/bulk", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %.200s", w.Code, tt.status, w.Body.String())
+			}
+			if code := errorCode(t, w); code != tt.code {
+				t.Errorf("code = %q, want %q", code, tt.code)
+			}
+		})
+	}
+}
+
+func TestUpdateNodeRejectsStatusChange(t *testing.T) {
+	node := dbtest.Node("node", 1, 1)
+
+	// Status belongs to health checks and the drain endpoint, so any change
+	// is refused before the node is saved
+	for _, status := range []string{"inactive", "unhealthy", "draining", "unknown"} {
+		t.Run(status, func(t *testing.T) {
+			h := NewAdminHandler(&database.Database{Queries: dbtest.New(node)}, nil, nil, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			r := gin.New()
+			r.PATCH("/admin/api/v1/nodes/:id", h.UpdateNode)
+
+			w := serve(r, http.MethodPatch, "/admin/api/v1/nodes/"+node.ID.String(), `{"name": "renamed", "status": "`+status+`"}`)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+			}
+			if code := errorCode(t, w); code != apierror.CodeValidation {
+				t.Errorf("code = %q, want %q", code, apierror.CodeValidation)
+			}
+		})
+	}
+}
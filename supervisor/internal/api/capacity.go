@@ -0,0 +1,158 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/routing"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CapacityReport is the connection capacity of the healthy nodes outside
+// maintenance, with a breakdown per zone when any node has one.
+type CapacityReport struct {
+	CapacitySummary
+	Zones     []ZoneCapacity `json:"zones,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// ZoneCapacity is the capacity of the nodes in one zone; nodes without a
+// zone are grouped under "".
+type ZoneCapacity struct {
+	Zone string `json:"zone"`
+	CapacitySummary
+}
+
+// CapacitySummary totals the capacity and connections of a set of nodes.
+// SpareConnections is every free connection slot, while Headroom is how
+// many more connections fit before the first node saturates if they are
+// spread like the current ones, or in proportion to capacity while there
+// are none. Bottleneck is that first node.
+type CapacitySummary struct {
+	Nodes             int                 `json:"nodes"`
+	SaturatedNodes    int                 `json:"saturated_nodes"`
+	TotalCapacity     int                 `json:"total_capacity"`
+	ActiveConnections int                 `json:"active_connections"`
+	Utilization       float64             `json:"utilization"`
+	SpareConnections  int                 `json:"spare_connections"`
+	Headroom          int                 `json:"headroom"`
+	Bottleneck        *CapacityBottleneck `json:"bottleneck,omitempty"`
+}
+
+// CapacityBottleneck is the node that saturates first as load grows.
+type CapacityBottleneck struct {
+	NodeID uuid.UUID `json:"node_id"`
+	Name   string    `json:"name"`
+	Zone   string    `json:"zone,omitempty"`
+}
+
+// summarizeCapacity totals nodes and projects their headroom. Nodes without
+// capacity take no connections and are only counted.
+func summarizeCapacity(nodes []models.Node) CapacitySummary {
+	summary := CapacitySummary{Nodes: len(nodes)}
+	for _, node := range nodes {
+		if node.Capacity <= 0 {
+			continue
+		}
+		summary.TotalCapacity += node.Capacity
+		summary.ActiveConnections += node.ActiveConnections
+		summary.SpareConnections += max(node.Capacity-node.ActiveConnections, 0)
+		if routing.IsSaturated(node) {
+			summary.SaturatedNodes++
+		}
+	}
+	if summary.TotalCapacity == 0 {
+		return summary
+	}
+	summary.Utilization = float64(summary.ActiveConnections) / float64(summary.TotalCapacity)
+
+	// Extra load x gives each node x times its share of the current load;
+	// the headroom is the x at which the first node reaches capacity. The
+	// share is kept as a fraction so the headroom is exact rather than one
+	// short after float rounding
+	for _, node := range nodes {
+		if node.Capacity <= 0 {
+			continue
+		}
+		share, of := node.Capacity, summary.TotalCapacity
+		if summary.ActiveConnections > 0 {
+			share, of = node.ActiveConnections, summary.ActiveConnections
+		}
+		if share <= 0 {
+			continue
+		}
+		fits := int(int64(max(node.Capacity-node.ActiveConnections, 0)) * int64(of) / int64(share))
+		if summary.Bottleneck == nil || fits < summary.Headroom {
+			summary.Headroom = fits
+			summary.Bottleneck = &CapacityBottleneck{NodeID: node.ID, Name: node.Name, Zone: node.Zone}
+		}
+	}
+	return summary
+}
+
+// capacityReport summarizes nodes as a whole and, when any node has a zone,
+// per zone.
+func capacityReport(nodes []models.Node, now time.Time) CapacityReport {
+	report := CapacityReport{CapacitySummary: summarizeCapacity(nodes), Timestamp: now.UTC()}
+
+	byZone := make(map[string][]models.Node)
+	zoned := false
+	for _, node := range nodes {
+		byZone[node.Zone] = append(byZone[node.Zone], node)
+		zoned = zoned || node.Zone != ""
+	}
+	if !zoned {
+		return report
+	}
+
+	report.Zones = make([]ZoneCapacity, 0, len(byZone))
+	for zone, zoneNodes := range byZone {
+		report.Zones = append(report.Zones, ZoneCapacity{Zone: zone, CapacitySummary: summarizeCapacity(zoneNodes)})
+	}
+	sort.Slice(report.Zones, func(i, j int) bool {
+		return report.Zones[i].Zone < report.Zones[j].Zone
+	})
+	return report
+}
+
+// GET /admin/api/v1/capacity
+//
+// @Summary Project cluster capacity headroom
+// @Description Totals capacity and active connections over the healthy nodes
+// @Description outside maintenance and estimates the headroom: how many more
+// @Description connections fit before the first node saturates if they are
+// @Description spread like the current ones. Zones are broken down separately
+// @Description when any node has one.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} CapacityReport
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/capacity [get]
+func (h *AdminHandler) GetCapacity(c *gin.Context) {
+	// Every healthy node counts, however long ago it was checked
+	rows, err := h.db.Queries.GetHealthyNodes(c.Request.Context(), pgtype.Timestamp{})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch nodes")
+		return
+	}
+
+	nodes := make([]models.Node, 0, len(rows))
+	for _, row := range rows {
+		node := routing.ConvertDBNodeToModel(row)
+		if node.Maintenance {
+			// Nodes in maintenance take no traffic
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+
+	c.JSON(http.StatusOK, capacityReport(nodes, time.Now()))
+}
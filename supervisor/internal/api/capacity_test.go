@@ -0,0 +1,153 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"arx-supervisor/internal/models"
+	"github.com/google/uuid"
+)
+
+func capacityNode(name, zone string, capacity, active int) models.Node {
+	return models.Node{
+		ID:                uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)),
+		Name:              name,
+		Zone:              zone,
+		Capacity:          capacity,
+		ActiveConnections: active,
+		Status:            models.NodeStatusHealthy,
+	}
+}
+
+func TestSummarizeCapacity(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []models.Node
+		want  CapacitySummary
+		// bottleneck is the name of the first node to saturate, empty for
+		// none
+		bottleneck string
+	}{
+		{
+			name: "no nodes",
+			want: CapacitySummary{},
+		},
+		{
+			name:       "idle cluster spreads by capacity",
+			nodes:      []models.Node{capacityNode("small", "", 100, 0), capacityNode("large", "", 300, 0)},
+			want:       CapacitySummary{Nodes: 2, TotalCapacity: 400, SpareConnections: 400, Headroom: 400},
+			bottleneck: "small",
+		},
+		{
+			name:       "load spreads like the current connections",
+			nodes:      []models.Node{capacityNode("busy", "", 100, 50), capacityNode("quiet", "", 100, 10)},
+			want:       CapacitySummary{Nodes: 2, TotalCapacity: 200, ActiveConnections: 60, Utilization: 0.3, SpareConnections: 140, Headroom: 60},
+			bottleneck: "busy",
+		},
+		{
+			// Both nodes are half full, so each takes 14 more; float division
+			// put the headroom at 13.999...
+			name:       "headroom is exact",
+			nodes:      []models.Node{capacityNode("a", "", 10, 5), capacityNode("b", "", 18, 9)},
+			want:       CapacitySummary{Nodes: 2, TotalCapacity: 28, ActiveConnections: 14, Utilization: 0.5, SpareConnections: 14, Headroom: 14},
+			bottleneck: "a",
+		},
+		{
+			name:       "idle node takes no share of the load",
+			nodes:      []models.Node{capacityNode("busy", "", 100, 90), capacityNode("idle", "", 100, 0)},
+			want:       CapacitySummary{Nodes: 2, TotalCapacity: 200, ActiveConnections: 90, Utilization: 0.45, SpareConnections: 110, Headroom: 10},
+			bottleneck: "busy",
+		},
+		{
+			name:       "saturated node leaves no headroom",
+			nodes:      []models.Node{capacityNode("full", "", 100, 100), capacityNode("idle", "", 100, 0)},
+			want:       CapacitySummary{Nodes: 2, SaturatedNodes: 1, TotalCapacity: 200, ActiveConnections: 100, Utilization: 0.5, SpareConnections: 100},
+			bottleneck: "full",
+		},
+		{
+			name:       "overloaded node has no spare connections",
+			nodes:      []models.Node{capacityNode("over", "", 10, 15), capacityNode("light", "", 100, 5)},
+			want:       CapacitySummary{Nodes: 2, SaturatedNodes: 1, TotalCapacity: 110, ActiveConnections: 20, Utilization: 20.0 / 110, SpareConnections: 95},
+			bottleneck: "over",
+		},
+		{
+			name:       "zero-capacity nodes are only counted",
+			nodes:      []models.Node{capacityNode("none", "", 0, 7), capacityNode("node", "", 100, 20)},
+			want:       CapacitySummary{Nodes: 2, TotalCapacity: 100, ActiveConnections: 20, Utilization: 0.2, SpareConnections: 80, Headroom: 80},
+			bottleneck: "node",
+		},
+		{
+			name:  "only zero-capacity nodes",
+			nodes: []models.Node{capacityNode("none", "", 0, 0), capacityNode("negative", "", -5, 0)},
+			want:  CapacitySummary{Nodes: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizeCapacity(tt.nodes)
+
+			bottleneck := ""
+			if got.Bottleneck != nil {
+				bottleneck = got.Bottleneck.Name
+			}
+			if bottleneck != tt.bottleneck {
+				t.Errorf("bottleneck = %q, want %q", bottleneck, tt.bottleneck)
+			}
+			got.Bottleneck = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summary = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapacityReport(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	t.Run("no zones", func(t *testing.T) {
+		report := capacityReport([]models.Node{capacityNode("a", "", 100, 10), capacityNode("b", "", 100, 30)}, now)
+		if report.Zones != nil {
+			t.Errorf("zones = %+v, want none when no node has a zone", report.Zones)
+		}
+		if report.Nodes != 2 || report.TotalCapacity != 200 {
+			t.Errorf("summary = %+v, want both nodes", report.CapacitySummary)
+		}
+		if !report.Timestamp.Equal(now) || report.Timestamp.Location() != time.UTC {
+			t.Errorf("timestamp = %v, want %v in UTC", report.Timestamp, now)
+		}
+	})
+
+	t.Run("zones with an unzoned bucket", func(t *testing.T) {
+		nodes := []models.Node{
+			capacityNode("west-1", "us-west", 100, 50),
+			capacityNode("east-1", "us-east", 100, 0),
+			capacityNode("unzoned", "", 50, 25),
+			capacityNode("west-2", "us-west", 100, 10),
+		}
+		report := capacityReport(nodes, now)
+
+		if report.Nodes != 4 || report.TotalCapacity != 350 || report.ActiveConnections != 85 {
+			t.Errorf("summary = %+v, want every node", report.CapacitySummary)
+		}
+		var zones []string
+		for _, zone := range report.Zones {
+			zones = append(zones, zone.Zone)
+		}
+		if want := []string{"", "us-east", "us-west"}; !reflect.DeepEqual(zones, want) {
+			t.Fatalf("zones = %q, want %q", zones, want)
+		}
+
+		unzoned, east, west := report.Zones[0], report.Zones[1], report.Zones[2]
+		if unzoned.Nodes != 1 || unzoned.Headroom != 25 || unzoned.Bottleneck.Name != "unzoned" {
+			t.Errorf("unzoned = %+v, want the node without a zone", unzoned.CapacitySummary)
+		}
+		if east.Headroom != 100 || east.ActiveConnections != 0 {
+			t.Errorf("us-east = %+v, want the idle zone's full capacity", east.CapacitySummary)
+		}
+		if west.Nodes != 2 || west.Headroom != 60 || west.Bottleneck.Name != "west-1" {
+			t.Errorf("us-west = %+v, want headroom 60 limited by west-1", west.CapacitySummary)
+		}
+	})
+}
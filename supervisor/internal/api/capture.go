@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"arx-supervisor/internal/config"
+)
+
+// capturePayloadHeader opts a routing request into payload capture when
+// CAPTURE_PAYLOADS is opt-in, over HTTP and, lowercased, as gRPC metadata.
+const capturePayloadHeader = "X-Capture-Payload"
+
+// Capture modes, chosen with CAPTURE_PAYLOADS
+const (
+	CaptureOff    = "off"
+	CaptureOptIn  = "opt-in"
+	CaptureAlways = "always"
+)
+
+// redactedValue replaces the value of every redacted field.
+const redactedValue = "[REDACTED]"
+
+// PayloadCapture decides which routing requests are stored with their
+// responses and prepares both for storage. A nil PayloadCapture captures
+// nothing.
+type PayloadCapture struct {
+	mode     string
+	maxBytes int
+	redact   map[string]bool
+}
+
+// NewPayloadCapture validates cfg and returns the capture it describes.
+func NewPayloadCapture(cfg config.CaptureConfig) (*PayloadCapture, error) {
+	switch cfg.Mode {
+	case CaptureOff, CaptureOptIn, CaptureAlways:
+	default:
+		return nil, fmt.Errorf("capture mode must be %q, %q or %q", CaptureOff, CaptureOptIn, CaptureAlways)
+	}
+	if cfg.MaxBytes < 1 {
+		return nil, errors.New("capture size cap must be at least 1 byte")
+	}
+
+	redact := make(map[string]bool, len(cfg.RedactFields))
+	for _, field := range cfg.RedactFields {
+		redact[strings.ToLower(field)] = true
+	}
+	return &PayloadCapture{mode: cfg.Mode, maxBytes: cfg.MaxBytes, redact: redact}, nil
+}
+
+// Wants reports whether a request is captured, given the value of its
+// X-Capture-Payload header.
+func (p *PayloadCapture) Wants(header string) bool {
+	if p == nil {
+		return false
+	}
+	switch p.mode {
+	case CaptureAlways:
+		return true
+	case CaptureOptIn:
+		return header == "true" || header == "1"
+	}
+	return false
+}
+
+// Encode marshals a captured payload for storage, masking redacted fields at
+// any depth. Payloads over the size cap are replaced by a marker recording
+// their size, so a stored payload is always valid JSON.
+func (p *PayloadCapture) Encode(payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode captured payload: %w", err)
+	}
+	if len(p.redact) > 0 {
+		var decoded interface{}
+		if err := json.Unmarshal(body, &decoded); err == nil {
+			body, _ = json.Marshal(p.redactValue(decoded))
+		}
+	}
+	if len(body) > p.maxBytes {
+		body, _ = json.Marshal(map[string]interface{}{"truncated": true, "size": len(body)})
+	}
+	return body, nil
+}
+
+func (p *PayloadCapture) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if p.redact[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = p.redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = p.redactValue(item)
+		}
+	}
+	return value
+}
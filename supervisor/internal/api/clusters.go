@@ -0,0 +1,303 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/logging"
+	"arx-supervisor/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	errClusterNotFound = errors.New("cluster not found")
+	errClusterInUse    = errors.New("cluster has nodes")
+)
+
+// ClusterRequest creates a cluster, or replaces one with PUT.
+type ClusterRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description,omitempty" binding:"max=500"`
+}
+
+// clusterParam returns the cluster column value for a validated cluster ID,
+// NULL when it is empty.
+func clusterParam(id string) pgtype.UUID {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: parsed, Valid: true}
+}
+
+func convertDBCluster(cluster db.Cluster, nodeCount int64) models.Cluster {
+	return models.Cluster{
+		ID:          uuid.UUID(cluster.ID.Bytes),
+		Name:        cluster.Name,
+		Description: cluster.Description,
+		NodeCount:   nodeCount,
+		CreatedAt:   cluster.CreatedAt.Time,
+		UpdatedAt:   cluster.UpdatedAt.Time,
+	}
+}
+
+// clusterIDs returns the IDs of every cluster, so a batch can be checked
+// against them without one lookup per item.
+func (h *AdminHandler) clusterIDs(ctx context.Context) (map[uuid.UUID]bool, error) {
+	clusters, err := h.db.Queries.ListClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[uuid.UUID]bool, len(clusters))
+	for _, cluster := range clusters {
+		ids[uuid.UUID(cluster.ID.Bytes)] = true
+	}
+	return ids, nil
+}
+
+// clusterID parses the :id path parameter, writing a 400 if it is invalid.
+func clusterID(c *gin.Context) (pgtype.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid cluster ID")
+		return pgtype.UUID{}, false
+	}
+	return pgtype.UUID{Bytes: id, Valid: true}, true
+}
+
+// GET /admin/api/v1/clusters
+//
+// @Summary List clusters
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Cluster
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/clusters [get]
+func (h *AdminHandler) ListClusters(c *gin.Context) {
+	ctx := c.Request.Context()
+	clusters, err := h.db.Queries.ListClusters(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch clusters")
+		return
+	}
+	counts, err := h.db.Queries.CountClusterNodes(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch clusters")
+		return
+	}
+	nodeCounts := make(map[uuid.UUID]int64, len(counts))
+	for _, count := range counts {
+		nodeCounts[uuid.UUID(count.ClusterID.Bytes)] = count.NodeCount
+	}
+
+	out := make([]models.Cluster, len(clusters))
+	for i, cluster := range clusters {
+		out[i] = convertDBCluster(cluster, nodeCounts[uuid.UUID(cluster.ID.Bytes)])
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// GET /admin/api/v1/clusters/:id
+//
+// @Summary Get a cluster
+// @Tags admin
+// @Produce json
+// @Param id path string true "Cluster ID" format(uuid)
+// @Success 200 {object} models.Cluster
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/clusters/{id} [get]
+func (h *AdminHandler) GetCluster(c *gin.Context) {
+	id, ok := clusterID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	cluster, err := h.db.Queries.GetClusterByID(ctx, id)
+	if err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeClusterNotFound, "Cluster not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch cluster")
+		return
+	}
+	nodeCount, err := h.db.Queries.CountNodesInCluster(ctx, id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch cluster")
+		return
+	}
+
+	c.JSON(http.StatusOK, convertDBCluster(cluster, nodeCount))
+}
+
+// POST /admin/api/v1/clusters
+//
+// @Summary Create a cluster
+// @Description Nodes join the cluster through their cluster_id, and routing
+// @Description requests naming it are only routed to its nodes.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param cluster body ClusterRequest true "Cluster"
+// @Success 201 {object} models.Cluster
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 409 {object} apierror.Response "Name already taken"
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/clusters [post]
+func (h *AdminHandler) CreateCluster(c *gin.Context) {
+	var req ClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	cluster, err := h.db.Queries.CreateCluster(ctx, db.CreateClusterParams{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			respondError(c, http.StatusConflict, apierror.CodeClusterConflict, "A cluster with this name already exists")
+			return
+		}
+		h.logger.ErrorContext(ctx, "Failed to create cluster",
+			"request_id", logging.RequestID(ctx), "error", err)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create cluster")
+		return
+	}
+
+	c.JSON(http.StatusCreated, convertDBCluster(cluster, 0))
+}
+
+// PUT /admin/api/v1/clusters/:id
+//
+// @Summary Replace a cluster
+// @Description Renames the cluster and replaces its description. Its nodes
+// @Description stay in it.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Cluster ID" format(uuid)
+// @Param cluster body ClusterRequest true "Cluster"
+// @Success 200 {object} models.Cluster
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 409 {object} apierror.Response "Name already taken"
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/clusters/{id} [put]
+func (h *AdminHandler) UpdateCluster(c *gin.Context) {
+	id, ok := clusterID(c)
+	if !ok {
+		return
+	}
+	var req ClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	cluster, err := h.db.Queries.UpdateCluster(ctx, db.UpdateClusterParams{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		switch {
+		case database.IsNotFound(err):
+			respondError(c, http.StatusNotFound, apierror.CodeClusterNotFound, "Cluster not found")
+		case database.IsUniqueViolation(err):
+			respondError(c, http.StatusConflict, apierror.CodeClusterConflict, "A cluster with this name already exists")
+		default:
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update cluster")
+		}
+		return
+	}
+	nodeCount, err := h.db.Queries.CountNodesInCluster(ctx, id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch cluster")
+		return
+	}
+
+	c.JSON(http.StatusOK, convertDBCluster(cluster, nodeCount))
+}
+
+// DELETE /admin/api/v1/clusters/:id
+//
+// @Summary Delete a cluster
+// @Description Only empty clusters can be deleted; move or delete their nodes
+// @Description first. Soft-deleted nodes are taken out of the cluster.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Cluster ID" format(uuid)
+// @Success 204
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 409 {object} apierror.Response "Cluster still has nodes"
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/clusters/{id} [delete]
+func (h *AdminHandler) DeleteCluster(c *gin.Context) {
+	id, ok := clusterID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := h.db.WithTx(ctx, func(q db.Querier) error {
+		nodeCount, err := q.CountNodesInCluster(ctx, id)
+		if err != nil {
+			return err
+		}
+		if nodeCount > 0 {
+			return errClusterInUse
+		}
+		deleted, err := q.DeleteCluster(ctx, id)
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return errClusterNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errClusterNotFound):
+			respondError(c, http.StatusNotFound, apierror.CodeClusterNotFound, "Cluster not found")
+		case errors.Is(err, errClusterInUse):
+			respondError(c, http.StatusConflict, apierror.CodeClusterConflict, "Cluster still has nodes")
+		default:
+			h.logger.ErrorContext(ctx, "Failed to delete cluster",
+				"request_id", logging.RequestID(ctx), "error", err)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete cluster")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"arx-supervisor/internal/models"
+)
+
+// Bucket counts for dashboard histograms when the request does not choose
+// one, and the most it may ask for.
+const (
+	defaultHistogramBuckets = 10
+	maxHistogramBuckets     = 100
+)
+
+// Histogram counts values into equal-width buckets over [0, 100]. Bucket i
+// covers [Boundaries[i], Boundaries[i+1]); values at or above 100 fall in
+// the last bucket.
+type Histogram struct {
+	Boundaries []float64 `json:"boundaries"`
+	Counts     []int     `json:"counts"`
+}
+
+// NodeHistograms describes how load is distributed across healthy nodes, in
+// percent.
+type NodeHistograms struct {
+	CPUUsage              Histogram `json:"cpu_usage"`
+	MemoryUsage           Histogram `json:"memory_usage"`
+	ConnectionUtilization Histogram `json:"connection_utilization"`
+}
+
+func newHistogram(buckets int) Histogram {
+	h := Histogram{
+		Boundaries: make([]float64, buckets+1),
+		Counts:     make([]int, buckets),
+	}
+	for i := range h.Boundaries {
+		h.Boundaries[i] = 100 * float64(i) / float64(buckets)
+	}
+	return h
+}
+
+func (h *Histogram) add(value float64) {
+	buckets := len(h.Counts)
+	i := int(value / 100 * float64(buckets))
+	if i < 0 {
+		i = 0
+	}
+	if i >= buckets {
+		i = buckets - 1
+	}
+	h.Counts[i]++
+}
+
+// nodeHistograms buckets the CPU, memory and connection utilization of nodes.
+func nodeHistograms(nodes []models.Node, buckets int) NodeHistograms {
+	histograms := NodeHistograms{
+		CPUUsage:              newHistogram(buckets),
+		MemoryUsage:           newHistogram(buckets),
+		ConnectionUtilization: newHistogram(buckets),
+	}
+	for _, node := range nodes {
+		histograms.CPUUsage.add(node.CPUUsage)
+		histograms.MemoryUsage.add(node.MemoryUsage)
+		if node.Capacity > 0 {
+			histograms.ConnectionUtilization.add(100 * float64(node.ActiveConnections) / float64(node.Capacity))
+		}
+	}
+	return histograms
+}
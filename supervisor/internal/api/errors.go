@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Report binding failures under the JSON names clients send
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// respondError writes the standard error envelope.
+func respondError(c *gin.Context, status int, code, message string) {
+	apierror.Respond(c, status, code, message)
+}
+
+// respondValidationError writes a 400 VALIDATION_ERROR for a request that
+// failed to bind or validate, with per-field details where the error names
+// the fields.
+func respondValidationError(c *gin.Context, err error) {
+	respondFieldErrors(c, "", err)
+}
+
+// respondFieldErrors is respondValidationError with field names in the
+// details prefixed by prefix, e.g. "location." for a nested model.
+func respondFieldErrors(c *gin.Context, prefix string, err error) {
+	e := validationError(prefix, err)
+	apierror.RespondWithDetails(c, http.StatusBadRequest, e.Code, e.Message, e.Details)
+}
+
+// validationError describes a binding or validation failure as a
+// VALIDATION_ERROR, naming the offending fields in the details when it can.
+func validationError(prefix string, err error) apierror.Error {
+	var (
+		validationErrs validator.ValidationErrors
+		fieldErr       *models.FieldError
+		typeErr        *json.UnmarshalTypeError
+	)
+
+	details := map[string]interface{}{}
+	message := "Invalid request"
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			details[prefix+fieldPath(fe)] = validationMessage(fe)
+		}
+		message = "Request failed validation"
+	case errors.As(err, &fieldErr):
+		details[prefix+fieldErr.Field] = fieldErr.Message
+		message = prefix + fieldErr.Error()
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		details[prefix+typeErr.Field] = "must be " + jsonTypeName(typeErr.Type)
+		message = "Request body has a field of the wrong type"
+	case errors.As(err, new(*json.SyntaxError)), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		message = "Request body must be valid JSON"
+	}
+
+	if len(details) == 0 {
+		details = nil
+	}
+	return apierror.Error{Code: apierror.CodeValidation, Message: message, Details: details}
+}
+
+// fieldPath returns the dotted JSON path of a failed field without the name
+// of the top-level struct, e.g. "coordinates.x".
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// jsonTypeName describes a Go type the way a JSON client sees it.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+func validationMessage(fe validator.FieldError) string {
+	// Length limits on strings count characters
+	unit := ""
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return "must be at least " + fe.Param() + unit
+	case "max", "lte":
+		return "must be at most " + fe.Param() + unit
+	case "oneof":
+		return "must be one of " + fe.Param()
+	case "startswith":
+		return "must start with " + fe.Param()
+	}
+	return fmt.Sprintf("failed the %q check", fe.Tag())
+}
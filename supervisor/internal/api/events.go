@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/auth"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/events"
+	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/routing"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Page sizes for node event listings
+const (
+	defaultNodeEventLimit = 50
+	maxNodeEventLimit     = 500
+)
+
+// NodeEventList is one page of a node's events, newest first. Total counts
+// all of the node's events.
+type NodeEventList struct {
+	Items  []models.NodeEvent `json:"items"`
+	Total  int64              `json:"total"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+}
+
+// adminActor names the admin making the request for the node event log.
+func adminActor(c *gin.Context) string {
+	if claims, ok := c.Get(auth.ClaimsKey); ok {
+		if claims, ok := claims.(*auth.Claims); ok {
+			return events.AdminActor(claims.Subject)
+		}
+	}
+	return events.ActorAdmin
+}
+
+// nodeSnapshot returns the node as currently stored, for the before side of
+// an event, or nil if it cannot be read.
+func (h *AdminHandler) nodeSnapshot(ctx context.Context, nodeID uuid.UUID) *models.Node {
+	row, err := h.db.Queries.GetNodeByID(ctx, pgtype.UUID{Bytes: nodeID, Valid: true})
+	if err != nil {
+		return nil
+	}
+	node := routing.ConvertDBNodeToModel(row)
+	return &node
+}
+
+// GET /admin/api/v1/nodes/:id/events
+//
+// @Summary List a node's lifecycle events
+// @Description Returns the node's creations, updates, status changes,
+// @Description drains, maintenance changes and deletions, newest first, with
+// @Description the node before and after each one. Events outlive the node,
+// @Description so deleted nodes keep their history.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Node ID" format(uuid)
+// @Param limit query int false "Maximum events to return, at most 500" default(50)
+// @Param offset query int false "Events to skip"
+// @Success 200 {object} NodeEventList
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/{id}/events [get]
+func (h *AdminHandler) GetNodeEvents(c *gin.Context) {
+	nodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultNodeEventLimit)))
+	if err != nil || limit < 1 || limit > maxNodeEventLimit {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("Invalid limit, expected 1 to %d", maxNodeEventLimit))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid offset")
+		return
+	}
+
+	ctx := c.Request.Context()
+	id := pgtype.UUID{Bytes: nodeID, Valid: true}
+	rows, err := h.db.Queries.ListNodeEvents(ctx, db.ListNodeEventsParams{
+		NodeID:     id,
+		PageLimit:  int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch node events")
+		return
+	}
+	total, err := h.db.Queries.CountNodeEvents(ctx, id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch node events")
+		return
+	}
+
+	list := NodeEventList{
+		Items:  make([]models.NodeEvent, len(rows)),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	for i, row := range rows {
+		list.Items[i], err = events.ConvertDBEventToModel(row)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "Failed to decode node event", "event_id", uuid.UUID(row.ID.Bytes), "error", err)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch node events")
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, list)
+}
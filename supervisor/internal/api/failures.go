@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/logging"
+	"arx-supervisor/internal/metrics"
+	"arx-supervisor/internal/routing"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RouteFailureRequest reports the node that failed a routed request.
+type RouteFailureRequest struct {
+	NodeID string `json:"node_id" binding:"required"`
+	Reason string `json:"reason,omitempty" binding:"max=255"`
+	// Alternate asks for another node to retry the request on
+	Alternate bool `json:"alternate,omitempty"`
+}
+
+// RouteFailureResponse acknowledges a failure report. Alternate is the node
+// to retry on when one was asked for, omitted when no other node can take the
+// request.
+type RouteFailureResponse struct {
+	RequestID    string         `json:"request_id"`
+	FailedNodeID uuid.UUID      `json:"failed_node_id"`
+	ReportedAt   time.Time      `json:"reported_at"`
+	Alternate    *RouteResponse `json:"alternate,omitempty"`
+}
+
+// POST /api/v1/route/:request_id/failed
+//
+// @Summary Report a node that failed a routed request
+// @Description Records the failure on the latest routing request with this
+// @Description request ID and penalizes the node's load score for
+// @Description ROUTING_FAILURE_TTL seconds. The node must have been selected
+// @Description for the request or returned as one of its fallbacks. With
+// @Description alternate set, the request is routed again without the failed
+// @Description node.
+// @Tags routing
+// @Accept json
+// @Produce json
+// @Param request_id path string true "Request ID the request was routed with"
+// @Param failure body RouteFailureRequest true "Failed node"
+// @Param X-Routing-Seed header string false "Seed for routing the alternate instead of the request ID; for debugging"
+// @Success 200 {object} RouteFailureResponse
+// @Failure 400 {object} apierror.Response
+// @Failure 404 {object} apierror.Response "Unknown request or node"
+// @Failure 409 {object} apierror.Response "The node did not serve the request"
+// @Failure 429 {object} apierror.Response "Rate limit exceeded; see Retry-After"
+// @Failure 500 {object} apierror.Response
+// @Failure 504 {object} apierror.Response "Routing an alternate timed out"
+// @Router /api/v1/route/{request_id}/failed [post]
+func (h *PublicHandler) ReportRouteFailure(c *gin.Context) {
+	var req RouteFailureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	nodeID, err := uuid.Parse(req.NodeID)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
+		return
+	}
+	seed, ok := routingSeed(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.db.Queries.GetNodeByID(ctx, pgtype.UUID{Bytes: nodeID, Valid: true}); err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeNodeNotFound, "Node not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch node")
+		return
+	}
+
+	params := db.ReportRoutingFailureParams{
+		RequestID:    c.Param("request_id"),
+		FailedNodeID: pgtype.UUID{Bytes: nodeID, Valid: true},
+	}
+	if req.Reason != "" {
+		params.FailureReason = pgtype.Text{String: req.Reason, Valid: true}
+	}
+	row, err := h.db.Queries.ReportRoutingFailure(ctx, params)
+	if database.IsNotFound(err) {
+		// Either nothing was routed under this ID or the node did not serve
+		// it; only the first is unknown
+		_, err = h.db.Queries.GetRoutingRequestByRequestID(ctx, params.RequestID)
+		switch {
+		case database.IsNotFound(err):
+			respondError(c, http.StatusNotFound, apierror.CodeRequestNotFound, "Routing request not found")
+			return
+		case err == nil:
+			respondError(c, http.StatusConflict, apierror.CodeConflict, "The node was neither selected nor a fallback for this request")
+			return
+		}
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to record failure")
+		return
+	}
+
+	metrics.ReportedFailures.Inc()
+	h.router.ReportFailure(nodeID)
+
+	response := RouteFailureResponse{
+		RequestID:    row.RequestID,
+		FailedNodeID: nodeID,
+		ReportedAt:   row.FailureReportedAt.Time,
+	}
+	if !req.Alternate {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// Route again from the captured request, or from what its row records
+	// when it was not captured
+	var original RouteRequest
+	if err := json.Unmarshal(row.RequestData, &original); err != nil || original.RequestID == "" {
+		original = storedRouteRequest(row)
+	}
+	routeCtx, cancel := h.withTimeout(ctx)
+	defer cancel()
+	result, _, err := h.router.RouteRequest(routeCtx, routing.Request{
+		RequestID:      original.RequestID,
+		Coordinates:    original.Coordinates,
+		ClientID:       original.ClientID,
+		Priority:       routing.NormalizePriority(original.Priority),
+		PreferredZone:  original.PreferredZone,
+		RequiredLabels: original.RequiredLabels,
+		Cluster:        original.ClusterID(),
+		Exclude:        nodeID,
+		Seed:           seed,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to route alternate",
+			"request_id", logging.RequestID(ctx), "routing_request_id", row.RequestID, "error", err)
+		respondRoutingError(c, err)
+		return
+	}
+	if result != nil {
+		alternate := h.newRouteResponse(original, result, false)
+		response.Alternate = &alternate
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// storedRouteRequest rebuilds a routing request that was not captured from
+// its coordinates and the routing options kept in its metadata. Only the
+// client ID, and with it stickiness, is lost.
+func storedRouteRequest(row db.RoutingRequest) RouteRequest {
+	req := RouteRequest{RequestID: row.RequestID}
+	req.Coordinates.X = row.CoordinatesX
+	req.Coordinates.Y = row.CoordinatesY
+
+	var metadata struct {
+		Priority       string            `json:"priority"`
+		PreferredZone  string            `json:"preferred_zone"`
+		RequiredLabels map[string]string `json:"required_labels"`
+		Cluster        string            `json:"cluster"`
+	}
+	if err := json.Unmarshal(row.Metadata, &metadata); err == nil {
+		req.Priority = metadata.Priority
+		req.PreferredZone = metadata.PreferredZone
+		req.RequiredLabels = metadata.RequiredLabels
+		req.Cluster = metadata.Cluster
+	}
+	return req
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/routing"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// geoJSONContentType is the media type registered for GeoJSON (RFC 7946).
+const geoJSONContentType = "application/geo+json"
+
+// NodeFeatureCollection is the node topology as a GeoJSON FeatureCollection.
+// Skipped lists the nodes left out because their coordinates are not a
+// valid longitude and latitude.
+type NodeFeatureCollection struct {
+	Type     string        `json:"type" example:"FeatureCollection"`
+	Features []NodeFeature `json:"features"`
+	Skipped  []SkippedNode `json:"skipped,omitempty"`
+}
+
+// NodeFeature is one node as a GeoJSON Point feature.
+type NodeFeature struct {
+	Type       string         `json:"type" example:"Feature"`
+	ID         uuid.UUID      `json:"id"`
+	Geometry   PointGeometry  `json:"geometry"`
+	Properties NodeProperties `json:"properties"`
+}
+
+// PointGeometry is a GeoJSON Point, coordinates ordered [longitude, latitude].
+type PointGeometry struct {
+	Type        string     `json:"type" example:"Point"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type NodeProperties struct {
+	Name              string  `json:"name"`
+	Status            string  `json:"status"`
+	Zone              string  `json:"zone,omitempty"`
+	Maintenance       bool    `json:"maintenance"`
+	Capacity          int     `json:"capacity"`
+	ActiveConnections int     `json:"active_connections"`
+	CPUUsage          float64 `json:"cpu_usage"`
+	MemoryUsage       float64 `json:"memory_usage"`
+	LoadScore         float64 `json:"load_score"`
+}
+
+// SkippedNode names a node left out of a GeoJSON export and why.
+type SkippedNode struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Reason string    `json:"reason"`
+}
+
+// GET /admin/api/v1/nodes/export.geojson
+//
+// @Summary Export node topology as GeoJSON
+// @Description Returns every node as a Point feature at [location_x,
+// @Description location_y] read as [longitude, latitude]. Nodes outside the
+// @Description geographic range are left out and listed under skipped.
+// @Tags admin
+// @Produce application/geo+json
+// @Param status query string false "Only nodes with this status"
+// @Success 200 {object} NodeFeatureCollection
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/export.geojson [get]
+func (h *AdminHandler) ExportNodesGeoJSON(c *gin.Context) {
+	rows, err := h.db.Queries.GetAllNodes(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch nodes")
+		return
+	}
+
+	status := c.Query("status")
+	weights := h.router.Config().LoadScoreWeights
+	collection := NodeFeatureCollection{Type: "FeatureCollection", Features: []NodeFeature{}}
+	for _, row := range rows {
+		node := routing.ConvertDBNodeToModel(row)
+		if status != "" && node.Status != status {
+			continue
+		}
+
+		location := models.Location{X: node.LocationX, Y: node.LocationY}
+		if err := location.Validate(true); err != nil {
+			collection.Skipped = append(collection.Skipped, SkippedNode{ID: node.ID, Name: node.Name, Reason: err.Error()})
+			continue
+		}
+
+		// Nodes without capacity have no meaningful connection load
+		loadScore := 0.0
+		if node.Capacity > 0 {
+			loadScore = routing.CalculateLoadScore(node, weights)
+		}
+		collection.Features = append(collection.Features, NodeFeature{
+			Type:     "Feature",
+			ID:       node.ID,
+			Geometry: PointGeometry{Type: "Point", Coordinates: [2]float64{node.LocationX, node.LocationY}},
+			Properties: NodeProperties{
+				Name:              node.Name,
+				Status:            node.Status,
+				Zone:              node.Zone,
+				Maintenance:       node.Maintenance,
+				Capacity:          node.Capacity,
+				ActiveConnections: node.ActiveConnections,
+				CPUUsage:          node.CPUUsage,
+				MemoryUsage:       node.MemoryUsage,
+				LoadScore:         loadScore,
+			},
+		})
+	}
+
+	body, err := json.Marshal(collection)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to encode nodes")
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="nodes.geojson"`)
+	c.Data(http.StatusOK, geoJSONContentType, body)
+}
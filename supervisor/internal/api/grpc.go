@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/events"
+	"arx-supervisor/internal/logging"
+	"arx-supervisor/internal/metrics"
+	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/routing"
+	"arx-supervisor/internal/rpc/arxpb"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPCServer serves the arx.v1.Routing gRPC service. It runs on top of the
+// public HTTP handler so both protocols validate, record and broadcast
+// requests the same way. Errors are returned as apierror.Error values, which
+// gRPC converts into statuses.
+type GRPCServer struct {
+	arxpb.UnimplementedRoutingServer
+	h *PublicHandler
+}
+
+func NewGRPCServer(public *PublicHandler) *GRPCServer {
+	return &GRPCServer{h: public}
+}
+
+func (s *GRPCServer) Route(ctx context.Context, in *arxpb.RouteRequest) (*arxpb.RouteResponse, error) {
+	received := time.Now()
+	req := RouteRequest{
+		RequestID:      in.GetRequestId(),
+		Coordinates:    locationFromProto(in.GetCoordinates()),
+		Priority:       in.GetPriority(),
+		ClientID:       in.GetClientId(),
+		PreferredZone:  in.GetPreferredZone(),
+		RequiredLabels: in.GetRequiredLabels(),
+		Cluster:        in.GetCluster(),
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, validationError("", err)
+	}
+	if err := req.Coordinates.Validate(s.h.router.Geographic()); err != nil {
+		return nil, validationError("coordinates.", err)
+	}
+	seed := metadataValue(ctx, routingSeedHeader)
+	if len(seed) > maxRoutingSeedLength {
+		return nil, apierror.Error{Code: apierror.CodeValidation, Message: errRoutingSeedTooLong.Error()}
+	}
+
+	ctx, cancel := s.h.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	result, decision, err := s.h.router.RouteRequest(ctx, routing.Request{
+		RequestID:      req.RequestID,
+		Coordinates:    req.Coordinates,
+		ClientID:       req.ClientID,
+		Priority:       routing.NormalizePriority(req.Priority),
+		PreferredZone:  req.PreferredZone,
+		RequiredLabels: req.RequiredLabels,
+		Cluster:        req.ClusterID(),
+		Seed:           seed,
+	})
+	metrics.RoutingLatency.Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.RoutingFailures.WithLabelValues(routingFailureReason(err)).Inc()
+		s.h.logger.ErrorContext(ctx, "Failed to route request",
+			"request_id", logging.RequestID(ctx), "error", err)
+		return nil, routingError(err)
+	}
+
+	var response RouteResponse
+	if result != nil {
+		response = s.h.newRouteResponse(req, result, false)
+	}
+	var captured *RouteResponse
+	if s.h.capture.Wants(metadataValue(ctx, capturePayloadHeader)) {
+		captured = &response
+	}
+	s.h.saveRoutingRequest(ctx, req, result, decision, time.Since(received), logging.PeerIP(ctx), userAgent(ctx), captured)
+
+	if result == nil {
+		metrics.RoutingFailures.WithLabelValues(metrics.ReasonNoNodes).Inc()
+		return nil, apierror.Error{Code: apierror.CodeNoHealthyNodes, Message: "No healthy nodes available"}
+	}
+	metrics.RoutedRequests.Inc()
+	s.h.publishRoute(req, result)
+
+	out := &arxpb.RouteResponse{
+		RequestId:   response.RequestID,
+		RoutedTo:    nodeInfoToProto(response.RoutedTo),
+		RoutingMode: response.RoutingMode,
+		Stale:       response.Stale,
+	}
+	for _, fallback := range response.Fallbacks {
+		out.Fallbacks = append(out.Fallbacks, nodeInfoToProto(fallback))
+	}
+	return out, nil
+}
+
+func (s *GRPCServer) GetNodes(ctx context.Context, in *arxpb.GetNodesRequest) (*arxpb.GetNodesResponse, error) {
+	limit := int(in.GetLimit())
+	if limit == 0 {
+		limit = defaultNodeListLimit
+	}
+	if limit < 1 || limit > maxNodeListLimit {
+		return nil, apierror.Error{Code: apierror.CodeValidation, Message: fmt.Sprintf("Invalid limit, expected 1 to %d", maxNodeListLimit)}
+	}
+	if in.GetOffset() < 0 {
+		return nil, apierror.Error{Code: apierror.CodeValidation, Message: "Invalid offset"}
+	}
+
+	params := db.ListNodesParams{
+		PageLimit:  int32(limit),
+		PageOffset: in.GetOffset(),
+	}
+	if status := in.GetStatus(); status != "" {
+		params.Status = pgtype.Text{String: status, Valid: true}
+	}
+	if zone := in.GetZone(); zone != "" {
+		params.Zone = pgtype.Text{String: zone, Valid: true}
+	}
+	if labels := in.GetLabels(); len(labels) > 0 {
+		params.Labels = models.EncodeLabels(labels)
+	}
+	if cluster := in.GetCluster(); cluster != "" {
+		id, err := uuid.Parse(cluster)
+		if err != nil {
+			return nil, apierror.Error{Code: apierror.CodeValidation, Message: "Invalid cluster ID"}
+		}
+		params.ClusterID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	list, err := listNodes(ctx, s.h.db.Queries, params)
+	if err != nil {
+		return nil, apierror.Error{Code: apierror.CodeInternal, Message: "Failed to fetch nodes"}
+	}
+
+	out := &arxpb.GetNodesResponse{
+		Items:  make([]*arxpb.Node, len(list.Items)),
+		Total:  list.Total,
+		Limit:  int32(list.Limit),
+		Offset: int32(list.Offset),
+	}
+	for i, node := range list.Items {
+		out.Items[i] = nodeToProto(node)
+	}
+	return out, nil
+}
+
+func (s *GRPCServer) RegisterNode(ctx context.Context, in *arxpb.RegisterNodeRequest) (*arxpb.Node, error) {
+	grant, err := s.h.checkRegistrationToken(ctx, metadataValue(ctx, registrationTokenHeader))
+	if err == nil && grant.Spent {
+		err = errInvalidRegistrationToken
+	}
+	if err != nil {
+		return nil, registrationTokenError(err)
+	}
+
+	req := RegisterNodeRequest{
+		Name:           in.GetName(),
+		Location:       locationFromProto(in.GetLocation()),
+		Endpoint:       in.GetEndpoint(),
+		Zone:           in.GetZone(),
+		HealthPath:     in.GetHealthPath(),
+		HealthProtocol: in.GetHealthProtocol(),
+		Labels:         in.GetLabels(),
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, validationError("", err)
+	}
+	if err := req.Location.Validate(s.h.router.Geographic()); err != nil {
+		return nil, validationError("location.", err)
+	}
+	endpoint, err := models.NormalizeEndpoint(req.Endpoint)
+	if err != nil {
+		return nil, validationError("", err)
+	}
+	req.Endpoint = endpoint
+
+	protocol, path := healthCheckFor(req.Endpoint, req.HealthProtocol, req.HealthPath)
+	if !in.GetSkipProbe() {
+		if _, err := s.h.monitor.Probe(ctx, req.Endpoint, protocol, path); err != nil {
+			return nil, apierror.Error{Code: apierror.CodeEndpointUnreachable, Message: "endpoint is unreachable: " + err.Error()}
+		}
+	}
+
+	var node models.Node
+	err = s.h.db.WithTx(ctx, func(q db.Querier) error {
+		created, err := q.CreateNode(ctx, registerNodeParams(req, protocol, path))
+		if err != nil {
+			return err
+		}
+		node = routing.ConvertDBNodeToModel(created)
+		return spendRegistrationToken(ctx, q, grant, node.ID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidRegistrationToken):
+			return nil, registrationTokenError(err)
+		case database.IsUniqueViolation(err):
+			return nil, apierror.Error{Code: apierror.CodeEndpointConflict, Message: "A node with this endpoint already exists"}
+		}
+		return nil, apierror.Error{Code: apierror.CodeInternal, Message: "Failed to register node"}
+	}
+
+	s.h.router.InvalidateIndex()
+	s.h.events.Record(ctx, events.Event{
+		NodeID: node.ID,
+		Type:   "node_registered",
+		Actor:  events.ActorNode,
+		After:  &node,
+	})
+
+	return nodeToProto(node), nil
+}
+
+// registrationTokenError converts a rejected registration token into the
+// error returned over gRPC.
+func registrationTokenError(err error) error {
+	switch {
+	case errors.Is(err, errMissingRegistrationToken):
+		return apierror.Error{Code: apierror.CodeUnauthorized, Message: "Missing registration token"}
+	case errors.Is(err, errInvalidRegistrationToken):
+		return apierror.Error{Code: apierror.CodeUnauthorized, Message: "Invalid registration token"}
+	}
+	return apierror.Error{Code: apierror.CodeInternal, Message: "Failed to check registration token"}
+}
+
+// userAgent returns the gRPC client's user agent, if it sent one.
+func userAgent(ctx context.Context) string {
+	return metadataValue(ctx, "user-agent")
+}
+
+// metadataValue returns the first value of key in the incoming gRPC
+// metadata, if any.
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func locationFromProto(location *arxpb.Location) models.Location {
+	return models.Location{X: location.GetX(), Y: location.GetY()}
+}
+
+func nodeInfoToProto(info NodeInfo) *arxpb.NodeInfo {
+	return &arxpb.NodeInfo{
+		Id:           info.ID.String(),
+		Name:         info.Name,
+		Endpoint:     info.Endpoint,
+		Zone:         info.Zone,
+		Distance:     info.Distance,
+		DistanceUnit: info.DistanceUnit,
+		LoadScore:    info.LoadScore,
+	}
+}
+
+func nodeToProto(node models.Node) *arxpb.Node {
+	out := &arxpb.Node{
+		Id:                node.ID.String(),
+		Name:              node.Name,
+		LocationX:         node.LocationX,
+		LocationY:         node.LocationY,
+		Endpoint:          node.Endpoint,
+		Capacity:          int32(node.Capacity),
+		Weight:            int32(node.Weight),
+		Zone:              node.Zone,
+		HealthPath:        node.HealthPath,
+		HealthProtocol:    node.HealthProtocol,
+		Labels:            node.Labels,
+		Status:            node.Status,
+		Maintenance:       node.Maintenance,
+		CpuUsage:          node.CPUUsage,
+		MemoryUsage:       node.MemoryUsage,
+		ActiveConnections: int32(node.ActiveConnections),
+		CreatedAt:         timestamppb.New(node.CreatedAt),
+		UpdatedAt:         timestamppb.New(node.UpdatedAt),
+	}
+	if node.LastHealthCheck != nil {
+		out.LastHealthCheck = timestamppb.New(*node.LastHealthCheck)
+	}
+	if node.ClusterID != nil {
+		out.ClusterId = node.ClusterID.String()
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyKeyHeader lets clients retry a request without repeating its
+// side effects.
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+)
+
+var errIdempotencyKeyInUse = errors.New("idempotency key is in use")
+
+// hashRequest fingerprints a bound request so a reused key can be matched
+// against the request it was first sent with.
+func hashRequest(req interface{}) string {
+	// Bound request structs always marshal
+	body, _ := json.Marshal(req)
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// replayIdempotent writes the stored response for key if there is one,
+// reporting whether the request has been answered. A key reused with a
+// different request is rejected with 409.
+func (h *PublicHandler) replayIdempotent(c *gin.Context, key, requestHash string) bool {
+	ctx := c.Request.Context()
+	stored, err := h.db.Queries.GetIdempotencyKey(ctx, key)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return false
+		}
+		h.logger.ErrorContext(ctx, "Failed to look up idempotency key",
+			"request_id", logging.RequestID(ctx), "error", err)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to look up idempotency key")
+		return true
+	}
+
+	if stored.RequestHash != requestHash {
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "Idempotency-Key was already used with a different request")
+		return true
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(int(stored.StatusCode), "application/json; charset=utf-8", stored.ResponseBody)
+	return true
+}
+
+// saveIdempotentResponse stores the response to a keyed request for ttl. It
+// returns errIdempotencyKeyInUse if another request stored the key first.
+func saveIdempotentResponse(ctx context.Context, q db.Querier, key, requestHash string, status int, response interface{}, ttl time.Duration) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+		return err
+	}
+	saved, err := q.SaveIdempotencyKey(ctx, db.SaveIdempotencyKeyParams{
+		Key:          key,
+		RequestHash:  requestHash,
+		StatusCode:   int32(status),
+		ResponseBody: body,
+		TtlSeconds:   int32(ttl / time.Second),
+	})
+	if err != nil {
+		return err
+	}
+	if saved == 0 {
+		return errIdempotencyKeyInUse
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/health"
+	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/routing"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxBulkNodes caps how many nodes a single bulk request may create.
+const maxBulkNodes = 100
+
+// Page sizes for node listings
+const (
+	defaultNodeListLimit = 100
+	maxNodeListLimit     = 500
+)
+
+var (
+	errNodeNotFound   = errors.New("node not found")
+	errNodeReferenced = errors.New("node is referenced")
+	errBulkConflict   = errors.New("bulk item conflicts with an existing node")
+)
+
+// validLocation checks coordinates against the router's distance mode and
+// writes a 400 naming the offending field if they are invalid.
+func validLocation(c *gin.Context, router *routing.Service, field string, location models.Location) bool {
+	if err := location.Validate(router.Geographic()); err != nil {
+		respondFieldErrors(c, field+".", err)
+		return false
+	}
+	return true
+}
+
+// nodeListParams parses the limit, offset, status, zone, label and cluster
+// query parameters of a node listing and writes a 400 if any is invalid.
+// Soft-deleted nodes are only included with ?include_deleted=true when
+// allowDeleted is set.
+func nodeListParams(c *gin.Context, allowDeleted bool) (db.ListNodesParams, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultNodeListLimit)))
+	if err != nil || limit < 1 || limit > maxNodeListLimit {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("Invalid limit, expected 1 to %d", maxNodeListLimit))
+		return db.ListNodesParams{}, false
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid offset")
+		return db.ListNodesParams{}, false
+	}
+
+	params := db.ListNodesParams{
+		IncludeDeleted: allowDeleted && c.Query("include_deleted") == "true",
+		PageLimit:      int32(limit),
+		PageOffset:     int32(offset),
+	}
+	if status := c.Query("status"); status != "" {
+		params.Status = pgtype.Text{String: status, Valid: true}
+	}
+	if zone, ok := c.GetQuery("zone"); ok {
+		params.Zone = pgtype.Text{String: zone, Valid: true}
+	}
+	if filters := c.QueryArray("label"); len(filters) > 0 {
+		labels := make(map[string]string, len(filters))
+		for _, filter := range filters {
+			key, value, ok := strings.Cut(filter, "=")
+			if !ok || key == "" {
+				respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid label, expected key=value: "+filter)
+				return db.ListNodesParams{}, false
+			}
+			labels[key] = value
+		}
+		params.Labels = models.EncodeLabels(labels)
+	}
+	if cluster := c.Query("cluster"); cluster != "" {
+		id, err := uuid.Parse(cluster)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid cluster ID")
+			return db.ListNodesParams{}, false
+		}
+		params.ClusterID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	return params, true
+}
+
+// listNodes returns one page of nodes and the number matching the filters.
+func listNodes(ctx context.Context, q db.Querier, params db.ListNodesParams) (NodeList, error) {
+	rows, err := q.ListNodes(ctx, params)
+	if err != nil {
+		return NodeList{}, err
+	}
+	total, err := q.CountListNodes(ctx, db.CountListNodesParams{
+		IncludeDeleted: params.IncludeDeleted,
+		Status:         params.Status,
+		Zone:           params.Zone,
+		Labels:         params.Labels,
+		ClusterID:      params.ClusterID,
+	})
+	if err != nil {
+		return NodeList{}, err
+	}
+
+	list := NodeList{
+		Items:  make([]models.Node, len(rows)),
+		Total:  total,
+		Limit:  int(params.PageLimit),
+		Offset: int(params.PageOffset),
+	}
+	for i, row := range rows {
+		list.Items[i] = routing.ConvertDBNodeToModel(row)
+	}
+	return list, nil
+}
+
+// normalEndpoint replaces the endpoint with its canonical form and writes a
+// 400 if it is not a valid http or https URL.
+func normalEndpoint(c *gin.Context, endpoint *string) bool {
+	normalized, err := models.NormalizeEndpoint(*endpoint)
+	if err != nil {
+		respondValidationError(c, err)
+		return false
+	}
+	*endpoint = normalized
+	return true
+}
+
+// reachableEndpoint probes the endpoint's health check once and writes a 400
+// if it does not answer, unless the request has ?skip_probe=true.
+func reachableEndpoint(c *gin.Context, monitor *health.Monitor, endpoint, protocol, path string) bool {
+	if c.Query("skip_probe") == "true" {
+		return true
+	}
+	if _, err := monitor.Probe(c.Request.Context(), endpoint, protocol, path); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeEndpointUnreachable, "endpoint is unreachable: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// healthCheckFor fills in the health check protocol and path a create request
+// left empty.
+func healthCheckFor(endpoint, protocol, path string) (string, string) {
+	if protocol == "" {
+		protocol = models.DefaultHealthProtocol(endpoint)
+	}
+	if path == "" {
+		path = models.DefaultHealthPath
+	}
+	return protocol, path
+}
+
+// createNodeParams builds the insert for a node created through the admin API.
+func createNodeParams(req CreateNodeRequest) db.CreateNodeParams {
+	// Set default capacity if not provided
+	capacity := req.Capacity
+	if capacity == 0 {
+		capacity = 100
+	}
+	weight := 1
+	if req.Weight != nil {
+		weight = *req.Weight
+	}
+	protocol, path := healthCheckFor(req.Endpoint, req.HealthProtocol, req.HealthPath)
+
+	return db.CreateNodeParams{
+		Name:      req.Name,
+		LocationX: req.Location.X,
+		LocationY: req.Location.Y,
+		Endpoint:  req.Endpoint,
+		Capacity:  pgtype.Int4{Int32: int32(capacity), Valid: true},
+		Status:    pgtype.Text{String: models.NodeStatusInactive, Valid: true},
+		Weight:    int32(weight),
+		Zone:      req.Zone,
+		Labels:    models.EncodeLabels(req.Labels),
+		ClusterID: clusterParam(req.ClusterID),
+
+		HealthPath:     path,
+		HealthProtocol: protocol,
+	}
+}
+
+// registerNodeParams builds the insert for a self-registered node, which
+// starts active with the default capacity and weight.
+func registerNodeParams(req RegisterNodeRequest, protocol, path string) db.CreateNodeParams {
+	return db.CreateNodeParams{
+		Name:      req.Name,
+		LocationX: req.Location.X,
+		LocationY: req.Location.Y,
+		Endpoint:  req.Endpoint,
+		Capacity:  pgtype.Int4{Int32: 100, Valid: true},
+		Status:    pgtype.Text{String: models.NodeStatusActive, Valid: true},
+		Weight:    1,
+		Zone:      req.Zone,
+		Labels:    models.EncodeLabels(req.Labels),
+
+		HealthPath:     path,
+		HealthProtocol: protocol,
+	}
+}
+
+// createNode inserts a node and returns the persisted row as a model.
+func createNode(ctx context.Context, database *database.Database, params db.CreateNodeParams) (models.Node, error) {
+	node, err := database.Queries.CreateNode(ctx, params)
+	if err != nil {
+		return models.Node{}, err
+	}
+	return routing.ConvertDBNodeToModel(node), nil
+}
+
+func convertDBSystemMetric(metric db.SystemMetric) models.SystemMetric {
+	var nodeID *uuid.UUID
+	if metric.NodeID.Valid {
+		id := uuid.UUID(metric.NodeID.Bytes)
+		nodeID = &id
+	}
+
+	return models.SystemMetric{
+		ID:         uuid.UUID(metric.ID.Bytes),
+		MetricType: metric.MetricType,
+		NodeID:     nodeID,
+		Value:      metric.Value,
+		Timestamp:  metric.Timestamp.Time,
+	}
+}
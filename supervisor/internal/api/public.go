@@ -1,154 +1,1045 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
+	"arx-supervisor/internal/apierror"
 	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/events"
+	"arx-supervisor/internal/health"
+	"arx-supervisor/internal/logging"
+	"arx-supervisor/internal/metrics"
 	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/ratelimit"
 	"arx-supervisor/internal/routing"
+	"arx-supervisor/internal/tracing"
 	"arx-supervisor/internal/websocket"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// maxBatchRoutes caps how many requests a single batch may route.
+const maxBatchRoutes = 100
+
+// maxBatchBodyBytes caps the body of a route batch or bulk node create, which
+// is read before its items are counted. It leaves room for maxBatchRoutes
+// requests or maxBulkNodes nodes with all their labels.
+const maxBatchBodyBytes = 4 << 20
+
+// routingSeedHeader seeds the tie-breaking and p2c sampling of a routing
+// request, over HTTP and, lowercased, as gRPC metadata, so a decision can be
+// replayed. It is meant for tests and debugging.
+const routingSeedHeader = "X-Routing-Seed"
+
+// maxRoutingSeedLength caps the X-Routing-Seed header.
+const maxRoutingSeedLength = 128
+
+var errRoutingSeedTooLong = fmt.Errorf("%s must be at most %d characters", routingSeedHeader, maxRoutingSeedLength)
+
+// routingSeed returns the X-Routing-Seed header, writing a 400 if it is too
+// long.
+func routingSeed(c *gin.Context) (string, bool) {
+	seed := c.GetHeader(routingSeedHeader)
+	if len(seed) > maxRoutingSeedLength {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, errRoutingSeedTooLong.Error())
+		return "", false
+	}
+	return seed, true
+}
+
 type PublicHandler struct {
-	db     *database.Database
-	router *routing.Service
-	wsHub  *websocket.Hub
+	db      *database.Database
+	router  *routing.Service
+	wsHub   *websocket.Hub
+	events  *events.Recorder
+	monitor *health.Monitor
+	logger  *slog.Logger
+
+	// idempotencyTTL is how long responses to keyed requests are replayed
+	idempotencyTTL time.Duration
+	// requestTimeout bounds routing a request, zero for no bound
+	requestTimeout time.Duration
+	// registrationToken is the shared token nodes may register with, empty
+	// to only accept one-time tokens
+	registrationToken string
+	// capture decides which routing responses are stored, nil for none
+	capture *PayloadCapture
+	// limiter charges a batch one token per request it holds
+	limiter *ratelimit.Limiter
 }
 
 type RouteRequest struct {
-	RequestID   string          `json:"request_id" binding:"required"`
-	Coordinates models.Location `json:"coordinates" binding:"required"`
-	Priority    string          `json:"priority,omitempty"`
+	RequestID     string          `json:"request_id" binding:"required"`
+	Coordinates   models.Location `json:"coordinates" binding:"required"`
+	Priority      string          `json:"priority,omitempty"`
+	ClientID      string          `json:"client_id,omitempty"`
+	PreferredZone string          `json:"preferred_zone,omitempty"`
+	// RequiredLabels limits routing to nodes carrying all of these labels
+	RequiredLabels map[string]string `json:"required_labels,omitempty" binding:"omitempty,max=32,dive,keys,min=1,max=63,endkeys,max=255"`
+	// Cluster limits routing to the nodes of the cluster with this ID
+	Cluster string `json:"cluster,omitempty" binding:"omitempty,uuid"`
+}
+
+// ClusterID returns the cluster the request is limited to, uuid.Nil for none.
+func (r RouteRequest) ClusterID() uuid.UUID {
+	id, _ := uuid.Parse(r.Cluster)
+	return id
 }
 
 type RegisterNodeRequest struct {
 	Name     string          `json:"name" binding:"required"`
 	Location models.Location `json:"location" binding:"required"`
 	Endpoint string          `json:"endpoint" binding:"required"`
+	Zone     string          `json:"zone,omitempty" binding:"max=100"`
+
+	HealthPath     string `json:"health_path,omitempty" binding:"omitempty,startswith=/,max=255"`
+	HealthProtocol string `json:"health_protocol,omitempty" binding:"omitempty,oneof=http https tcp"`
+
+	Labels map[string]string `json:"labels,omitempty" binding:"omitempty,max=32,dive,keys,min=1,max=63,endkeys,max=255"`
 }
 
+// RouteResponse is the routing decision. Fallback is the best backup node
+// and Fallbacks lists every backup, best first; both are omitted when no
+// other node qualifies. Candidates is only returned by route previews and by
+// routes requested with ?explain=true. Stale is set when the database was
+// unreachable and the node was chosen from the last known healthy nodes.
 type RouteResponse struct {
-	RoutedTo  NodeInfo `json:"routed_to"`
-	RequestID string   `json:"request_id"`
+	RoutedTo    NodeInfo        `json:"routed_to"`
+	Fallback    *NodeInfo       `json:"fallback,omitempty"`
+	Fallbacks   []NodeInfo      `json:"fallbacks,omitempty"`
+	Candidates  []CandidateInfo `json:"candidates,omitempty"`
+	RequestID   string          `json:"request_id"`
+	RoutingMode string          `json:"routing_mode"`
+	Stale       bool            `json:"stale,omitempty"`
 }
 
+// NodeInfo describes a routing candidate. DistanceUnit is "km" in haversine
+// mode and "units" otherwise.
 type NodeInfo struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	Endpoint  string    `json:"endpoint"`
-	Distance  float64   `json:"distance"`
-	LoadScore float64   `json:"load_score"`
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Endpoint     string    `json:"endpoint"`
+	Zone         string    `json:"zone,omitempty"`
+	Distance     float64   `json:"distance"`
+	DistanceUnit string    `json:"distance_unit"`
+	LoadScore    float64   `json:"load_score"`
+}
+
+// CandidateInfo is a scored routing candidate. Score combines the load score
+// and normalized distance using the routing weights; the lowest wins.
+type CandidateInfo struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Endpoint     string    `json:"endpoint"`
+	Zone         string    `json:"zone,omitempty"`
+	Distance     float64   `json:"distance"`
+	DistanceUnit string    `json:"distance_unit"`
+	LoadScore    float64   `json:"load_score"`
+	Score        float64   `json:"score"`
+}
+
+// RouteBatchResult is the outcome of one request of a batch. Exactly one of
+// Route and Error is set.
+type RouteBatchResult struct {
+	Index     int             `json:"index"`
+	RequestID string          `json:"request_id"`
+	Route     *RouteResponse  `json:"route,omitempty"`
+	Error     *apierror.Error `json:"error,omitempty"`
+}
+
+// NearbyNode is a node found by a proximity search with its distance from
+// the searched coordinates.
+type NearbyNode struct {
+	Node         models.Node `json:"node"`
+	Distance     float64     `json:"distance"`
+	DistanceUnit string      `json:"distance_unit"`
+}
+
+// NodeList is one page of a node listing. Total counts every node matching
+// the filters.
+type NodeList struct {
+	Items  []models.Node `json:"items"`
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
 }
 
-func NewPublicHandler(db *database.Database, router *routing.Service, wsHub *websocket.Hub) *PublicHandler {
+// Cluster health verdicts
+const (
+	ClusterHealthy  = "healthy"
+	ClusterDegraded = "degraded"
+	ClusterCritical = "critical"
+)
+
+// clusterHealthyRatio is the fraction of healthy nodes at or above which the
+// cluster is considered healthy rather than degraded.
+const clusterHealthyRatio = 0.75
+
+type ClusterHealth struct {
+	Status            string         `json:"status"`
+	TotalNodes        int            `json:"total_nodes"`
+	HealthyNodes      int            `json:"healthy_nodes"`
+	HealthyRatio      float64        `json:"healthy_ratio"`
+	NodesByStatus     map[string]int `json:"nodes_by_status"`
+	OldestHealthCheck *time.Time     `json:"oldest_health_check"`
+	Timestamp         time.Time      `json:"timestamp"`
+}
+
+func NewPublicHandler(db *database.Database, router *routing.Service, wsHub *websocket.Hub, recorder *events.Recorder, monitor *health.Monitor, logger *slog.Logger, idempotencyTTL, requestTimeout time.Duration, registrationToken string, capture *PayloadCapture, limiter *ratelimit.Limiter) *PublicHandler {
 	return &PublicHandler{
-		db:     db,
-		router: router,
-		wsHub:  wsHub,
+		db:             db,
+		router:         router,
+		wsHub:          wsHub,
+		events:         recorder,
+		monitor:        monitor,
+		logger:         logger,
+		idempotencyTTL: idempotencyTTL,
+		requestTimeout: requestTimeout,
+
+		registrationToken: registrationToken,
+		capture:           capture,
+		limiter:           limiter,
 	}
 }
 
+// withTimeout bounds ctx by the request timeout, when one is configured, so
+// a slow database cannot hold a routing request indefinitely.
+func (h *PublicHandler) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, h.requestTimeout)
+}
+
+// routingError describes a failure of the router: a timeout when the request
+// timeout ran out, an internal error otherwise.
+func routingError(err error) apierror.Error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return apierror.Error{Code: apierror.CodeTimeout, Message: "Routing timed out"}
+	}
+	return apierror.Error{Code: apierror.CodeInternal, Message: "Failed to route request"}
+}
+
+// respondRoutingError answers a request the router failed on with 504 for
+// timeouts and 500 otherwise.
+func respondRoutingError(c *gin.Context, err error) {
+	e := routingError(err)
+	status := http.StatusInternalServerError
+	if e.Code == apierror.CodeTimeout {
+		status = http.StatusGatewayTimeout
+	}
+	respondError(c, status, e.Code, e.Message)
+}
+
+// routingFailureReason is the routing_failures_total reason for err.
+func routingFailureReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return metrics.ReasonTimeout
+	}
+	return metrics.ReasonError
+}
+
 // POST /api/v1/route
+//
+// @Summary Route a request to the best node
+// @Tags routing
+// @Accept json
+// @Produce json
+// @Param request body RouteRequest true "Request to route"
+// @Param explain query bool false "Include every scored candidate, best first"
+// @Param X-Capture-Payload header bool false "Store the request and its response when CAPTURE_PAYLOADS is opt-in"
+// @Param X-Routing-Seed header string false "Seed for tie-breaking and p2c sampling instead of the request ID; for debugging"
+// @Success 200 {object} RouteResponse
+// @Failure 400 {object} apierror.Response
+// @Failure 429 {object} apierror.Response "Rate limit exceeded; see Retry-After"
+// @Failure 500 {object} apierror.Response
+// @Failure 503 {object} apierror.Response "No healthy nodes available, or the server is shutting down"
+// @Failure 504 {object} apierror.Response "Routing timed out"
+// @Router /api/v1/route [post]
 func (h *PublicHandler) RouteRequest(c *gin.Context) {
+	received := time.Now()
+	defer func() {
+		metrics.RouteResponses.WithLabelValues(strconv.Itoa(c.Writer.Status())).Inc()
+	}()
+
 	var req RouteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondValidationError(c, err)
+		return
+	}
+	if !validLocation(c, h.router, "coordinates", req.Coordinates) {
+		return
+	}
+	seed, ok := routingSeed(c)
+	if !ok {
 		return
 	}
 
+	explain := c.Query("explain") == "true"
+	ctx, cancel := h.withTimeout(c.Request.Context())
+	defer cancel()
+
 	// Route the request
-	selectedNode, err := h.router.RouteRequest(c.Request.Context(), req.RequestID, req.Coordinates)
+	start := time.Now()
+	result, decision, err := h.router.RouteRequest(ctx, routing.Request{
+		RequestID:      req.RequestID,
+		Coordinates:    req.Coordinates,
+		ClientID:       req.ClientID,
+		Priority:       routing.NormalizePriority(req.Priority),
+		Explain:        explain,
+		PreferredZone:  req.PreferredZone,
+		RequiredLabels: req.RequiredLabels,
+		Cluster:        req.ClusterID(),
+		Seed:           seed,
+	})
+	metrics.RoutingLatency.Observe(time.Since(start).Seconds())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to route request"})
+		metrics.RoutingFailures.WithLabelValues(routingFailureReason(err)).Inc()
+		h.logger.ErrorContext(ctx, "Failed to route request",
+			"request_id", logging.RequestID(ctx), "error", err)
+		respondRoutingError(c, err)
 		return
 	}
 
-	if selectedNode == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No healthy nodes available"})
-		return
+	var response RouteResponse
+	if result != nil {
+		response = h.newRouteResponse(req, result, explain)
 	}
+	h.recordRoutingRequest(ctx, c, req, result, decision, time.Since(received), &response)
 
-	// Calculate distance and load score
-	distance := routing.CalculateDistance(req.Coordinates.X, req.Coordinates.Y, selectedNode.LocationX, selectedNode.LocationY)
-	loadScore := routing.CalculateLoadScore(*selectedNode)
+	if result == nil {
+		metrics.RoutingFailures.WithLabelValues(metrics.ReasonNoNodes).Inc()
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeNoHealthyNodes, "No healthy nodes available")
+		return
+	}
+	metrics.RoutedRequests.Inc()
+	h.publishRoute(req, result)
 
-	// Create routing request record in database would go here
-	// For now, just send the response
+	c.JSON(http.StatusOK, response)
+}
 
-	// Send real-time update
-	h.wsHub.Broadcast <- websocket.Message{
+// publishRoute sends a route_request event for a routed request.
+func (h *PublicHandler) publishRoute(req RouteRequest, result *routing.RouteResult) {
+	h.wsHub.Publish(websocket.Message{
 		Type: "route_request",
 		Data: map[string]interface{}{
 			"request_id":    req.RequestID,
 			"coordinates_x": req.Coordinates.X,
 			"coordinates_y": req.Coordinates.Y,
-			"selected_node": selectedNode,
-			"distance":      distance,
-			"load_score":    loadScore,
+			"selected_node": result.Node,
+			"distance":      result.Distance,
+			"distance_unit": h.router.DistanceUnit(),
+			"load_score":    result.LoadScore,
+			"routing_mode":  result.Mode,
+			"priority":      routing.NormalizePriority(req.Priority),
 			"status":        "routed",
 			"timestamp":     time.Now().UTC(),
 		},
+	})
+}
+
+// POST /api/v1/route/preview
+//
+// @Summary Preview a routing decision
+// @Description Runs the same selection as POST /api/v1/route and also returns
+// @Description every scored candidate, but records nothing and broadcasts
+// @Description nothing. Use it to check how weight changes affect routing.
+// @Tags routing
+// @Accept json
+// @Produce json
+// @Param request body RouteRequest true "Request to route"
+// @Param X-Routing-Seed header string false "Seed for tie-breaking and p2c sampling instead of the request ID; for debugging"
+// @Success 200 {object} RouteResponse
+// @Failure 400 {object} apierror.Response
+// @Failure 429 {object} apierror.Response "Rate limit exceeded; see Retry-After"
+// @Failure 500 {object} apierror.Response
+// @Failure 503 {object} apierror.Response "No healthy nodes available, or the server is shutting down"
+// @Failure 504 {object} apierror.Response "Routing timed out"
+// @Router /api/v1/route/preview [post]
+func (h *PublicHandler) PreviewRoute(c *gin.Context) {
+	var req RouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if !validLocation(c, h.router, "coordinates", req.Coordinates) {
+		return
+	}
+	seed, ok := routingSeed(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := h.withTimeout(c.Request.Context())
+	defer cancel()
+
+	result, _, err := h.router.RouteRequest(ctx, routing.Request{
+		RequestID:      req.RequestID,
+		Coordinates:    req.Coordinates,
+		ClientID:       req.ClientID,
+		Priority:       routing.NormalizePriority(req.Priority),
+		Explain:        true,
+		PreferredZone:  req.PreferredZone,
+		RequiredLabels: req.RequiredLabels,
+		Cluster:        req.ClusterID(),
+		Preview:        true,
+		Seed:           seed,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to preview route",
+			"request_id", logging.RequestID(ctx), "error", err)
+		respondRoutingError(c, err)
+		return
+	}
+	if result == nil {
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeNoHealthyNodes, "No healthy nodes available")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.newRouteResponse(req, result, true))
+}
+
+// POST /api/v1/route/batch
+//
+// @Summary Route many requests at once
+// @Description Routes every request against one snapshot of the healthy nodes
+// @Description and returns the results in request order. Requests that are
+// @Description invalid or cannot be routed carry an error instead of a route
+// @Description without failing the rest of the batch.
+// @Tags routing
+// @Accept json
+// @Produce json
+// @Param requests body []RouteRequest true "Requests to route, at most 100 in at most 4 MiB"
+// @Param X-Capture-Payload header bool false "Store each request and its response when CAPTURE_PAYLOADS is opt-in"
+// @Param X-Routing-Seed header string false "Seed for the tie-breaking and p2c sampling of every request instead of its request ID; for debugging"
+// @Success 200 {array} RouteBatchResult
+// @Failure 400 {object} apierror.Response
+// @Failure 413 {object} apierror.Response "Batch too large"
+// @Failure 429 {object} apierror.Response "Rate limit exceeded; see Retry-After"
+// @Failure 500 {object} apierror.Response
+// @Failure 503 {object} apierror.Response "Server is shutting down"
+// @Failure 504 {object} apierror.Response "Routing timed out"
+// @Router /api/v1/route/batch [post]
+func (h *PublicHandler) RouteBatch(c *gin.Context) {
+	received := time.Now()
+	defer func() {
+		metrics.RouteResponses.WithLabelValues(strconv.Itoa(c.Writer.Status())).Inc()
+	}()
+
+	seed, ok := routingSeed(c)
+	if !ok {
+		return
+	}
+	var reqs []RouteRequest
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchBodyBytes)
+	if err := json.NewDecoder(body).Decode(&reqs); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge,
+				fmt.Sprintf("A batch body may be at most %d bytes", maxBatchBodyBytes))
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Request body must be a JSON array of route requests")
+		return
+	}
+	if len(reqs) == 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "No requests to route")
+		return
+	}
+	if len(reqs) > maxBatchRoutes {
+		respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge,
+			fmt.Sprintf("At most %d requests can be routed per batch", maxBatchRoutes))
+		return
+	}
+	if ok, retryAfter := h.limiter.AllowN(c.Request.Context(), ratelimit.ClientKey(c), len(reqs)); !ok {
+		ratelimit.Abort(c, retryAfter)
+		return
 	}
 
-	c.JSON(http.StatusOK, RouteResponse{
-		RoutedTo: NodeInfo{
-			ID:        selectedNode.ID,
-			Name:      selectedNode.Name,
-			Endpoint:  selectedNode.Endpoint,
-			Distance:  distance,
-			LoadScore: loadScore,
+	// Invalid items are answered up front; the rest are routed together
+	results := make([]RouteBatchResult, len(reqs))
+	routable := make([]routing.Request, 0, len(reqs))
+	indexes := make([]int, 0, len(reqs))
+	geographic := h.router.Geographic()
+	for i, req := range reqs {
+		results[i] = RouteBatchResult{Index: i, RequestID: req.RequestID}
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			e := validationError("", err)
+			results[i].Error = &e
+			continue
+		}
+		if err := req.Coordinates.Validate(geographic); err != nil {
+			e := validationError("coordinates.", err)
+			results[i].Error = &e
+			continue
+		}
+		routable = append(routable, routing.Request{
+			RequestID:      req.RequestID,
+			Coordinates:    req.Coordinates,
+			ClientID:       req.ClientID,
+			Priority:       routing.NormalizePriority(req.Priority),
+			PreferredZone:  req.PreferredZone,
+			RequiredLabels: req.RequiredLabels,
+			Cluster:        req.ClusterID(),
+			Seed:           seed,
+		})
+		indexes = append(indexes, i)
+	}
+
+	ctx, cancel := h.withTimeout(c.Request.Context())
+	defer cancel()
+	var outcomes []routing.BatchResult
+	if len(routable) > 0 {
+		var err error
+		outcomes, err = h.router.RouteBatch(ctx, routable)
+		if err != nil {
+			metrics.RoutingFailures.WithLabelValues(routingFailureReason(err)).Add(float64(len(routable)))
+			h.logger.ErrorContext(ctx, "Failed to route batch",
+				"request_id", logging.RequestID(ctx), "error", err)
+			respondRoutingError(c, err)
+			return
+		}
+	}
+
+	routed := 0
+	for j, outcome := range outcomes {
+		i := indexes[j]
+		if outcome.Err != nil {
+			metrics.RoutingFailures.WithLabelValues(routingFailureReason(outcome.Err)).Inc()
+			h.logger.ErrorContext(ctx, "Failed to route request",
+				"request_id", logging.RequestID(ctx), "routing_request_id", reqs[i].RequestID, "error", outcome.Err)
+			e := routingError(outcome.Err)
+			results[i].Error = &e
+			continue
+		}
+
+		var response RouteResponse
+		if outcome.Result != nil {
+			response = h.newRouteResponse(reqs[i], outcome.Result, false)
+		}
+		h.recordRoutingRequest(ctx, c, reqs[i], outcome.Result, outcome.Decision, time.Since(received), &response)
+		if outcome.Result == nil {
+			metrics.RoutingFailures.WithLabelValues(metrics.ReasonNoNodes).Inc()
+			results[i].Error = &apierror.Error{Code: apierror.CodeNoHealthyNodes, Message: "No healthy nodes available"}
+			continue
+		}
+		metrics.RoutedRequests.Inc()
+		routed++
+		results[i].Route = &response
+	}
+
+	// One event for the whole batch rather than one per request
+	h.wsHub.Publish(websocket.Message{
+		Type: "route_batch",
+		Data: map[string]interface{}{
+			"count":     len(reqs),
+			"routed":    routed,
+			"failed":    len(reqs) - routed,
+			"timestamp": time.Now().UTC(),
 		},
-		RequestID: req.RequestID,
+	})
+
+	c.JSON(http.StatusOK, results)
+}
+
+// newRouteResponse builds the reply for a routing decision, listing every
+// scored candidate when explain is set.
+func (h *PublicHandler) newRouteResponse(req RouteRequest, result *routing.RouteResult, explain bool) RouteResponse {
+	unit := h.router.DistanceUnit()
+	response := RouteResponse{
+		RoutedTo:    newNodeInfo(result.ScoredNode, unit),
+		RequestID:   req.RequestID,
+		RoutingMode: result.Mode,
+		Stale:       result.Stale,
+	}
+	for _, fallback := range result.Fallbacks {
+		response.Fallbacks = append(response.Fallbacks, newNodeInfo(fallback, unit))
+	}
+	if len(response.Fallbacks) > 0 {
+		response.Fallback = &response.Fallbacks[0]
+	}
+	if explain {
+		response.Candidates = make([]CandidateInfo, 0, len(result.Candidates))
+		for _, candidate := range result.Candidates {
+			response.Candidates = append(response.Candidates, CandidateInfo{
+				ID:           candidate.Node.ID,
+				Name:         candidate.Node.Name,
+				Endpoint:     candidate.Node.Endpoint,
+				Zone:         candidate.Node.Zone,
+				Distance:     candidate.Distance,
+				DistanceUnit: unit,
+				LoadScore:    candidate.LoadScore,
+				Score:        candidate.Score,
+			})
+		}
+	}
+	return response
+}
+
+func newNodeInfo(scored routing.ScoredNode, distanceUnit string) NodeInfo {
+	return NodeInfo{
+		ID:           scored.Node.ID,
+		Name:         scored.Node.Name,
+		Endpoint:     scored.Node.Endpoint,
+		Zone:         scored.Node.Zone,
+		Distance:     scored.Distance,
+		DistanceUnit: distanceUnit,
+		LoadScore:    scored.LoadScore,
+	}
+}
+
+// recordRoutingRequest persists the outcome of a route request. The request's
+// correlation ID is stored in its metadata so it can be matched with the
+// request log, and the decision audit in its processing metrics. elapsed is
+// how long the request has been handled so far, stored rounded up to whole
+// milliseconds. response is stored as well when payload capture wants the
+// request. The insert runs under ctx, which carries the request timeout.
+// Failures are logged rather than failing the request.
+func (h *PublicHandler) recordRoutingRequest(ctx context.Context, c *gin.Context, req RouteRequest, result *routing.RouteResult, decision *routing.Decision, elapsed time.Duration, response *RouteResponse) {
+	if !h.capture.Wants(c.GetHeader(capturePayloadHeader)) {
+		response = nil
+	}
+	h.saveRoutingRequest(ctx, req, result, decision, elapsed, c.ClientIP(), c.Request.UserAgent(), response)
+}
+
+// encodeCapture encodes a captured payload, storing none when it cannot be
+// encoded.
+func (h *PublicHandler) encodeCapture(ctx context.Context, payload interface{}) []byte {
+	body, err := h.capture.Encode(payload)
+	if err != nil {
+		h.logger.WarnContext(ctx, "Failed to capture payload",
+			"request_id", logging.RequestID(ctx), "error", err)
+	}
+	return body
+}
+
+// saveRoutingRequest is recordRoutingRequest for callers without a gin
+// context, such as the gRPC server.
+func (h *PublicHandler) saveRoutingRequest(ctx context.Context, req RouteRequest, result *routing.RouteResult, decision *routing.Decision, elapsed time.Duration, clientIP, userAgent string, response *RouteResponse) {
+	priority := routing.NormalizePriority(req.Priority)
+
+	params := db.CreateRoutingRequestParams{
+		RequestID:      req.RequestID,
+		CoordinatesX:   req.Coordinates.X,
+		CoordinatesY:   req.Coordinates.Y,
+		Status:         pgtype.Text{String: models.RoutingStatusFailed, Valid: true},
+		ResponseTimeMs: pgtype.Int4{Int32: int32((elapsed + time.Millisecond - 1) / time.Millisecond), Valid: true},
+	}
+	metadata := map[string]interface{}{
+		"correlation_id": logging.RequestID(ctx),
+		"priority":       priority,
+	}
+	if req.PreferredZone != "" {
+		metadata["preferred_zone"] = req.PreferredZone
+	}
+	if len(req.RequiredLabels) > 0 {
+		metadata["required_labels"] = req.RequiredLabels
+	}
+	if req.Cluster != "" {
+		metadata["cluster"] = req.Cluster
+	}
+	if result != nil {
+		params.SelectedNodeID = pgtype.UUID{Bytes: result.Node.ID, Valid: true}
+		for _, fallback := range result.Fallbacks {
+			params.FallbackNodeIds = append(params.FallbackNodeIds, pgtype.UUID{Bytes: fallback.Node.ID, Valid: true})
+		}
+		params.Distance = pgtype.Float8{Float64: result.Distance, Valid: true}
+		params.LoadScore = pgtype.Float8{Float64: result.LoadScore, Valid: true}
+		params.Status = pgtype.Text{String: models.RoutingStatusRouted, Valid: true}
+		metadata["routing_mode"] = result.Mode
+	}
+
+	// Marshalling maps of strings and the decision cannot fail
+	params.Metadata, _ = json.Marshal(metadata)
+	if decision != nil {
+		params.ProcessingMetrics, _ = json.Marshal(decision)
+	}
+	params.ClientInfo, _ = json.Marshal(map[string]interface{}{
+		"client_id":  req.ClientID,
+		"ip":         clientIP,
+		"user_agent": userAgent,
+	})
+	// Captured requests and responses are redacted and capped alike. A
+	// request no node could take is captured with the error it got
+	if response != nil {
+		var reply interface{} = response
+		if result == nil {
+			reply = apierror.Response{
+				Error: apierror.Error{Code: apierror.CodeNoHealthyNodes, Message: "No healthy nodes available"},
+			}
+		}
+		params.RequestData = h.encodeCapture(ctx, req)
+		params.ResponseData = h.encodeCapture(ctx, reply)
+	}
+
+	ctx, span := tracing.Start(ctx, "db.CreateRoutingRequest")
+	defer span.End()
+	if err := h.db.Guarded(func() error {
+		_, err := h.db.Queries.CreateRoutingRequest(ctx, params)
+		return err
+	}); err != nil {
+		tracing.RecordError(span, err)
+		h.logger.ErrorContext(ctx, "Failed to record routing request",
+			"request_id", logging.RequestID(ctx), "routing_request_id", req.RequestID, "error", err)
+	}
+}
+
+// RoutedRequest is the public view of a recorded routing request. Request IDs
+// are chosen by clients, so anyone who knows one only learns where the
+// request went, never who sent it or what it carried.
+type RoutedRequest struct {
+	RequestID      string     `json:"request_id"`
+	SelectedNodeID *uuid.UUID `json:"selected_node_id"`
+	Distance       *float64   `json:"distance"`
+	LoadScore      *float64   `json:"load_score"`
+	Status         string     `json:"status"`
+	ResponseTimeMs *int       `json:"response_time_ms"`
+}
+
+// GET /api/v1/route/:request_id
+//
+// @Summary Get a routed request
+// @Description Returns the latest request recorded with this request ID: the
+// @Description selected node, distance, load score, status and response time.
+// @Tags routing
+// @Produce json
+// @Param request_id path string true "Request ID the request was routed with"
+// @Success 200 {object} RoutedRequest
+// @Failure 404 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/route/{request_id} [get]
+func (h *PublicHandler) GetRoutingRequest(c *gin.Context) {
+	request, err := h.db.Queries.GetRoutingRequestByRequestID(c.Request.Context(), c.Param("request_id"))
+	if err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeRequestNotFound, "Routing request not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch routing request")
+		return
+	}
+
+	result := convertDBRoutingRequest(request)
+	c.JSON(http.StatusOK, RoutedRequest{
+		RequestID:      result.RequestID,
+		SelectedNodeID: result.SelectedNodeID,
+		Distance:       result.Distance,
+		LoadScore:      result.LoadScore,
+		Status:         result.Status,
+		ResponseTimeMs: result.ResponseTimeMs,
 	})
 }
 
 // GET /api/v1/nodes
+//
+// @Summary List nodes
+// @Tags nodes
+// @Produce json
+// @Param limit query int false "Maximum nodes to return, at most 500" default(100)
+// @Param offset query int false "Nodes to skip"
+// @Param status query string false "Only return nodes with this status"
+// @Param zone query string false "Only return nodes in this zone"
+// @Param label query []string false "Only return nodes with this key=value label; repeat to require several" collectionFormat(multi)
+// @Param cluster query string false "Only return nodes of the cluster with this ID" format(uuid)
+// @Success 200 {object} NodeList
+// @Failure 400 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/nodes [get]
 func (h *PublicHandler) GetNodes(c *gin.Context) {
-	nodes, err := h.router.GetAllNodes(c.Request.Context())
+	params, ok := nodeListParams(c, false)
+	if !ok {
+		return
+	}
+
+	list, err := listNodes(c.Request.Context(), h.db.Queries, params)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch nodes")
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GET /api/v1/nodes/nearby
+//
+// @Summary Find nodes near a point
+// @Description Lists registered nodes of any status within radius of the coordinates, nearest first, without routing to them.
+// @Tags nodes
+// @Produce json
+// @Param x query number true "X coordinate (longitude in haversine mode)"
+// @Param y query number true "Y coordinate (latitude in haversine mode)"
+// @Param radius query number true "Search radius in the distance unit of the current mode"
+// @Param limit query int false "Maximum nodes to return, at most 500" default(100)
+// @Success 200 {array} NearbyNode
+// @Failure 400 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/nodes/nearby [get]
+func (h *PublicHandler) GetNearbyNodes(c *gin.Context) {
+	var coordinates models.Location
+	for _, param := range []struct {
+		name  string
+		value *float64
+	}{{"x", &coordinates.X}, {"y", &coordinates.Y}} {
+		value, err := strconv.ParseFloat(c.Query(param.name), 64)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, param.name+" is required and must be a number")
+			return
+		}
+		*param.value = value
+	}
+	if err := coordinates.Validate(h.router.Geographic()); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	radius, err := strconv.ParseFloat(c.Query("radius"), 64)
+	if err != nil || !(radius > 0) || math.IsInf(radius, 0) {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "radius must be a positive number")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultNodeListLimit)))
+	if err != nil || limit < 1 || limit > maxNodeListLimit {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("Invalid limit, expected 1 to %d", maxNodeListLimit))
+		return
+	}
+
+	found, err := h.router.Nearby(c.Request.Context(), coordinates, radius, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch nodes"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch nodes")
 		return
 	}
 
+	unit := h.router.DistanceUnit()
+	nodes := make([]NearbyNode, len(found))
+	for i, candidate := range found {
+		nodes[i] = NearbyNode{Node: candidate.Node, Distance: candidate.Distance, DistanceUnit: unit}
+	}
 	c.JSON(http.StatusOK, nodes)
 }
 
+// GET /api/v1/nodes/:id
+//
+// @Summary Get a node
+// @Tags nodes
+// @Produce json
+// @Param id path string true "Node ID" format(uuid)
+// @Success 200 {object} models.Node
+// @Failure 400 {object} apierror.Response
+// @Failure 404 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/nodes/{id} [get]
+func (h *PublicHandler) GetNode(c *gin.Context) {
+	nodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
+		return
+	}
+
+	node, err := h.db.Queries.GetNodeByID(c.Request.Context(), pgtype.UUID{Bytes: nodeID, Valid: true})
+	if err != nil {
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, apierror.CodeNodeNotFound, "Node not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch node")
+		return
+	}
+
+	c.JSON(http.StatusOK, routing.ConvertDBNodeToModel(node))
+}
+
 // POST /api/v1/nodes/register
+//
+// @Summary Register a node
+// @Description Requires the shared NODE_REGISTRATION_TOKEN or a one-time
+// @Description token minted through the admin API. A one-time token is spent
+// @Description by the registration it makes and afterwards only replays it
+// @Description for retries with the same Idempotency-Key.
+// @Tags nodes
+// @Accept json
+// @Produce json
+// @Param X-Registration-Token header string true "Registration token"
+// @Param node body RegisterNodeRequest true "Node to register"
+// @Param skip_probe query bool false "Accept the node without probing its health check"
+// @Param Idempotency-Key header string false "Replays the first response for retries with the same key and body"
+// @Success 201 {object} models.Node
+// @Failure 400 {object} apierror.Response
+// @Failure 401 {object} apierror.Response "Missing or invalid registration token"
+// @Failure 409 {object} apierror.Response "Endpoint already registered, or Idempotency-Key reused with a different body"
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/nodes/register [post]
 func (h *PublicHandler) RegisterNode(c *gin.Context) {
+	ctx := c.Request.Context()
+	grant, err := h.checkRegistrationToken(ctx, c.GetHeader(registrationTokenHeader))
+	if err != nil {
+		respondRegistrationTokenError(c, err)
+		return
+	}
+
 	var req RegisterNodeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondValidationError(c, err)
+		return
+	}
+	if !validLocation(c, h.router, "location", req.Location) || !normalEndpoint(c, &req.Endpoint) {
+		return
+	}
+
+	key := c.GetHeader(idempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLength {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Idempotency-Key is too long")
+		return
+	}
+	var requestHash string
+	if key != "" {
+		requestHash = hashRequest(req)
+		if h.replayIdempotent(c, key, requestHash) {
+			return
+		}
+	}
+	if grant.Spent {
+		respondRegistrationTokenError(c, errInvalidRegistrationToken)
+		return
+	}
+	protocol, path := healthCheckFor(req.Endpoint, req.HealthProtocol, req.HealthPath)
+	if !reachableEndpoint(c, h.monitor, req.Endpoint, protocol, path) {
+		return
+	}
+
+	var node models.Node
+	err = h.db.WithTx(ctx, func(q db.Querier) error {
+		created, err := q.CreateNode(ctx, registerNodeParams(req, protocol, path))
+		if err != nil {
+			return err
+		}
+		node = routing.ConvertDBNodeToModel(created)
+		if err := spendRegistrationToken(ctx, q, grant, node.ID); err != nil {
+			return err
+		}
+
+		if key == "" {
+			return nil
+		}
+		return saveIdempotentResponse(ctx, q, key, requestHash, http.StatusCreated, node, h.idempotencyTTL)
+	})
+	if err != nil {
+		// A concurrent retry with the same key may have registered the node
+		if key != "" && (errors.Is(err, errIdempotencyKeyInUse) || database.IsUniqueViolation(err)) &&
+			h.replayIdempotent(c, key, requestHash) {
+			return
+		}
+		switch {
+		case errors.Is(err, errIdempotencyKeyInUse):
+			respondError(c, http.StatusConflict, apierror.CodeConflict, "A request with this Idempotency-Key is already in progress")
+			return
+		case errors.Is(err, errInvalidRegistrationToken):
+			respondRegistrationTokenError(c, err)
+			return
+		case database.IsUniqueViolation(err):
+			respondError(c, http.StatusConflict, apierror.CodeEndpointConflict, "A node with this endpoint already exists")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to register node")
+		return
+	}
+
+	h.router.InvalidateIndex()
+
+	// Record and broadcast the change
+	h.events.Record(ctx, events.Event{
+		NodeID: node.ID,
+		Type:   "node_registered",
+		Actor:  events.ActorNode,
+		After:  &node,
+	})
+
+	c.JSON(http.StatusCreated, node)
+}
+
+// POST /api/v1/nodes/:id/heartbeat
+//
+// @Summary Push a node's load
+// @Description Nodes the supervisor cannot reach can report their own load. A recent heartbeat counts as a passed health check.
+// @Description Requires the shared NODE_REGISTRATION_TOKEN or the one-time token the node registered with.
+// @Description Inactive nodes and nodes in maintenance are refused.
+// @Tags nodes
+// @Accept json
+// @Produce json
+// @Param X-Registration-Token header string true "Registration token"
+// @Param id path string true "Node ID" format(uuid)
+// @Param heartbeat body health.HealthResponse true "Current load, shaped like the node's /health response"
+// @Success 200 {object} models.Node
+// @Failure 400 {object} apierror.Response
+// @Failure 401 {object} apierror.Response "Missing or invalid registration token"
+// @Failure 404 {object} apierror.Response
+// @Failure 409 {object} apierror.Response "Node is inactive or in maintenance"
+// @Failure 500 {object} apierror.Response
+// @Router /api/v1/nodes/{id}/heartbeat [post]
+func (h *PublicHandler) Heartbeat(c *gin.Context) {
+	nodeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid node ID")
+		return
+	}
+
+	var req health.HealthResponse
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if req.NodeID != "" && req.NodeID != nodeID.String() {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "node_id does not match the node in the path")
+		return
+	}
+	if req.Load.CPUPercent < 0 || req.Load.MemoryPercent < 0 || req.Load.ActiveConnections < 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "load values must not be negative")
 		return
 	}
 
-	// Create node in database would go here
-	// For now, just return a mock response
-	nodeUUID := uuid.New()
-	mockNode := models.Node{
-		ID:        nodeUUID,
-		Name:      req.Name,
-		LocationX: req.Location.X,
-		LocationY: req.Location.Y,
-		Endpoint:  req.Endpoint,
-		Capacity:  100,
-		Status:    "active",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	ctx := c.Request.Context()
+	if err := h.checkHeartbeatToken(ctx, c.GetHeader(registrationTokenHeader), nodeID); err != nil {
+		respondRegistrationTokenError(c, err)
+		return
 	}
 
-	// Broadcast update
-	h.wsHub.Broadcast <- websocket.Message{
-		Type: "node_registered",
-		Data: mockNode,
+	node, err := h.monitor.RecordHeartbeat(ctx, nodeID, req)
+	if err != nil {
+		switch {
+		case database.IsNotFound(err):
+			respondError(c, http.StatusNotFound, apierror.CodeNodeNotFound, "Node not found")
+			return
+		case errors.Is(err, health.ErrHeartbeatRefused):
+			respondError(c, http.StatusConflict, apierror.CodeConflict, "Node is inactive or in maintenance")
+			return
+		}
+		h.logger.ErrorContext(ctx, "Failed to record heartbeat",
+			"request_id", logging.RequestID(ctx), "node_id", nodeID, "error", err)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to record heartbeat")
+		return
 	}
 
-	c.JSON(http.StatusCreated, mockNode)
+	c.JSON(http.StatusOK, node)
 }
 
 // GET /api/v1/health
+//
+// @Summary Supervisor liveness
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/health [get]
 func (h *PublicHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":    "healthy",
@@ -156,3 +1047,56 @@ func (h *PublicHandler) Health(c *gin.Context) {
 		"timestamp": time.Now().UTC(),
 	})
 }
+
+// GET /api/v1/health/cluster
+//
+// @Summary Cluster health summary
+// @Tags health
+// @Produce json
+// @Success 200 {object} ClusterHealth
+// @Failure 500 {object} apierror.Response
+// @Failure 503 {object} ClusterHealth "No healthy nodes"
+// @Router /api/v1/health/cluster [get]
+func (h *PublicHandler) ClusterHealth(c *gin.Context) {
+	nodes, err := h.router.GetAllNodes(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch nodes")
+		return
+	}
+
+	summary := ClusterHealth{
+		TotalNodes:    len(nodes),
+		NodesByStatus: make(map[string]int),
+		Timestamp:     time.Now().UTC(),
+	}
+	for _, node := range nodes {
+		summary.NodesByStatus[node.Status]++
+		if node.Status == models.NodeStatusHealthy {
+			summary.HealthyNodes++
+		}
+		// The oldest check reveals a stalled health monitor
+		if node.LastHealthCheck != nil &&
+			(summary.OldestHealthCheck == nil || node.LastHealthCheck.Before(*summary.OldestHealthCheck)) {
+			summary.OldestHealthCheck = node.LastHealthCheck
+		}
+	}
+
+	if summary.TotalNodes > 0 {
+		summary.HealthyRatio = float64(summary.HealthyNodes) / float64(summary.TotalNodes)
+	}
+
+	switch {
+	case summary.HealthyNodes == 0:
+		summary.Status = ClusterCritical
+	case summary.HealthyRatio >= clusterHealthyRatio:
+		summary.Status = ClusterHealthy
+	default:
+		summary.Status = ClusterDegraded
+	}
+
+	status := http.StatusOK
+	if summary.Status == ClusterCritical {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, summary)
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/db/dbtest"
+	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/routing"
+	"arx-supervisor/internal/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter serves the public handler backed by the fake queries, with
+// the supervisor's default routing weights.
+func newTestRouter(queries *dbtest.Querier) *gin.Engine {
+	database := &database.Database{Queries: queries}
+	router := routing.NewService(database, config.RoutingConfig{
+		KNearest:       3,
+		MaxDistance:    50,
+		LoadWeight:     0.6,
+		DistanceWeight: 0.4,
+		DistanceMode:   routing.DistanceModeEuclidean,
+		Strategy:       routing.StrategyBest,
+		LoadScoreWeights: config.LoadScoreWeights{
+			CPU:         0.4,
+			Memory:      0.3,
+			Connections: 0.3,
+		},
+		NormalLoadThreshold: 0.8,
+		LowLoadThreshold:    0.8,
+		AllowOverflow:       true,
+		TieEpsilon:          routing.DefaultTieEpsilon,
+	}, 0)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewPublicHandler(database, router, websocket.NewHub(config.WebSocketConfig{}, ""), nil, nil, logger, 0, 0, "", nil, nil)
+
+	r := gin.New()
+	r.POST("/api/v1/route", h.RouteRequest)
+	r.GET("/api/v1/route/:request_id", h.GetRoutingRequest)
+	r.GET("/api/v1/nodes/:id", h.GetNode)
+	return r
+}
+
+func serve(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var response apierror.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("error body %q: %v", w.Body.String(), err)
+	}
+	return response.Error.Code
+}
+
+func TestRouteRequest(t *testing.T) {
+	near := dbtest.Node("near", 1, 1)
+	far := dbtest.Node("far", 500, 500)
+
+	tests := []struct {
+		name     string
+		nodes    []db.Node
+		err      error
+		body     string
+		status   int
+		code     string
+		routedTo string
+		// recorded is the status of the stored routing request, empty when
+		// none should be stored
+		recorded string
+	}{
+		{
+			name:     "routes to the nearest node",
+			nodes:    []db.Node{far, near},
+			body:     `{"request_id": "req-1", "coordinates": {"x": 2, "y": 2}}`,
+			status:   http.StatusOK,
+			routedTo: "near",
+			recorded: models.RoutingStatusRouted,
+		},
+		{
+			name:     "no node within range",
+			nodes:    []db.Node{far},
+			body:     `{"request_id": "req-1", "coordinates": {"x": 2, "y": 2}}`,
+			status:   http.StatusServiceUnavailable,
+			code:     apierror.CodeNoHealthyNodes,
+			recorded: models.RoutingStatusFailed,
+		},
+		{
+			name:   "missing request ID",
+			nodes:  []db.Node{near},
+			body:   `{"coordinates": {"x": 2, "y": 2}}`,
+			status: http.StatusBadRequest,
+			code:   apierror.CodeValidation,
+		},
+		{
+			name:   "malformed body",
+			nodes:  []db.Node{near},
+			body:   `{"request_id": `,
+			status: http.StatusBadRequest,
+			code:   apierror.CodeValidation,
+		},
+		{
+			name:   "database error",
+			nodes:  []db.Node{near},
+			err:    errors.New("connection refused"),
+			body:   `{"request_id": "req-1", "coordinates": {"x": 2, "y": 2}}`,
+			status: http.StatusInternalServerError,
+			code:   apierror.CodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := dbtest.New(tt.nodes...)
+			queries.Err = tt.err
+			r := newTestRouter(queries)
+
+			w := serve(r, http.MethodPost, "/api/v1/route", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.code != "" {
+				if code := errorCode(t, w); code != tt.code {
+					t.Errorf("code = %q, want %q", code, tt.code)
+				}
+			}
+			if tt.routedTo != "" {
+				var response RouteResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("response %q: %v", w.Body.String(), err)
+				}
+				if response.RoutedTo.Name != tt.routedTo {
+					t.Errorf("routed to %q, want %q", response.RoutedTo.Name, tt.routedTo)
+				}
+			}
+
+			stored := queries.RoutingRequests()
+			if tt.recorded == "" {
+				if len(stored) != 0 {
+					t.Errorf("stored %d routing requests, want none", len(stored))
+				}
+				return
+			}
+			if len(stored) != 1 || stored[0].Status.String != tt.recorded {
+				t.Fatalf("stored %+v, want one %s request", stored, tt.recorded)
+			}
+			if stored[0].RequestData != nil {
+				t.Errorf("stored request data %s with payload capture off", stored[0].RequestData)
+			}
+		})
+	}
+}
+
+func TestGetRoutingRequest(t *testing.T) {
+	node := dbtest.Node("node", 1, 1)
+	queries := dbtest.New(node)
+	if _, err := queries.CreateRoutingRequest(t.Context(), db.CreateRoutingRequestParams{
+		RequestID:      "req-1",
+		SelectedNodeID: node.ID,
+		Distance:       pgtype.Float8{Float64: 1.5, Valid: true},
+		Status:         pgtype.Text{String: models.RoutingStatusRouted, Valid: true},
+		ClientInfo:     []byte(`{"client_id": "secret-client"}`),
+		RequestData:    []byte(`{"client_id": "secret-client"}`),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		id     string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "known request", id: "req-1", status: http.StatusOK},
+		{name: "unknown request", id: "req-2", status: http.StatusNotFound, code: apierror.CodeRequestNotFound},
+		{name: "database error", id: "req-1", err: errors.New("connection refused"), status: http.StatusInternalServerError, code: apierror.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries.Err = tt.err
+			r := newTestRouter(queries)
+
+			w := serve(r, http.MethodGet, "/api/v1/route/"+tt.id, "")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.code != "" {
+				if code := errorCode(t, w); code != tt.code {
+					t.Errorf("code = %q, want %q", code, tt.code)
+				}
+				return
+			}
+			if strings.Contains(w.Body.String(), "secret-client") {
+				t.Errorf("public lookup leaked the client: %s", w.Body.String())
+			}
+			var routed RoutedRequest
+			if err := json.Unmarshal(w.Body.Bytes(), &routed); err != nil {
+				t.Fatal(err)
+			}
+			if routed.SelectedNodeID == nil || *routed.SelectedNodeID != node.ID.Bytes || routed.Status != models.RoutingStatusRouted {
+				t.Errorf("got %+v, want the routed request", routed)
+			}
+		})
+	}
+}
+
+func TestGetNode(t *testing.T) {
+	node := dbtest.Node("node", 1, 1)
+	deleted := dbtest.Node("deleted", 2, 2)
+	deleted.DeletedAt = deleted.CreatedAt
+
+	tests := []struct {
+		name   string
+		id     string
+		status int
+		code   string
+	}{
+		{name: "known node", id: node.ID.String(), status: http.StatusOK},
+		{name: "deleted node", id: deleted.ID.String(), status: http.StatusNotFound, code: apierror.CodeNodeNotFound},
+		{name: "invalid ID", id: "not-a-uuid", status: http.StatusBadRequest, code: apierror.CodeValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter(dbtest.New(node, deleted))
+
+			w := serve(r, http.MethodGet, "/api/v1/nodes/"+tt.id, "")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.code != "" {
+				if code := errorCode(t, w); code != tt.code {
+					t.Errorf("code = %q, want %q", code, tt.code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// registrationTokenHeader carries the token a node registers with, over HTTP
+// and, lowercased, as gRPC metadata.
+const registrationTokenHeader = "X-Registration-Token"
+
+// defaultRegistrationTokenTTL is how long, in seconds, a one-time
+// registration token stays valid unless the admin asks otherwise. At most a
+// week may be asked for.
+const defaultRegistrationTokenTTL = 3600
+
+var (
+	errMissingRegistrationToken = errors.New("missing registration token")
+	errInvalidRegistrationToken = errors.New("invalid registration token")
+)
+
+// CreateRegistrationTokenRequest sets how long, in seconds, a one-time token
+// stays valid.
+type CreateRegistrationTokenRequest struct {
+	TTLSeconds int `json:"ttl_seconds,omitempty" binding:"omitempty,min=1,max=604800"`
+}
+
+// RegistrationToken is a newly minted one-time token. The token itself is
+// only returned here; the supervisor keeps just its hash.
+type RegistrationToken struct {
+	ID        uuid.UUID `json:"id"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// registrationGrant is what a registration token allows. OneTime is the hash
+// of a one-time token to spend on the registration, empty for the shared
+// token. Spent marks a one-time token that was already used, which is only
+// good for replaying the registration it made.
+type registrationGrant struct {
+	OneTime string
+	Spent   bool
+}
+
+// hashRegistrationToken returns the form one-time tokens are stored in.
+func hashRegistrationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkRegistrationToken accepts the shared NODE_REGISTRATION_TOKEN or a
+// one-time token minted by an admin that has not expired.
+func (h *PublicHandler) checkRegistrationToken(ctx context.Context, token string) (registrationGrant, error) {
+	if token == "" {
+		return registrationGrant{}, errMissingRegistrationToken
+	}
+	if h.registrationToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.registrationToken)) == 1 {
+		return registrationGrant{}, nil
+	}
+
+	hash := hashRegistrationToken(token)
+	stored, err := h.db.Queries.GetRegistrationToken(ctx, hash)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return registrationGrant{}, errInvalidRegistrationToken
+		}
+		return registrationGrant{}, err
+	}
+	return registrationGrant{OneTime: hash, Spent: stored.UsedAt.Valid}, nil
+}
+
+// checkHeartbeatToken accepts the shared NODE_REGISTRATION_TOKEN or the
+// one-time token nodeID registered with, which keeps authorizing that node's
+// heartbeats after it expires.
+func (h *PublicHandler) checkHeartbeatToken(ctx context.Context, token string, nodeID uuid.UUID) error {
+	if token == "" {
+		return errMissingRegistrationToken
+	}
+	if h.registrationToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.registrationToken)) == 1 {
+		return nil
+	}
+
+	_, err := h.db.Queries.GetNodeRegistrationToken(ctx, db.GetNodeRegistrationTokenParams{
+		TokenHash: hashRegistrationToken(token),
+		NodeID:    pgtype.UUID{Bytes: nodeID, Valid: true},
+	})
+	if database.IsNotFound(err) {
+		return errInvalidRegistrationToken
+	}
+	return err
+}
+
+// spendRegistrationToken marks the one-time token of grant used by nodeID,
+// inside the transaction creating the node so a failed registration leaves
+// the token valid. It fails with errInvalidRegistrationToken if another
+// registration spent the token first.
+func spendRegistrationToken(ctx context.Context, q db.Querier, grant registrationGrant, nodeID uuid.UUID) error {
+	if grant.OneTime == "" {
+		return nil
+	}
+	_, err := q.UseRegistrationToken(ctx, db.UseRegistrationTokenParams{
+		TokenHash: grant.OneTime,
+		NodeID:    pgtype.UUID{Bytes: nodeID, Valid: true},
+	})
+	if database.IsNotFound(err) {
+		return errInvalidRegistrationToken
+	}
+	return err
+}
+
+// respondRegistrationTokenError replies to a registration whose token was
+// not accepted.
+func respondRegistrationTokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errMissingRegistrationToken):
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Missing registration token")
+	case errors.Is(err, errInvalidRegistrationToken):
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid registration token")
+	default:
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check registration token")
+	}
+}
+
+// POST /admin/api/v1/nodes/registration-tokens
+//
+// @Summary Mint a one-time node registration token
+// @Description Returns a token a single node can register with, sent in the
+// @Description X-Registration-Token header of POST /api/v1/nodes/register.
+// @Description The token is shown only once and expires after ttl_seconds,
+// @Description one hour by default.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param token body CreateRegistrationTokenRequest false "Token lifetime"
+// @Success 201 {object} RegistrationToken
+// @Failure 400 {object} apierror.Response
+// @Security BearerAuth
+// @Failure 401 {object} apierror.Response
+// @Failure 403 {object} apierror.Response
+// @Failure 500 {object} apierror.Response
+// @Router /admin/api/v1/nodes/registration-tokens [post]
+func (h *AdminHandler) CreateRegistrationToken(c *gin.Context) {
+	var req CreateRegistrationTokenRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondValidationError(c, err)
+			return
+		}
+	}
+	ttl := req.TTLSeconds
+	if ttl == 0 {
+		ttl = defaultRegistrationTokenTTL
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate registration token")
+		return
+	}
+	token := hex.EncodeToString(secret)
+
+	ctx := c.Request.Context()
+	stored, err := h.db.Queries.CreateRegistrationToken(ctx, db.CreateRegistrationTokenParams{
+		TokenHash:  hashRegistrationToken(token),
+		CreatedBy:  adminActor(c),
+		TtlSeconds: int32(ttl),
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to create registration token",
+			"request_id", logging.RequestID(ctx), "error", err)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create registration token")
+		return
+	}
+
+	c.JSON(http.StatusCreated, RegistrationToken{
+		ID:        uuid.UUID(stored.ID.Bytes),
+		Token:     token,
+		ExpiresAt: stored.ExpiresAt.Time,
+	})
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/routing"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// exportCSVHeader lists the columns written by the routing request CSV export.
+var exportCSVHeader = []string{
+	"request_id",
+	"coordinates_x",
+	"coordinates_y",
+	"selected_node_id",
+	"distance",
+	"load_score",
+	"status",
+	"response_time_ms",
+	"created_at",
+}
+
+// exportCSVRecord formats a routing request as a CSV row matching
+// exportCSVHeader. Missing values are written as empty fields.
+func exportCSVRecord(request db.RoutingRequest) []string {
+	record := []string{
+		request.RequestID,
+		strconv.FormatFloat(request.CoordinatesX, 'f', -1, 64),
+		strconv.FormatFloat(request.CoordinatesY, 'f', -1, 64),
+		"", "", "", "", "",
+		request.CreatedAt.Time.UTC().Format(time.RFC3339),
+	}
+	if request.SelectedNodeID.Valid {
+		record[3] = uuid.UUID(request.SelectedNodeID.Bytes).String()
+	}
+	if request.Distance.Valid {
+		record[4] = strconv.FormatFloat(request.Distance.Float64, 'f', -1, 64)
+	}
+	if request.LoadScore.Valid {
+		record[5] = strconv.FormatFloat(request.LoadScore.Float64, 'f', -1, 64)
+	}
+	if request.Status.Valid {
+		record[6] = request.Status.String
+	}
+	if request.ResponseTimeMs.Valid {
+		record[7] = strconv.Itoa(int(request.ResponseTimeMs.Int32))
+	}
+	return record
+}
+
+// RoutingRequestDetail is a routing request with its decision audit decoded
+// from the processing metrics. Decision is omitted for requests recorded
+// before audits were kept.
+type RoutingRequestDetail struct {
+	models.RoutingRequest
+	Decision *routing.Decision `json:"decision,omitempty"`
+}
+
+func convertDBRoutingRequest(request db.RoutingRequest) models.RoutingRequest {
+	result := models.RoutingRequest{
+		ID:           uuid.UUID(request.ID.Bytes),
+		RequestID:    request.RequestID,
+		CoordinatesX: request.CoordinatesX,
+		CoordinatesY: request.CoordinatesY,
+		Status:       request.Status.String,
+		CreatedAt:    request.CreatedAt.Time,
+	}
+
+	if request.SelectedNodeID.Valid {
+		id := uuid.UUID(request.SelectedNodeID.Bytes)
+		result.SelectedNodeID = &id
+	}
+	for _, id := range request.FallbackNodeIds {
+		result.FallbackNodeIDs = append(result.FallbackNodeIDs, uuid.UUID(id.Bytes))
+	}
+	if request.Distance.Valid {
+		result.Distance = &request.Distance.Float64
+	}
+	if request.LoadScore.Valid {
+		result.LoadScore = &request.LoadScore.Float64
+	}
+	if request.ResponseTimeMs.Valid {
+		ms := int(request.ResponseTimeMs.Int32)
+		result.ResponseTimeMs = &ms
+	}
+	if request.FailedNodeID.Valid {
+		id := uuid.UUID(request.FailedNodeID.Bytes)
+		result.FailedNodeID = &id
+	}
+	result.FailureReason = request.FailureReason.String
+	if request.FailureReportedAt.Valid {
+		result.FailureReportedAt = &request.FailureReportedAt.Time
+	}
+
+	// The Scan helpers accept []byte and map nil to NULL, so they cannot fail here
+	result.ScanRequestData(request.RequestData)
+	result.ScanResponseData(request.ResponseData)
+	result.ScanMetadata(request.Metadata)
+	result.ScanClientInfo(request.ClientInfo)
+	result.ScanProcessingMetrics(request.ProcessingMetrics)
+
+	return result
+}
+
+// parseTimeQuery reads an optional RFC3339 query parameter. It writes a 400
+// response and returns false if the value is malformed.
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid "+name+", expected RFC3339 timestamp")
+		return nil, false
+	}
+	return &t, true
+}
@@ -0,0 +1,65 @@
+// Package apierror defines the envelope every HTTP error reply is wrapped in,
+// e.g. {"error":{"code":"NODE_NOT_FOUND","message":"Node not found"}}.
+// Clients should branch on the code; messages are for humans and may change.
+package apierror
+
+import "github.com/gin-gonic/gin"
+
+// Error codes
+const (
+	// CodeValidation marks malformed or invalid input. Details map each
+	// offending field to what is wrong with it, when known.
+	CodeValidation = "VALIDATION_ERROR"
+
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeForbidden    = "FORBIDDEN"
+	CodeRateLimited  = "RATE_LIMITED"
+
+	CodeNodeNotFound        = "NODE_NOT_FOUND"
+	CodeEndpointConflict    = "ENDPOINT_CONFLICT"
+	CodeEndpointUnreachable = "ENDPOINT_UNREACHABLE"
+	CodeNodeReferenced      = "NODE_REFERENCED"
+	CodeNoHealthyNodes      = "NO_HEALTHY_NODES"
+	CodeRequestNotFound     = "REQUEST_NOT_FOUND"
+	CodeClusterNotFound     = "CLUSTER_NOT_FOUND"
+	// CodeClusterConflict marks a cluster name already taken, or a cluster
+	// deleted while live nodes still belong to it
+	CodeClusterConflict = "CLUSTER_CONFLICT"
+
+	// CodeConflict covers requests clashing with one in progress or already
+	// made, such as a reused Idempotency-Key or an overlapping prune.
+	CodeConflict = "CONFLICT"
+
+	CodePayloadTooLarge = "PAYLOAD_TOO_LARGE"
+	CodeShuttingDown    = "SHUTTING_DOWN"
+	// CodeTimeout marks requests that ran out of REQUEST_TIMEOUT_MS
+	CodeTimeout  = "TIMEOUT"
+	CodeInternal = "INTERNAL_ERROR"
+)
+
+// Error describes what went wrong.
+type Error struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Response is the body of every error reply.
+type Response struct {
+	Error Error `json:"error"`
+}
+
+// Respond writes an error reply.
+func Respond(c *gin.Context, status int, code, message string) {
+	RespondWithDetails(c, status, code, message, nil)
+}
+
+// RespondWithDetails writes an error reply carrying details.
+func RespondWithDetails(c *gin.Context, status int, code, message string, details map[string]interface{}) {
+	c.JSON(status, Response{Error: Error{Code: code, Message: message, Details: details}})
+}
+
+// Abort writes an error reply and stops the handler chain, for middleware.
+func Abort(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, Response{Error: Error{Code: code, Message: message}})
+}
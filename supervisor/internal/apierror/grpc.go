@@ -0,0 +1,62 @@
+package apierror
+
+import (
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain names the supervisor in the ErrorInfo detail of gRPC errors.
+const Domain = "arx-supervisor"
+
+// grpcCodes maps error codes onto the closest gRPC status codes.
+var grpcCodes = map[string]codes.Code{
+	CodeValidation:          codes.InvalidArgument,
+	CodeUnauthorized:        codes.Unauthenticated,
+	CodeForbidden:           codes.PermissionDenied,
+	CodeRateLimited:         codes.ResourceExhausted,
+	CodeNodeNotFound:        codes.NotFound,
+	CodeRequestNotFound:     codes.NotFound,
+	CodeClusterNotFound:     codes.NotFound,
+	CodeClusterConflict:     codes.FailedPrecondition,
+	CodeEndpointConflict:    codes.AlreadyExists,
+	CodeEndpointUnreachable: codes.FailedPrecondition,
+	CodeNodeReferenced:      codes.FailedPrecondition,
+	CodeNoHealthyNodes:      codes.Unavailable,
+	CodeConflict:            codes.Aborted,
+	CodePayloadTooLarge:     codes.ResourceExhausted,
+	CodeShuttingDown:        codes.Unavailable,
+	CodeTimeout:             codes.DeadlineExceeded,
+	CodeInternal:            codes.Internal,
+}
+
+// Error lets gRPC handlers return an Error directly.
+func (e Error) Error() string {
+	return e.Message
+}
+
+// GRPCStatus converts the error into a gRPC status carrying an ErrorInfo
+// whose reason is the error code and whose metadata holds the details, so
+// gRPC clients can branch on the same codes as HTTP clients.
+func (e Error) GRPCStatus() *status.Status {
+	code, ok := grpcCodes[e.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+
+	info := &errdetails.ErrorInfo{Reason: e.Code, Domain: Domain}
+	if len(e.Details) > 0 {
+		info.Metadata = make(map[string]string, len(e.Details))
+		for field, detail := range e.Details {
+			info.Metadata[field] = fmt.Sprint(detail)
+		}
+	}
+
+	st := status.New(code, e.Message)
+	if withInfo, err := st.WithDetails(info); err == nil {
+		return withInfo
+	}
+	return st
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"arx-supervisor/internal/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// ClaimsKey is the gin context key holding the authenticated *Claims.
+const ClaimsKey = "auth_claims"
+
+// AuthRequired validates the bearer JWT on each request and requires the
+// admin role. Missing, invalid or expired tokens are rejected with 401 and
+// tokens without the admin role with 403.
+func AuthRequired(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if secret == "" {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authentication is not configured")
+			return
+		}
+
+		claims, err := ParseToken(bearerToken(c.GetHeader("Authorization")), secret)
+		if err != nil {
+			message := "Invalid token"
+			switch {
+			case errors.Is(err, ErrMissingToken):
+				message = "Missing bearer token"
+			case errors.Is(err, ErrTokenExpired):
+				message = "Token has expired"
+			}
+			apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, message)
+			return
+		}
+
+		if claims.Role != RoleAdmin {
+			apierror.Abort(c, http.StatusForbidden, apierror.CodeForbidden, "Admin role required")
+			return
+		}
+
+		c.Set(ClaimsKey, claims)
+		c.Next()
+	}
+}
+
+func bearerToken(header string) string {
+	const prefix = "Bearer "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(prefix):])
+}
@@ -3,18 +3,66 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Routing  RoutingConfig
-	Health   HealthConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Routing   RoutingConfig
+	Health    HealthConfig
+	Auth      AuthConfig
+	WebSocket WebSocketConfig
+	RateLimit RateLimitConfig
+	Retention RetentionConfig
+	PeerSync  PeerSyncConfig
+	State     StateConfig
+	Capture   CaptureConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Host string
+	// GRPCPort serves the gRPC routing API. "0" disables it.
+	GRPCPort string
+	// LogLevel is one of debug, info, warn or error
+	LogLevel string
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set.
+	// TLSMinVersion is "1.2" or "1.3".
+	TLSCertFile   string
+	TLSKeyFile    string
+	TLSMinVersion string
+	// AllowedOrigins may make cross-origin browser requests. "*" allows
+	// any origin without credentials.
+	AllowedOrigins []string
+	// IdempotencyTTL is how long, in seconds, responses to requests sent
+	// with an Idempotency-Key are kept for replay.
+	IdempotencyTTL int
+	// RequestTimeout bounds, in milliseconds, how long routing a request
+	// may take, database calls included. Zero disables the limit.
+	RequestTimeout int
+	// HTTP server timeouts in seconds; zero disables each. ReadTimeout
+	// covers the whole request including the body, WriteTimeout the time
+	// from the end of the request headers to the end of the response and
+	// IdleTimeout how long a keep-alive connection waits for the next
+	// request.
+	ReadTimeout       int
+	ReadHeaderTimeout int
+	WriteTimeout      int
+	IdleTimeout       int
+	// HTTP2 serves HTTP/2 to clients that negotiate it over TLS. H2C also
+	// accepts HTTP/2 without TLS, for use behind a proxy that speaks it.
+	HTTP2 bool
+	H2C   bool
+	// ShutdownDrainDelay is how long, in seconds, the server keeps serving
+	// with readiness failing before it stops accepting connections, so load
+	// balancers can stop sending traffic first.
+	ShutdownDrainDelay int
+}
+
+// TLSEnabled reports whether the server should serve HTTPS.
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
 }
 
 type DatabaseConfig struct {
@@ -24,6 +72,21 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// Pool sizing; zero leaves the pgxpool default. MaxConnLifetime is in
+	// seconds.
+	MaxConns        int
+	MinConns        int
+	MaxConnLifetime int
+	// ConnectRetries is how many times the initial ping is retried, with
+	// exponential backoff starting at ConnectRetryDelay seconds.
+	ConnectRetries    int
+	ConnectRetryDelay int
+	// BreakerThreshold consecutive connection failures on the routing path
+	// stop database calls for BreakerCooldown seconds, except for one trial
+	// call per cooldown. Zero disables the breaker.
+	BreakerThreshold int
+	BreakerCooldown  int
 }
 
 type RoutingConfig struct {
@@ -31,19 +94,198 @@ type RoutingConfig struct {
 	MaxDistance    float64
 	LoadWeight     float64
 	DistanceWeight float64
+	DistanceMode   string
+	// LoadScoreWeights weigh CPU, memory and connection usage against each
+	// other in a node's load score.
+	LoadScoreWeights LoadScoreWeights
+	// CoordinateSystem is "cartesian" for plane X/Y or "geographic" for
+	// longitude/latitude and must match DistanceMode. Empty picks the
+	// system DistanceMode works in.
+	CoordinateSystem string
+	// ProjectionLatitude is the latitude, in degrees, the projected distance
+	// mode flattens the globe about.
+	ProjectionLatitude float64
+	// Strategy is "best" to route to the best scored candidate, "p2c" to
+	// route to the less loaded of two random candidates or "least-conn" to
+	// route to the candidate with the fewest connections for its capacity.
+	Strategy string
+	// Load score above which nodes stop accepting normal and low priority
+	// requests. High priority requests may use nodes up to full load.
+	NormalLoadThreshold float64
+	LowLoadThreshold    float64
+	// AllowOverflow lets requests fall back to saturated nodes (active
+	// connections at or above capacity) when no other node is available.
+	AllowOverflow bool
+	// Fallbacks is how many backup nodes are returned with each route,
+	// bounded by the KNearest candidates considered.
+	Fallbacks int
+	// NodeCacheTTL is how long, in seconds, the healthy node set is cached
+	// between database reads. Zero disables the cache.
+	NodeCacheTTL int
+	// TieEpsilon is the score difference below which candidates are treated
+	// as equal and ordered by active connections, then rotated per request.
+	TieEpsilon float64
+	// StaleAfter is how long, in seconds, since its last health check a
+	// node's load stats count as stale, adding StalePenalty to its load
+	// score. Nodes unchecked for ExcludeAfter seconds are not routed to at
+	// all. Zero disables either.
+	StaleAfter   int
+	StalePenalty float64
+	ExcludeAfter int
+	// InFlightTTL is how long, in seconds, a routed request counts towards
+	// its node's connections in the load score, until the node's own stats
+	// reflect it. Zero disables in-flight tracking.
+	InFlightTTL int
+	// FailureTTL is how long, in seconds, a failure a client reports against
+	// a node adds StalePenalty to its load score. Zero ignores reports.
+	FailureTTL int
+	// SelectionCooldown is how long, in milliseconds, a selected node has
+	// CooldownPenalty added to its load score so bursts spread over several
+	// nodes. Zero disables the cooldown.
+	SelectionCooldown int
+	CooldownPenalty   float64
+	// Seed, when set, replaces the request ID as the seed of tie-breaking
+	// and p2c sampling for requests that do not send their own, so the same
+	// nodes and stats always produce the same decision. Meant for tests and
+	// debugging.
+	Seed string
+}
+
+// LoadScoreWeights are the shares of CPU usage, memory usage and connections
+// (relative to capacity) in a node's load score. They sum to 1 so load scores
+// stay between 0 and 1.
+type LoadScoreWeights struct {
+	CPU         float64
+	Memory      float64
+	Connections float64
 }
 
 type HealthConfig struct {
 	CheckInterval    int
 	Timeout          int
 	FailureThreshold int
+	// CheckConcurrency caps the health checks running at once
+	CheckConcurrency int
+	// MaxCheckAge is how long, in seconds, a healthy node may go without a
+	// health check or heartbeat before routing stops loading it. Zero
+	// disables the limit.
+	MaxCheckAge int
+	// BreakerThreshold consecutive failures open a node's circuit breaker,
+	// pausing its checks for BreakerCooldown seconds.
+	BreakerThreshold int
+	BreakerCooldown  int
+	// MaxBackoff caps, in seconds, how far checks of a failing node are
+	// spread out.
+	MaxBackoff int
+	// DrainPeriod is how long, in seconds, a draining node stays registered
+	// before it is removed. Zero keeps it until it is deleted explicitly.
+	DrainPeriod int
+	// DeregisterAfter is how long, in seconds, a node may stay unhealthy
+	// before it is deregistered: set inactive, or soft-deleted when
+	// AutoDeregister is set. Zero keeps unhealthy nodes forever.
+	DeregisterAfter int
+	AutoDeregister  bool
+	// StatsInterval is how often, in seconds, a cluster_stats summary is
+	// pushed to WebSocket clients. Zero disables it.
+	StatsInterval int
+	// CapacityWarningThreshold is the connection or CPU utilization, as a
+	// fraction, at which a node_capacity_warning is broadcast. Zero
+	// disables the warning.
+	CapacityWarningThreshold float64
+}
+
+type AuthConfig struct {
+	JWTSecret string
+	// RegistrationToken is the shared token nodes register with. When empty
+	// nodes can only register with one-time tokens minted by an admin.
+	RegistrationToken string
+}
+
+// RateLimitConfig limits requests per client on the public route endpoint.
+// An RPS of zero or less disables rate limiting.
+type RateLimitConfig struct {
+	RPS   float64
+	Burst int
+}
+
+// WebSocketConfig controls keepalive for realtime clients. All values are in
+// seconds; a client that does not answer a ping within PongWait is dropped.
+type WebSocketConfig struct {
+	PingInterval int
+	PongWait     int
+	WriteWait    int
+	// ReplayBufferSize is how many recent broadcasts are kept for clients
+	// that ask to replay after reconnecting.
+	ReplayBufferSize int
+	// BroadcastBuffer is how many broadcasts may wait for the hub, and
+	// OverflowPolicy what publishing does once that many are waiting:
+	// "drop-newest", "drop-oldest" or "block".
+	BroadcastBuffer int
+	OverflowPolicy  string
+}
+
+// PeerSyncConfig controls sharing node changes with other supervisors on
+// the same database over LISTEN/NOTIFY.
+type PeerSyncConfig struct {
+	Enabled bool
+}
+
+// StateConfig selects where state shared between replicas, such as rate
+// limits, is kept: in memory when RedisAddr is empty, in Redis otherwise.
+type StateConfig struct {
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+}
+
+// RetentionConfig controls pruning of routing requests and system metrics.
+// Rows older than Days are deleted every Interval seconds, BatchSize rows
+// per statement. A Days of zero or less keeps rows forever.
+type RetentionConfig struct {
+	Days      int
+	Interval  int
+	BatchSize int
+}
+
+// CaptureConfig controls storing routing responses with their routing
+// requests. Mode is "off", "opt-in" for requests that ask for it, or
+// "always". Captured payloads larger than MaxBytes are replaced by a
+// truncation marker, and fields named in RedactFields are masked first.
+type CaptureConfig struct {
+	Mode         string
+	MaxBytes     int
+	RedactFields []string
 }
 
 func Load() Config {
+	// Staleness windows are given in health check intervals
+	checkInterval := getEnvInt("HEALTH_CHECK_INTERVAL", 30)
+
 	return Config{
 		Server: ServerConfig{
 			Port: getEnv("SERVER_PORT", "8080"),
 			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+
+			GRPCPort: getEnv("GRPC_PORT", "9090"),
+
+			LogLevel: getEnv("LOG_LEVEL", "info"),
+
+			TLSCertFile:   getEnv("TLS_CERT_FILE", ""),
+			TLSKeyFile:    getEnv("TLS_KEY_FILE", ""),
+			TLSMinVersion: getEnv("TLS_MIN_VERSION", "1.2"),
+
+			AllowedOrigins: getEnvList("ALLOWED_ORIGINS", []string{"http://localhost:3000"}),
+			IdempotencyTTL: getEnvInt("IDEMPOTENCY_TTL", 86400),
+			RequestTimeout: getEnvInt("REQUEST_TIMEOUT_MS", 5000),
+
+			ReadTimeout:       getEnvInt("SERVER_READ_TIMEOUT", 15),
+			ReadHeaderTimeout: getEnvInt("SERVER_READ_HEADER_TIMEOUT", 5),
+			WriteTimeout:      getEnvInt("SERVER_WRITE_TIMEOUT", 30),
+			IdleTimeout:       getEnvInt("SERVER_IDLE_TIMEOUT", 120),
+			HTTP2:             getEnvBool("HTTP2_ENABLED", true),
+			H2C:               getEnvBool("H2C_ENABLED", false),
+
+			ShutdownDrainDelay: getEnvInt("SHUTDOWN_DRAIN_DELAY", 5),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -52,17 +294,97 @@ func Load() Config {
 			Password: getEnv("DB_PASSWORD", "password"),
 			DBName:   getEnv("DB_NAME", "arx_supervisor"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+
+			MaxConns:          getEnvInt("DB_MAX_CONNS", 0),
+			MinConns:          getEnvInt("DB_MIN_CONNS", 0),
+			MaxConnLifetime:   getEnvInt("DB_MAX_CONN_LIFETIME", 0),
+			ConnectRetries:    getEnvInt("DB_CONNECT_RETRIES", 5),
+			ConnectRetryDelay: getEnvInt("DB_CONNECT_RETRY_DELAY", 1),
+			BreakerThreshold:  getEnvInt("DB_BREAKER_THRESHOLD", 3),
+			BreakerCooldown:   getEnvInt("DB_BREAKER_COOLDOWN", 5),
 		},
 		Routing: RoutingConfig{
 			KNearest:       getEnvInt("K_NEAREST", 3),
 			MaxDistance:    getEnvFloat("MAX_DISTANCE", 50.0),
 			LoadWeight:     getEnvFloat("LOAD_WEIGHT", 0.6),
 			DistanceWeight: getEnvFloat("DISTANCE_WEIGHT", 0.4),
+			DistanceMode:   getEnv("DISTANCE_MODE", "euclidean"),
+			Strategy:       getEnv("ROUTING_STRATEGY", "best"),
+
+			LoadScoreWeights: LoadScoreWeights{
+				CPU:         getEnvFloat("LOAD_SCORE_CPU_WEIGHT", 0.4),
+				Memory:      getEnvFloat("LOAD_SCORE_MEMORY_WEIGHT", 0.3),
+				Connections: getEnvFloat("LOAD_SCORE_CONNECTION_WEIGHT", 0.3),
+			},
+
+			CoordinateSystem:   getEnv("COORDINATE_SYSTEM", ""),
+			ProjectionLatitude: getEnvFloat("PROJECTION_LATITUDE", 0),
+
+			NormalLoadThreshold: getEnvFloat("NORMAL_PRIORITY_LOAD_THRESHOLD", 0.8),
+			LowLoadThreshold:    getEnvFloat("LOW_PRIORITY_LOAD_THRESHOLD", 0.8),
+			AllowOverflow:       getEnvBool("ROUTING_ALLOW_OVERFLOW", true),
+			Fallbacks:           getEnvInt("ROUTING_FALLBACKS", 1),
+			NodeCacheTTL:        getEnvInt("ROUTING_NODE_CACHE_TTL", 1),
+			TieEpsilon:          getEnvFloat("ROUTING_TIE_EPSILON", 0.001),
+			StaleAfter:          getEnvInt("ROUTING_STALE_INTERVALS", 2) * checkInterval,
+			StalePenalty:        getEnvFloat("ROUTING_STALE_PENALTY", 0.5),
+			ExcludeAfter:        getEnvInt("ROUTING_EXCLUDE_INTERVALS", 5) * checkInterval,
+			InFlightTTL:         getEnvInt("ROUTING_INFLIGHT_TTL", 5),
+			FailureTTL:          getEnvInt("ROUTING_FAILURE_TTL", 30),
+			SelectionCooldown:   getEnvInt("ROUTING_SELECTION_COOLDOWN_MS", 0),
+			CooldownPenalty:     getEnvFloat("ROUTING_COOLDOWN_PENALTY", 0.1),
+			Seed:                getEnv("ROUTING_SEED", ""),
 		},
 		Health: HealthConfig{
-			CheckInterval:    getEnvInt("HEALTH_CHECK_INTERVAL", 30),
+			CheckInterval:    checkInterval,
 			Timeout:          getEnvInt("HEALTH_TIMEOUT", 5),
 			FailureThreshold: getEnvInt("HEALTH_FAILURE_THRESHOLD", 3),
+			CheckConcurrency: getEnvInt("HEALTH_CHECK_CONCURRENCY", 50),
+			MaxCheckAge:      getEnvInt("HEALTH_MAX_CHECK_INTERVALS", 5) * checkInterval,
+			BreakerThreshold: getEnvInt("HEALTH_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  getEnvInt("HEALTH_BREAKER_COOLDOWN", 60),
+			MaxBackoff:       getEnvInt("HEALTH_MAX_BACKOFF", 300),
+			DrainPeriod:      getEnvInt("NODE_DRAIN_PERIOD", 300),
+			DeregisterAfter:  getEnvInt("HEALTH_DEREGISTER_AFTER", 3600),
+			AutoDeregister:   getEnvBool("AUTO_DEREGISTER", false),
+			StatsInterval:    getEnvInt("CLUSTER_STATS_INTERVAL", 5),
+
+			CapacityWarningThreshold: getEnvFloat("CAPACITY_WARNING_THRESHOLD", 0.85),
+		},
+		Auth: AuthConfig{
+			JWTSecret:         getEnv("JWT_SECRET", ""),
+			RegistrationToken: getEnv("NODE_REGISTRATION_TOKEN", ""),
+		},
+		RateLimit: RateLimitConfig{
+			RPS:   getEnvFloat("RATE_LIMIT_RPS", 10),
+			Burst: getEnvInt("RATE_LIMIT_BURST", 20),
+		},
+		WebSocket: WebSocketConfig{
+			PingInterval: getEnvInt("WS_PING_INTERVAL", 30),
+			PongWait:     getEnvInt("WS_PONG_WAIT", 60),
+			WriteWait:    getEnvInt("WS_WRITE_WAIT", 10),
+
+			ReplayBufferSize: getEnvInt("WS_REPLAY_BUFFER", 100),
+			BroadcastBuffer:  getEnvInt("WS_BROADCAST_BUFFER", 256),
+			OverflowPolicy:   getEnv("WS_OVERFLOW_POLICY", "drop-newest"),
+		},
+		Retention: RetentionConfig{
+			Days:      getEnvInt("RETENTION_DAYS", 0),
+			Interval:  getEnvInt("RETENTION_INTERVAL", 3600),
+			BatchSize: getEnvInt("RETENTION_BATCH_SIZE", 1000),
+		},
+		PeerSync: PeerSyncConfig{
+			Enabled: getEnvBool("PEER_SYNC", true),
+		},
+		State: StateConfig{
+			RedisAddr:     getEnv("REDIS_ADDR", ""),
+			RedisPassword: getEnv("REDIS_PASSWORD", ""),
+			RedisDB:       getEnvInt("REDIS_DB", 0),
+		},
+		Capture: CaptureConfig{
+			Mode:         getEnv("CAPTURE_PAYLOADS", "off"),
+			MaxBytes:     getEnvInt("CAPTURE_MAX_BYTES", 16384),
+			RedactFields: getEnvList("CAPTURE_REDACT_FIELDS", nil),
 		},
 	}
 }
@@ -74,6 +396,22 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -83,6 +421,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvFloat(key string, defaultValue float64) float64 {
 	if value := os.Getenv(key); value != "" {
 		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
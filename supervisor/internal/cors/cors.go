@@ -0,0 +1,47 @@
+// Package cors restricts cross-origin browser access to configured origins.
+package cors
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Wildcard allows every origin. Credentialed requests are not allowed with a
+// wildcard, so it is only meant for local development.
+const Wildcard = "*"
+
+// Middleware echoes the request's Origin back when it is in allowed and
+// answers preflight requests. Requests from other origins get no CORS
+// headers, so browsers refuse to expose the response.
+func Middleware(allowed []string) gin.HandlerFunc {
+	origins := make(map[string]struct{}, len(allowed))
+	for _, origin := range allowed {
+		origins[origin] = struct{}{}
+	}
+	_, wildcard := origins[Wildcard]
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		c.Header("Vary", "Origin")
+
+		if origin != "" {
+			if _, ok := origins[origin]; ok {
+				c.Header("Access-Control-Allow-Origin", origin)
+				c.Header("Access-Control-Allow-Credentials", "true")
+			} else if wildcard {
+				c.Header("Access-Control-Allow-Origin", Wildcard)
+			}
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+			c.Header("Access-Control-Expose-Headers", "X-Total-Count, X-Request-ID")
+		}
+
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
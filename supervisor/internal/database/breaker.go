@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrUnavailable is returned instead of calling the database while its
+// circuit breaker is open.
+var ErrUnavailable = errors.New("database unavailable")
+
+// IsUnavailable reports whether err means the database could not be reached,
+// as opposed to a query the server answered with an error.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	return !errors.As(err, &pgErr)
+}
+
+// Breaker keeps calls to an unreachable database from piling up connection
+// attempts. After threshold consecutive connection failures it opens and
+// Allow reports false, except for a single trial call every cooldown that
+// decides whether it closes again. A nil Breaker allows every call.
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	open      bool
+	openedAt  time.Time
+}
+
+// NewBreaker returns a breaker opening after threshold failures, or nil when
+// threshold is zero or less.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		return nil
+	}
+	return &Breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow reports whether a call may go to the database now.
+func (b *Breaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if now := time.Now(); now.Sub(b.openedAt) >= b.cooldown {
+		// Let one trial call through and wait another cooldown for the next
+		b.openedAt = now
+		return true
+	}
+	return false
+}
+
+// Record applies the outcome of a call. Cancelled calls say nothing about
+// the database and are ignored.
+func (b *Breaker) Record(err error) {
+	if b == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !IsUnavailable(err) {
+		b.failures = 0
+		b.open = false
+		return
+	}
+
+	b.failures++
+	if !b.open && b.failures >= b.threshold {
+		b.open = true
+		b.openedAt = time.Now()
+	}
+}
+
+// Guarded runs fn unless the breaker is open, recording its outcome.
+func (d *Database) Guarded(fn func() error) error {
+	if !d.Breaker.Allow() {
+		return ErrUnavailable
+	}
+	err := fn()
+	d.Breaker.Record(err)
+	return err
+}
@@ -3,11 +3,16 @@ package database
 import (
 	"context"
 	"fmt"
+	"log"
+	"time"
 
 	"arx-supervisor/internal/db"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// maxConnectRetryDelay caps the backoff between connection attempts.
+const maxConnectRetryDelay = 30 * time.Second
+
 type Config struct {
 	Host     string
 	Port     int
@@ -15,11 +20,26 @@ type Config struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	ConnectRetries    int
+	ConnectRetryDelay time.Duration
+
+	// BreakerThreshold consecutive connection failures open the circuit
+	// breaker for BreakerCooldown; zero disables it.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
 }
 
 type Database struct {
-	Pool    *pgxpool.Pool
-	Queries *db.Queries
+	Pool *pgxpool.Pool
+	// Queries is the generated query interface, so services can be given a
+	// fake in place of Postgres.
+	Queries db.Querier
+	// Breaker guards calls made on the request path; see Guarded.
+	Breaker *Breaker
 }
 
 func NewDatabase(ctx context.Context, config Config) (*Database, error) {
@@ -27,26 +47,82 @@ func NewDatabase(ctx context.Context, config Config) (*Database, error) {
 	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
 
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection config: %w", err)
+	}
+	if config.MaxConns > 0 {
+		poolConfig.MaxConns = config.MaxConns
+	}
+	if config.MinConns > 0 {
+		poolConfig.MinConns = config.MinConns
+	}
+	if config.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.MaxConnLifetime
+	}
+
 	// Create connection pool
-	pool, err := pgxpool.New(ctx, dsn)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
 
 	// Test connection
-	if err := pool.Ping(ctx); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	if err := pingWithRetry(ctx, pool, config.ConnectRetries, config.ConnectRetryDelay); err != nil {
+		pool.Close()
+		return nil, err
 	}
 
-	// Initialize sqlc queries
-	queries := db.New(pool)
-
 	return &Database{
 		Pool:    pool,
-		Queries: queries,
+		Queries: db.New(pool),
+		Breaker: NewBreaker(config.BreakerThreshold, config.BreakerCooldown),
 	}, nil
 }
 
+// pingWithRetry pings the pool, retrying up to retries times with exponential
+// backoff so the supervisor can start before Postgres accepts connections.
+func pingWithRetry(ctx context.Context, pool *pgxpool.Pool, retries int, delay time.Duration) error {
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = pool.Ping(ctx); err == nil {
+			return nil
+		}
+		if attempt >= retries {
+			return fmt.Errorf("failed to ping database after %d attempts: %w", attempt+1, err)
+		}
+
+		log.Printf("Database not ready (attempt %d/%d), retrying in %s: %v", attempt+1, retries+1, delay, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to ping database: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, maxConnectRetryDelay)
+	}
+}
+
+// WithTx runs fn inside a transaction, committing if it returns nil and
+// rolling back otherwise.
+func (d *Database) WithTx(ctx context.Context, fn func(q db.Querier) error) error {
+	tx, err := d.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(db.New(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
 func (d *Database) Close() {
 	if d.Pool != nil {
 		d.Pool.Close()
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"arx-supervisor/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RoutingRequestFilter narrows a routing request export. Nil bounds are open.
+type RoutingRequestFilter struct {
+	From  *time.Time
+	To    *time.Time
+	Limit int
+}
+
+// streamRoutingRequests is hand-written rather than generated because sqlc's
+// :many queries buffer the entire result set in memory.
+const streamRoutingRequests = `SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id,
+    distance, load_score, status, response_time_ms, request_data, response_data,
+    metadata, client_info, processing_metrics, created_at
+FROM routing_requests
+WHERE ($1::timestamp IS NULL OR created_at >= $1::timestamp)
+  AND ($2::timestamp IS NULL OR created_at < $2::timestamp)
+ORDER BY created_at DESC
+LIMIT $3`
+
+// StreamRoutingRequests calls fn for each routing request matching the filter
+// as rows arrive from the database. Iteration stops at the first error
+// returned by fn.
+func (d *Database) StreamRoutingRequests(ctx context.Context, filter RoutingRequestFilter, fn func(db.RoutingRequest) error) error {
+	rows, err := d.Pool.Query(ctx, streamRoutingRequests,
+		optionalTimestamp(filter.From), optionalTimestamp(filter.To), filter.Limit)
+	if err != nil {
+		return fmt.Errorf("failed to query routing requests: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i db.RoutingRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestID,
+			&i.CoordinatesX,
+			&i.CoordinatesY,
+			&i.SelectedNodeID,
+			&i.Distance,
+			&i.LoadScore,
+			&i.Status,
+			&i.ResponseTimeMs,
+			&i.RequestData,
+			&i.ResponseData,
+			&i.Metadata,
+			&i.ClientInfo,
+			&i.ProcessingMetrics,
+			&i.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan routing request: %w", err)
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func optionalTimestamp(t *time.Time) pgtype.Timestamp {
+	if t == nil {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// LatencyPercentiles summarises routing response times. The percentiles are
+// nil when no request in the window recorded a response time.
+type LatencyPercentiles struct {
+	Count int64
+	P50   *float64
+	P90   *float64
+	P99   *float64
+}
+
+// responseTimePercentiles is hand-written rather than generated because the
+// percentiles are NULL over an empty window, which sqlc types as float64.
+const responseTimePercentiles = `SELECT COUNT(response_time_ms),
+    percentile_cont(0.5) WITHIN GROUP (ORDER BY response_time_ms),
+    percentile_cont(0.9) WITHIN GROUP (ORDER BY response_time_ms),
+    percentile_cont(0.99) WITHIN GROUP (ORDER BY response_time_ms)
+FROM routing_requests
+WHERE created_at >= $1 AND response_time_ms IS NOT NULL`
+
+// ResponseTimePercentiles computes the p50, p90 and p99 response times of the
+// routing requests created since the given time.
+func (d *Database) ResponseTimePercentiles(ctx context.Context, since time.Time) (LatencyPercentiles, error) {
+	var result LatencyPercentiles
+	var p50, p90, p99 pgtype.Float8
+	err := d.Pool.QueryRow(ctx, responseTimePercentiles, pgtype.Timestamp{Time: since.UTC(), Valid: true}).
+		Scan(&result.Count, &p50, &p90, &p99)
+	if err != nil {
+		return LatencyPercentiles{}, fmt.Errorf("failed to compute response time percentiles: %w", err)
+	}
+
+	result.P50 = optionalFloat(p50)
+	result.P90 = optionalFloat(p90)
+	result.P99 = optionalFloat(p99)
+	return result, nil
+}
+
+func optionalFloat(f pgtype.Float8) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
@@ -0,0 +1,255 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// migrationLockKey is the advisory lock held while migrating so concurrent
+// supervisors starting together don't apply the same migration twice.
+const migrationLockKey = 7428613
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.sql$`)
+
+// Migration is a numbered schema change with goose-style Up and Down sections.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations reads NNNNN_name.sql files from fsys in version order.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(files))
+	seen := make(map[int64]string, len(files))
+	for _, file := range files {
+		match := migrationFilePattern.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: file name must look like 00001_name.sql", file)
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", file, err)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, file, version)
+		}
+		seen[version] = file
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		up, down := splitMigration(string(content))
+		if strings.TrimSpace(up) == "" {
+			return nil, fmt.Errorf("migration %s: missing -- +goose Up section", file)
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: match[2], Up: up, Down: down})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// splitMigration separates the Up and Down sections of a goose migration.
+func splitMigration(content string) (up, down string) {
+	var upSQL, downSQL strings.Builder
+	var section *strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			section = &upSQL
+			continue
+		case "-- +goose Down":
+			section = &downSQL
+			continue
+		case "-- +goose StatementBegin", "-- +goose StatementEnd":
+			continue
+		}
+		if section != nil {
+			section.WriteString(line)
+			section.WriteByte('\n')
+		}
+	}
+	return upSQL.String(), downSQL.String()
+}
+
+// Migrate applies every migration in fsys that is not yet recorded in
+// schema_migrations, each in its own transaction. It is safe to run on every
+// startup.
+func (d *Database) Migrate(ctx context.Context, fsys fs.FS) error {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	return d.withMigrationLock(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if applied[m.Version] {
+				continue
+			}
+			if err := runMigration(ctx, conn, m.Up,
+				"INSERT INTO schema_migrations (version) VALUES ($1)", m.Version); err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			log.Printf("Applied migration %d_%s", m.Version, m.Name)
+		}
+		return nil
+	})
+}
+
+// RollbackMigration runs the Down section of the most recently applied
+// migration.
+func (d *Database) RollbackMigration(ctx context.Context, fsys fs.FS) error {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	return d.withMigrationLock(ctx, func(conn *pgx.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if !applied[m.Version] {
+				continue
+			}
+			if err := runMigration(ctx, conn, m.Down,
+				"DELETE FROM schema_migrations WHERE version = $1", m.Version); err != nil {
+				return fmt.Errorf("rollback of %d_%s failed: %w", m.Version, m.Name, err)
+			}
+			log.Printf("Rolled back migration %d_%s", m.Version, m.Name)
+			return nil
+		}
+
+		log.Println("No migrations to roll back")
+		return nil
+	})
+}
+
+func (d *Database) withMigrationLock(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := d.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	return fn(conn.Conn())
+}
+
+// runMigration executes sql and the bookkeeping statement in one transaction.
+func runMigration(ctx context.Context, conn *pgx.Conn, sql, record string, version int64) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Without arguments pgx uses the simple protocol, which allows several
+	// statements in one call
+	if strings.TrimSpace(sql) != "" {
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, record, version); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// appliedMigrations creates schema_migrations if needed and returns the
+// recorded versions. Databases previously migrated with the goose CLI have
+// their history imported on first run.
+func appliedMigrations(ctx context.Context, conn *pgx.Conn) (map[int64]bool, error) {
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+
+	if !exists {
+		if _, err := conn.Exec(ctx, `CREATE TABLE schema_migrations (
+    version BIGINT PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT NOW()
+)`); err != nil {
+			return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+		if err := importGooseHistory(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := conn.Query(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	versions, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	applied := make(map[int64]bool, len(versions))
+	for _, v := range versions {
+		applied[v] = true
+	}
+	return applied, nil
+}
+
+func importGooseHistory(ctx context.Context, conn *pgx.Conn) error {
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT to_regclass('goose_db_version') IS NOT NULL").Scan(&exists); err != nil {
+		return fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	// goose appends a row per up/down, so the latest row per version wins
+	_, err := conn.Exec(ctx, `INSERT INTO schema_migrations (version)
+SELECT version_id FROM (
+    SELECT DISTINCT ON (version_id) version_id, is_applied
+    FROM goose_db_version
+    WHERE version_id > 0
+    ORDER BY version_id, id DESC
+) latest
+WHERE is_applied`)
+	if err != nil {
+		return fmt.Errorf("failed to import goose history: %w", err)
+	}
+	return nil
+}
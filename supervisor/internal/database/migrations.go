@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"arx-supervisor/internal/config"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -20,6 +21,11 @@ func SetupDatabase(ctx context.Context, cfg config.DatabaseConfig) (*Database, e
 	}
 	defer masterPool.Close()
 
+	retryDelay := time.Duration(cfg.ConnectRetryDelay) * time.Second
+	if err := pingWithRetry(ctx, masterPool, cfg.ConnectRetries, retryDelay); err != nil {
+		return nil, err
+	}
+
 	// Create database if it doesn't exist
 	_, err = masterPool.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s", cfg.DBName))
 	if err != nil {
@@ -35,5 +41,13 @@ func SetupDatabase(ctx context.Context, cfg config.DatabaseConfig) (*Database, e
 		Password: cfg.Password,
 		DBName:   cfg.DBName,
 		SSLMode:  cfg.SSLMode,
+
+		MaxConns:          int32(cfg.MaxConns),
+		MinConns:          int32(cfg.MinConns),
+		MaxConnLifetime:   time.Duration(cfg.MaxConnLifetime) * time.Second,
+		ConnectRetries:    cfg.ConnectRetries,
+		ConnectRetryDelay: retryDelay,
+		BreakerThreshold:  cfg.BreakerThreshold,
+		BreakerCooldown:   time.Duration(cfg.BreakerCooldown) * time.Second,
 	})
 }
@@ -0,0 +1,197 @@
+// Package dbtest provides an in-memory db.Querier so routing, health and the
+// handlers can be tested without Postgres.
+package dbtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"arx-supervisor/internal/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Querier serves nodes and routing requests from memory, filtering them the
+// way the SQL queries do. It embeds db.Querier only to satisfy the interface:
+// a query it does not implement panics, so a test fails loudly when it
+// reaches one it did not expect. Set Err to make every implemented query
+// fail with it.
+type Querier struct {
+	db.Querier
+
+	mu       sync.Mutex
+	nodes    []db.Node
+	requests []db.RoutingRequest
+	calls    map[string]int
+
+	// Err is returned by every implemented query when set
+	Err error
+	// CheckedSince is the cutoff GetHealthyNodes was last called with
+	CheckedSince pgtype.Timestamp
+}
+
+// New returns a Querier holding nodes.
+func New(nodes ...db.Node) *Querier {
+	return &Querier{nodes: nodes, calls: make(map[string]int)}
+}
+
+// Node returns a healthy node at (x, y) with capacity 100, checked now.
+func Node(name string, x, y float64) db.Node {
+	now := pgtype.Timestamp{Time: time.Now().UTC(), Valid: true}
+	return db.Node{
+		ID:                pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		Name:              name,
+		LocationX:         x,
+		LocationY:         y,
+		Endpoint:          "http://" + name + ":8080",
+		Capacity:          pgtype.Int4{Int32: 100, Valid: true},
+		Status:            pgtype.Text{String: "healthy", Valid: true},
+		CpuUsage:          pgtype.Float8{Valid: true},
+		MemoryUsage:       pgtype.Float8{Valid: true},
+		ActiveConnections: pgtype.Int4{Valid: true},
+		LastHealthCheck:   now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Weight:            1,
+		HealthPath:        "/health",
+		HealthProtocol:    "http",
+		Labels:            []byte("{}"),
+	}
+}
+
+// SetNodes replaces the nodes, as if they were updated in the database.
+func (q *Querier) SetNodes(nodes ...db.Node) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.nodes = nodes
+}
+
+// Calls returns how many times the named query was called.
+func (q *Querier) Calls(name string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.calls[name]
+}
+
+// RoutingRequests returns the routing requests created so far, oldest first.
+func (q *Querier) RoutingRequests() []db.RoutingRequest {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]db.RoutingRequest(nil), q.requests...)
+}
+
+func (q *Querier) call(name string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.calls[name]++
+	return q.Err
+}
+
+func (q *Querier) GetAllNodes(ctx context.Context) ([]db.Node, error) {
+	if err := q.call("GetAllNodes"); err != nil {
+		return nil, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var nodes []db.Node
+	for _, node := range q.nodes {
+		if !node.DeletedAt.Valid {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+func (q *Querier) GetHealthyNodes(ctx context.Context, checkedSince pgtype.Timestamp) ([]db.Node, error) {
+	if err := q.call("GetHealthyNodes"); err != nil {
+		return nil, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.CheckedSince = checkedSince
+
+	var nodes []db.Node
+	for _, node := range q.nodes {
+		if node.Status.String != "healthy" {
+			continue
+		}
+		// NULL compares as unknown in SQL, so an unchecked node is left out
+		// whenever a cutoff is given
+		if checkedSince.Valid && (!node.LastHealthCheck.Valid || !node.LastHealthCheck.Time.After(checkedSince.Time)) {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func (q *Querier) GetNodeByID(ctx context.Context, id pgtype.UUID) (db.Node, error) {
+	if err := q.call("GetNodeByID"); err != nil {
+		return db.Node{}, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, node := range q.nodes {
+		if node.ID == id && !node.DeletedAt.Valid {
+			return node, nil
+		}
+	}
+	return db.Node{}, pgx.ErrNoRows
+}
+
+// GetRoutingConfig reports that no weights were saved, so services keep the
+// configuration they were created with.
+func (q *Querier) GetRoutingConfig(ctx context.Context) (db.RoutingConfig, error) {
+	if err := q.call("GetRoutingConfig"); err != nil {
+		return db.RoutingConfig{}, err
+	}
+	return db.RoutingConfig{}, pgx.ErrNoRows
+}
+
+func (q *Querier) CreateRoutingRequest(ctx context.Context, arg db.CreateRoutingRequestParams) (db.RoutingRequest, error) {
+	if err := q.call("CreateRoutingRequest"); err != nil {
+		return db.RoutingRequest{}, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	request := db.RoutingRequest{
+		ID:                pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		RequestID:         arg.RequestID,
+		CoordinatesX:      arg.CoordinatesX,
+		CoordinatesY:      arg.CoordinatesY,
+		SelectedNodeID:    arg.SelectedNodeID,
+		Distance:          arg.Distance,
+		LoadScore:         arg.LoadScore,
+		Status:            arg.Status,
+		ResponseTimeMs:    arg.ResponseTimeMs,
+		RequestData:       arg.RequestData,
+		ResponseData:      arg.ResponseData,
+		Metadata:          arg.Metadata,
+		ClientInfo:        arg.ClientInfo,
+		ProcessingMetrics: arg.ProcessingMetrics,
+		FallbackNodeIds:   arg.FallbackNodeIds,
+		CreatedAt:         pgtype.Timestamp{Time: time.Now().UTC(), Valid: true},
+	}
+	q.requests = append(q.requests, request)
+	return request, nil
+}
+
+func (q *Querier) GetRoutingRequestByRequestID(ctx context.Context, requestID string) (db.RoutingRequest, error) {
+	if err := q.call("GetRoutingRequestByRequestID"); err != nil {
+		return db.RoutingRequest{}, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := len(q.requests) - 1; i >= 0; i-- {
+		if q.requests[i].RequestID == requestID {
+			return q.requests[i], nil
+		}
+	}
+	return db.RoutingRequest{}, pgx.ErrNoRows
+}
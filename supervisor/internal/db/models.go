@@ -8,6 +8,23 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Cluster struct {
+	ID          pgtype.UUID      `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+}
+
+type IdempotencyKey struct {
+	Key          string           `json:"key"`
+	RequestHash  string           `json:"request_hash"`
+	StatusCode   int32            `json:"status_code"`
+	ResponseBody []byte           `json:"response_body"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	ExpiresAt    pgtype.Timestamp `json:"expires_at"`
+}
+
 type Node struct {
 	ID                pgtype.UUID      `json:"id"`
 	Name              string           `json:"name"`
@@ -22,6 +39,45 @@ type Node struct {
 	LastHealthCheck   pgtype.Timestamp `json:"last_health_check"`
 	CreatedAt         pgtype.Timestamp `json:"created_at"`
 	UpdatedAt         pgtype.Timestamp `json:"updated_at"`
+	DrainingSince     pgtype.Timestamp `json:"draining_since"`
+	LastHeartbeat     pgtype.Timestamp `json:"last_heartbeat"`
+	DeletedAt         pgtype.Timestamp `json:"deleted_at"`
+	Weight            int32            `json:"weight"`
+	Zone              string           `json:"zone"`
+	HealthPath        string           `json:"health_path"`
+	HealthProtocol    string           `json:"health_protocol"`
+	Maintenance       bool             `json:"maintenance"`
+	Labels            []byte           `json:"labels"`
+	ClusterID         pgtype.UUID      `json:"cluster_id"`
+}
+
+type NodeEvent struct {
+	ID        pgtype.UUID      `json:"id"`
+	NodeID    pgtype.UUID      `json:"node_id"`
+	EventType string           `json:"event_type"`
+	Actor     string           `json:"actor"`
+	Before    []byte           `json:"before"`
+	After     []byte           `json:"after"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type RegistrationToken struct {
+	ID        pgtype.UUID      `json:"id"`
+	TokenHash string           `json:"token_hash"`
+	CreatedBy string           `json:"created_by"`
+	ExpiresAt pgtype.Timestamp `json:"expires_at"`
+	UsedAt    pgtype.Timestamp `json:"used_at"`
+	NodeID    pgtype.UUID      `json:"node_id"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type RoutingConfig struct {
+	ID             int32            `json:"id"`
+	KNearest       int32            `json:"k_nearest"`
+	MaxDistance    float64          `json:"max_distance"`
+	LoadWeight     float64          `json:"load_weight"`
+	DistanceWeight float64          `json:"distance_weight"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
 }
 
 type RoutingRequest struct {
@@ -40,6 +96,10 @@ type RoutingRequest struct {
 	ClientInfo        []byte           `json:"client_info"`
 	ProcessingMetrics []byte           `json:"processing_metrics"`
 	CreatedAt         pgtype.Timestamp `json:"created_at"`
+	FailedNodeID      pgtype.UUID      `json:"failed_node_id"`
+	FailureReason     pgtype.Text      `json:"failure_reason"`
+	FailureReportedAt pgtype.Timestamp `json:"failure_reported_at"`
+	FallbackNodeIds   []pgtype.UUID    `json:"fallback_node_ids"`
 }
 
 type SystemMetric struct {
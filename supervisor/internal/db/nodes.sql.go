@@ -11,19 +11,78 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countHealthyNodes = `-- name: CountHealthyNodes :one
+SELECT COUNT(*) FROM nodes WHERE status = 'healthy'
+`
+
+func (q *Queries) CountHealthyNodes(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countHealthyNodes)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countListNodes = `-- name: CountListNodes :one
+SELECT COUNT(*) FROM nodes
+WHERE ($1::boolean OR deleted_at IS NULL)
+  AND ($2::varchar IS NULL OR status = $2)
+  AND ($3::varchar IS NULL OR zone = $3)
+  AND ($4::jsonb IS NULL OR labels @> $4)
+  AND ($5::uuid IS NULL OR cluster_id = $5)
+`
+
+type CountListNodesParams struct {
+	IncludeDeleted bool        `json:"include_deleted"`
+	Status         pgtype.Text `json:"status"`
+	Zone           pgtype.Text `json:"zone"`
+	Labels         []byte      `json:"labels"`
+	ClusterID      pgtype.UUID `json:"cluster_id"`
+}
+
+func (q *Queries) CountListNodes(ctx context.Context, arg CountListNodesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countListNodes,
+		arg.IncludeDeleted,
+		arg.Status,
+		arg.Zone,
+		arg.Labels,
+		arg.ClusterID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countNodes = `-- name: CountNodes :one
+SELECT COUNT(*) FROM nodes WHERE deleted_at IS NULL
+`
+
+func (q *Queries) CountNodes(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countNodes)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createNode = `-- name: CreateNode :one
-INSERT INTO nodes (name, location_x, location_y, endpoint, capacity, status)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at
+INSERT INTO nodes (name, location_x, location_y, endpoint, capacity, status, weight, zone,
+    health_path, health_protocol, labels, cluster_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
 `
 
 type CreateNodeParams struct {
-	Name      string      `json:"name"`
-	LocationX float64     `json:"location_x"`
-	LocationY float64     `json:"location_y"`
-	Endpoint  string      `json:"endpoint"`
-	Capacity  pgtype.Int4 `json:"capacity"`
-	Status    pgtype.Text `json:"status"`
+	Name           string      `json:"name"`
+	LocationX      float64     `json:"location_x"`
+	LocationY      float64     `json:"location_y"`
+	Endpoint       string      `json:"endpoint"`
+	Capacity       pgtype.Int4 `json:"capacity"`
+	Status         pgtype.Text `json:"status"`
+	Weight         int32       `json:"weight"`
+	Zone           string      `json:"zone"`
+	HealthPath     string      `json:"health_path"`
+	HealthProtocol string      `json:"health_protocol"`
+	Labels         []byte      `json:"labels"`
+	ClusterID      pgtype.UUID `json:"cluster_id"`
 }
 
 func (q *Queries) CreateNode(ctx context.Context, arg CreateNodeParams) (Node, error) {
@@ -34,6 +93,12 @@ func (q *Queries) CreateNode(ctx context.Context, arg CreateNodeParams) (Node, e
 		arg.Endpoint,
 		arg.Capacity,
 		arg.Status,
+		arg.Weight,
+		arg.Zone,
+		arg.HealthPath,
+		arg.HealthProtocol,
+		arg.Labels,
+		arg.ClusterID,
 	)
 	var i Node
 	err := row.Scan(
@@ -50,21 +115,254 @@ func (q *Queries) CreateNode(ctx context.Context, arg CreateNodeParams) (Node, e
 		&i.LastHealthCheck,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
 	)
 	return i, err
 }
 
-const deleteNode = `-- name: DeleteNode :exec
+const createNodeIfAbsent = `-- name: CreateNodeIfAbsent :one
+INSERT INTO nodes (name, location_x, location_y, endpoint, capacity, status, weight, zone,
+    health_path, health_protocol, labels, cluster_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (endpoint) WHERE deleted_at IS NULL DO NOTHING
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
+`
+
+type CreateNodeIfAbsentParams struct {
+	Name           string      `json:"name"`
+	LocationX      float64     `json:"location_x"`
+	LocationY      float64     `json:"location_y"`
+	Endpoint       string      `json:"endpoint"`
+	Capacity       pgtype.Int4 `json:"capacity"`
+	Status         pgtype.Text `json:"status"`
+	Weight         int32       `json:"weight"`
+	Zone           string      `json:"zone"`
+	HealthPath     string      `json:"health_path"`
+	HealthProtocol string      `json:"health_protocol"`
+	Labels         []byte      `json:"labels"`
+	ClusterID      pgtype.UUID `json:"cluster_id"`
+}
+
+func (q *Queries) CreateNodeIfAbsent(ctx context.Context, arg CreateNodeIfAbsentParams) (Node, error) {
+	row := q.db.QueryRow(ctx, createNodeIfAbsent,
+		arg.Name,
+		arg.LocationX,
+		arg.LocationY,
+		arg.Endpoint,
+		arg.Capacity,
+		arg.Status,
+		arg.Weight,
+		arg.Zone,
+		arg.HealthPath,
+		arg.HealthProtocol,
+		arg.Labels,
+		arg.ClusterID,
+	)
+	var i Node
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.LocationX,
+		&i.LocationY,
+		&i.Endpoint,
+		&i.Capacity,
+		&i.Status,
+		&i.CpuUsage,
+		&i.MemoryUsage,
+		&i.ActiveConnections,
+		&i.LastHealthCheck,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
+	)
+	return i, err
+}
+
+const deactivateUnhealthyNode = `-- name: DeactivateUnhealthyNode :one
+UPDATE nodes
+SET status = 'inactive', updated_at = NOW()
+WHERE id = $1 AND status = 'unhealthy' AND deleted_at IS NULL
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
+`
+
+func (q *Queries) DeactivateUnhealthyNode(ctx context.Context, id pgtype.UUID) (Node, error) {
+	row := q.db.QueryRow(ctx, deactivateUnhealthyNode, id)
+	var i Node
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.LocationX,
+		&i.LocationY,
+		&i.Endpoint,
+		&i.Capacity,
+		&i.Status,
+		&i.CpuUsage,
+		&i.MemoryUsage,
+		&i.ActiveConnections,
+		&i.LastHealthCheck,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
+	)
+	return i, err
+}
+
+const deleteDrainedNodes = `-- name: DeleteDrainedNodes :many
+UPDATE nodes
+SET status = 'deleted', deleted_at = NOW(), draining_since = NULL, updated_at = NOW()
+WHERE status = 'draining' AND draining_since <= $1 AND deleted_at IS NULL
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
+`
+
+func (q *Queries) DeleteDrainedNodes(ctx context.Context, drainingSince pgtype.Timestamp) ([]Node, error) {
+	rows, err := q.db.Query(ctx, deleteDrainedNodes, drainingSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Node
+	for rows.Next() {
+		var i Node
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.LocationX,
+			&i.LocationY,
+			&i.Endpoint,
+			&i.Capacity,
+			&i.Status,
+			&i.CpuUsage,
+			&i.MemoryUsage,
+			&i.ActiveConnections,
+			&i.LastHealthCheck,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DrainingSince,
+			&i.LastHeartbeat,
+			&i.DeletedAt,
+			&i.Weight,
+			&i.Zone,
+			&i.HealthPath,
+			&i.HealthProtocol,
+			&i.Maintenance,
+			&i.Labels,
+			&i.ClusterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteNode = `-- name: DeleteNode :one
 DELETE FROM nodes WHERE id = $1
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
 `
 
-func (q *Queries) DeleteNode(ctx context.Context, id pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteNode, id)
-	return err
+func (q *Queries) DeleteNode(ctx context.Context, id pgtype.UUID) (Node, error) {
+	row := q.db.QueryRow(ctx, deleteNode, id)
+	var i Node
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.LocationX,
+		&i.LocationY,
+		&i.Endpoint,
+		&i.Capacity,
+		&i.Status,
+		&i.CpuUsage,
+		&i.MemoryUsage,
+		&i.ActiveConnections,
+		&i.LastHealthCheck,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
+	)
+	return i, err
+}
+
+const drainNode = `-- name: DrainNode :one
+UPDATE nodes
+SET status = 'draining',
+    draining_since = CASE WHEN status = 'draining' THEN draining_since ELSE NOW() END,
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
+`
+
+func (q *Queries) DrainNode(ctx context.Context, id pgtype.UUID) (Node, error) {
+	row := q.db.QueryRow(ctx, drainNode, id)
+	var i Node
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.LocationX,
+		&i.LocationY,
+		&i.Endpoint,
+		&i.Capacity,
+		&i.Status,
+		&i.CpuUsage,
+		&i.MemoryUsage,
+		&i.ActiveConnections,
+		&i.LastHealthCheck,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
+	)
+	return i, err
 }
 
 const getAllNodes = `-- name: GetAllNodes :many
-SELECT id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at FROM nodes ORDER BY created_at DESC
+SELECT id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id FROM nodes WHERE deleted_at IS NULL ORDER BY created_at DESC
 `
 
 func (q *Queries) GetAllNodes(ctx context.Context) ([]Node, error) {
@@ -90,6 +388,16 @@ func (q *Queries) GetAllNodes(ctx context.Context) ([]Node, error) {
 			&i.LastHealthCheck,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DrainingSince,
+			&i.LastHeartbeat,
+			&i.DeletedAt,
+			&i.Weight,
+			&i.Zone,
+			&i.HealthPath,
+			&i.HealthProtocol,
+			&i.Maintenance,
+			&i.Labels,
+			&i.ClusterID,
 		); err != nil {
 			return nil, err
 		}
@@ -101,12 +409,52 @@ func (q *Queries) GetAllNodes(ctx context.Context) ([]Node, error) {
 	return items, nil
 }
 
+const getClusterStats = `-- name: GetClusterStats :one
+SELECT COUNT(*) AS total_nodes,
+    COUNT(*) FILTER (WHERE status = 'healthy') AS healthy_nodes,
+    COUNT(*) FILTER (WHERE status = 'degraded') AS degraded_nodes,
+    COUNT(*) FILTER (WHERE status = 'unhealthy') AS unhealthy_nodes,
+    COALESCE(AVG(cpu_usage) FILTER (WHERE status = 'healthy'), 0)::float8 AS avg_cpu_usage,
+    COALESCE(AVG(memory_usage) FILTER (WHERE status = 'healthy'), 0)::float8 AS avg_memory_usage,
+    COALESCE(SUM(active_connections), 0)::bigint AS active_connections
+FROM nodes
+WHERE deleted_at IS NULL
+`
+
+type GetClusterStatsRow struct {
+	TotalNodes        int64   `json:"total_nodes"`
+	HealthyNodes      int64   `json:"healthy_nodes"`
+	DegradedNodes     int64   `json:"degraded_nodes"`
+	UnhealthyNodes    int64   `json:"unhealthy_nodes"`
+	AvgCpuUsage       float64 `json:"avg_cpu_usage"`
+	AvgMemoryUsage    float64 `json:"avg_memory_usage"`
+	ActiveConnections int64   `json:"active_connections"`
+}
+
+func (q *Queries) GetClusterStats(ctx context.Context) (GetClusterStatsRow, error) {
+	row := q.db.QueryRow(ctx, getClusterStats)
+	var i GetClusterStatsRow
+	err := row.Scan(
+		&i.TotalNodes,
+		&i.HealthyNodes,
+		&i.DegradedNodes,
+		&i.UnhealthyNodes,
+		&i.AvgCpuUsage,
+		&i.AvgMemoryUsage,
+		&i.ActiveConnections,
+	)
+	return i, err
+}
+
 const getHealthyNodes = `-- name: GetHealthyNodes :many
-SELECT id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at FROM nodes WHERE status = 'healthy' ORDER BY created_at DESC
+SELECT id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id FROM nodes
+WHERE status = 'healthy'
+  AND ($1::timestamp IS NULL OR last_health_check > $1)
+ORDER BY created_at DESC
 `
 
-func (q *Queries) GetHealthyNodes(ctx context.Context) ([]Node, error) {
-	rows, err := q.db.Query(ctx, getHealthyNodes)
+func (q *Queries) GetHealthyNodes(ctx context.Context, checkedSince pgtype.Timestamp) ([]Node, error) {
+	rows, err := q.db.Query(ctx, getHealthyNodes, checkedSince)
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +476,16 @@ func (q *Queries) GetHealthyNodes(ctx context.Context) ([]Node, error) {
 			&i.LastHealthCheck,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DrainingSince,
+			&i.LastHeartbeat,
+			&i.DeletedAt,
+			&i.Weight,
+			&i.Zone,
+			&i.HealthPath,
+			&i.HealthProtocol,
+			&i.Maintenance,
+			&i.Labels,
+			&i.ClusterID,
 		); err != nil {
 			return nil, err
 		}
@@ -140,7 +498,7 @@ func (q *Queries) GetHealthyNodes(ctx context.Context) ([]Node, error) {
 }
 
 const getNodeByID = `-- name: GetNodeByID :one
-SELECT id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at FROM nodes WHERE id = $1
+SELECT id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id FROM nodes WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetNodeByID(ctx context.Context, id pgtype.UUID) (Node, error) {
@@ -160,6 +518,262 @@ func (q *Queries) GetNodeByID(ctx context.Context, id pgtype.UUID) (Node, error)
 		&i.LastHealthCheck,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
+	)
+	return i, err
+}
+
+const listNodes = `-- name: ListNodes :many
+SELECT id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id FROM nodes
+WHERE ($1::boolean OR deleted_at IS NULL)
+  AND ($2::varchar IS NULL OR status = $2)
+  AND ($3::varchar IS NULL OR zone = $3)
+  AND ($4::jsonb IS NULL OR labels @> $4)
+  AND ($5::uuid IS NULL OR cluster_id = $5)
+ORDER BY created_at DESC, id
+LIMIT $6 OFFSET $7
+`
+
+type ListNodesParams struct {
+	IncludeDeleted bool        `json:"include_deleted"`
+	Status         pgtype.Text `json:"status"`
+	Zone           pgtype.Text `json:"zone"`
+	Labels         []byte      `json:"labels"`
+	ClusterID      pgtype.UUID `json:"cluster_id"`
+	PageLimit      int32       `json:"page_limit"`
+	PageOffset     int32       `json:"page_offset"`
+}
+
+func (q *Queries) ListNodes(ctx context.Context, arg ListNodesParams) ([]Node, error) {
+	rows, err := q.db.Query(ctx, listNodes,
+		arg.IncludeDeleted,
+		arg.Status,
+		arg.Zone,
+		arg.Labels,
+		arg.ClusterID,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Node
+	for rows.Next() {
+		var i Node
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.LocationX,
+			&i.LocationY,
+			&i.Endpoint,
+			&i.Capacity,
+			&i.Status,
+			&i.CpuUsage,
+			&i.MemoryUsage,
+			&i.ActiveConnections,
+			&i.LastHealthCheck,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DrainingSince,
+			&i.LastHeartbeat,
+			&i.DeletedAt,
+			&i.Weight,
+			&i.Zone,
+			&i.HealthPath,
+			&i.HealthProtocol,
+			&i.Maintenance,
+			&i.Labels,
+			&i.ClusterID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordNodeHeartbeat = `-- name: RecordNodeHeartbeat :one
+UPDATE nodes
+SET status = CASE WHEN status = 'draining' THEN status ELSE 'healthy' END,
+    cpu_usage = $2, memory_usage = $3, active_connections = $4,
+    last_health_check = $5, last_heartbeat = $5, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL AND status <> 'inactive' AND NOT maintenance
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
+`
+
+type RecordNodeHeartbeatParams struct {
+	ID                pgtype.UUID      `json:"id"`
+	CpuUsage          pgtype.Float8    `json:"cpu_usage"`
+	MemoryUsage       pgtype.Float8    `json:"memory_usage"`
+	ActiveConnections pgtype.Int4      `json:"active_connections"`
+	LastHealthCheck   pgtype.Timestamp `json:"last_health_check"`
+}
+
+func (q *Queries) RecordNodeHeartbeat(ctx context.Context, arg RecordNodeHeartbeatParams) (Node, error) {
+	row := q.db.QueryRow(ctx, recordNodeHeartbeat,
+		arg.ID,
+		arg.CpuUsage,
+		arg.MemoryUsage,
+		arg.ActiveConnections,
+		arg.LastHealthCheck,
+	)
+	var i Node
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.LocationX,
+		&i.LocationY,
+		&i.Endpoint,
+		&i.Capacity,
+		&i.Status,
+		&i.CpuUsage,
+		&i.MemoryUsage,
+		&i.ActiveConnections,
+		&i.LastHealthCheck,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
+	)
+	return i, err
+}
+
+const setNodeMaintenance = `-- name: SetNodeMaintenance :one
+UPDATE nodes
+SET maintenance = COALESCE($1::boolean, NOT maintenance), updated_at = NOW()
+WHERE id = $2 AND deleted_at IS NULL
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
+`
+
+type SetNodeMaintenanceParams struct {
+	Maintenance pgtype.Bool `json:"maintenance"`
+	ID          pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) SetNodeMaintenance(ctx context.Context, arg SetNodeMaintenanceParams) (Node, error) {
+	row := q.db.QueryRow(ctx, setNodeMaintenance, arg.Maintenance, arg.ID)
+	var i Node
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.LocationX,
+		&i.LocationY,
+		&i.Endpoint,
+		&i.Capacity,
+		&i.Status,
+		&i.CpuUsage,
+		&i.MemoryUsage,
+		&i.ActiveConnections,
+		&i.LastHealthCheck,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
+	)
+	return i, err
+}
+
+const softDeleteNode = `-- name: SoftDeleteNode :one
+UPDATE nodes
+SET status = 'deleted', deleted_at = NOW(), draining_since = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
+`
+
+func (q *Queries) SoftDeleteNode(ctx context.Context, id pgtype.UUID) (Node, error) {
+	row := q.db.QueryRow(ctx, softDeleteNode, id)
+	var i Node
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.LocationX,
+		&i.LocationY,
+		&i.Endpoint,
+		&i.Capacity,
+		&i.Status,
+		&i.CpuUsage,
+		&i.MemoryUsage,
+		&i.ActiveConnections,
+		&i.LastHealthCheck,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
+	)
+	return i, err
+}
+
+const softDeleteUnhealthyNode = `-- name: SoftDeleteUnhealthyNode :one
+UPDATE nodes
+SET status = 'deleted', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'unhealthy' AND deleted_at IS NULL
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
+`
+
+func (q *Queries) SoftDeleteUnhealthyNode(ctx context.Context, id pgtype.UUID) (Node, error) {
+	row := q.db.QueryRow(ctx, softDeleteUnhealthyNode, id)
+	var i Node
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.LocationX,
+		&i.LocationY,
+		&i.Endpoint,
+		&i.Capacity,
+		&i.Status,
+		&i.CpuUsage,
+		&i.MemoryUsage,
+		&i.ActiveConnections,
+		&i.LastHealthCheck,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
 	)
 	return i, err
 }
@@ -168,9 +782,11 @@ const updateNode = `-- name: UpdateNode :one
 UPDATE nodes 
 SET name = $2, location_x = $3, location_y = $4, endpoint = $5, capacity = $6, status = $7,
     cpu_usage = $8, memory_usage = $9, active_connections = $10,
-    last_health_check = $11, updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at
+    last_health_check = $11, weight = $12, zone = $13,
+    health_path = $14, health_protocol = $15, labels = $16, cluster_id = $17, updated_at = NOW(),
+    draining_since = CASE WHEN $7 = 'draining' THEN COALESCE(draining_since, NOW()) END
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
 `
 
 type UpdateNodeParams struct {
@@ -185,6 +801,12 @@ type UpdateNodeParams struct {
 	MemoryUsage       pgtype.Float8    `json:"memory_usage"`
 	ActiveConnections pgtype.Int4      `json:"active_connections"`
 	LastHealthCheck   pgtype.Timestamp `json:"last_health_check"`
+	Weight            int32            `json:"weight"`
+	Zone              string           `json:"zone"`
+	HealthPath        string           `json:"health_path"`
+	HealthProtocol    string           `json:"health_protocol"`
+	Labels            []byte           `json:"labels"`
+	ClusterID         pgtype.UUID      `json:"cluster_id"`
 }
 
 func (q *Queries) UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, error) {
@@ -200,6 +822,12 @@ func (q *Queries) UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, e
 		arg.MemoryUsage,
 		arg.ActiveConnections,
 		arg.LastHealthCheck,
+		arg.Weight,
+		arg.Zone,
+		arg.HealthPath,
+		arg.HealthProtocol,
+		arg.Labels,
+		arg.ClusterID,
 	)
 	var i Node
 	err := row.Scan(
@@ -216,35 +844,47 @@ func (q *Queries) UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, e
 		&i.LastHealthCheck,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
 	)
 	return i, err
 }
 
 const updateNodeHealth = `-- name: UpdateNodeHealth :one
 UPDATE nodes 
-SET status = $2, cpu_usage = $3, memory_usage = $4, active_connections = $5,
-    last_health_check = $6, updated_at = NOW()
-WHERE id = $1
-RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at
+SET status = CASE WHEN status = 'draining' THEN status ELSE $1::varchar END,
+    cpu_usage = $2, memory_usage = $3,
+    active_connections = $4,
+    last_health_check = $5, updated_at = NOW()
+WHERE id = $6 AND deleted_at IS NULL
+RETURNING id, name, location_x, location_y, endpoint, capacity, status, cpu_usage, memory_usage, active_connections, last_health_check, created_at, updated_at, draining_since, last_heartbeat, deleted_at, weight, zone, health_path, health_protocol, maintenance, labels, cluster_id
 `
 
 type UpdateNodeHealthParams struct {
-	ID                pgtype.UUID      `json:"id"`
-	Status            pgtype.Text      `json:"status"`
+	Status            string           `json:"status"`
 	CpuUsage          pgtype.Float8    `json:"cpu_usage"`
 	MemoryUsage       pgtype.Float8    `json:"memory_usage"`
 	ActiveConnections pgtype.Int4      `json:"active_connections"`
 	LastHealthCheck   pgtype.Timestamp `json:"last_health_check"`
+	ID                pgtype.UUID      `json:"id"`
 }
 
 func (q *Queries) UpdateNodeHealth(ctx context.Context, arg UpdateNodeHealthParams) (Node, error) {
 	row := q.db.QueryRow(ctx, updateNodeHealth,
-		arg.ID,
 		arg.Status,
 		arg.CpuUsage,
 		arg.MemoryUsage,
 		arg.ActiveConnections,
 		arg.LastHealthCheck,
+		arg.ID,
 	)
 	var i Node
 	err := row.Scan(
@@ -261,6 +901,16 @@ func (q *Queries) UpdateNodeHealth(ctx context.Context, arg UpdateNodeHealthPara
 		&i.LastHealthCheck,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DrainingSince,
+		&i.LastHeartbeat,
+		&i.DeletedAt,
+		&i.Weight,
+		&i.Zone,
+		&i.HealthPath,
+		&i.HealthProtocol,
+		&i.Maintenance,
+		&i.Labels,
+		&i.ClusterID,
 	)
 	return i, err
 }
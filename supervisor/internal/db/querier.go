@@ -11,22 +11,64 @@ import (
 )
 
 type Querier interface {
+	CountClusterNodes(ctx context.Context) ([]CountClusterNodesRow, error)
+	CountHealthyNodes(ctx context.Context) (int64, error)
+	CountListNodes(ctx context.Context, arg CountListNodesParams) (int64, error)
+	CountNodeEvents(ctx context.Context, nodeID pgtype.UUID) (int64, error)
+	CountNodes(ctx context.Context) (int64, error)
+	CountNodesInCluster(ctx context.Context, clusterID pgtype.UUID) (int64, error)
+	CountRoutingRequestsByNode(ctx context.Context, selectedNodeID pgtype.UUID) (int64, error)
+	CreateCluster(ctx context.Context, arg CreateClusterParams) (Cluster, error)
 	CreateNode(ctx context.Context, arg CreateNodeParams) (Node, error)
+	CreateNodeEvent(ctx context.Context, arg CreateNodeEventParams) (NodeEvent, error)
+	CreateNodeIfAbsent(ctx context.Context, arg CreateNodeIfAbsentParams) (Node, error)
+	CreateRegistrationToken(ctx context.Context, arg CreateRegistrationTokenParams) (RegistrationToken, error)
 	CreateRoutingRequest(ctx context.Context, arg CreateRoutingRequestParams) (RoutingRequest, error)
 	CreateSystemMetric(ctx context.Context, arg CreateSystemMetricParams) (SystemMetric, error)
-	DeleteNode(ctx context.Context, id pgtype.UUID) error
+	DeactivateUnhealthyNode(ctx context.Context, id pgtype.UUID) (Node, error)
+	DeleteCluster(ctx context.Context, id pgtype.UUID) (int64, error)
+	DeleteDrainedNodes(ctx context.Context, drainingSince pgtype.Timestamp) ([]Node, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+	DeleteNode(ctx context.Context, id pgtype.UUID) (Node, error)
+	DeleteRoutingRequestsBefore(ctx context.Context, arg DeleteRoutingRequestsBeforeParams) (int64, error)
+	DeleteRoutingRequestsByNode(ctx context.Context, selectedNodeID pgtype.UUID) (int64, error)
+	DeleteSystemMetricsBefore(ctx context.Context, arg DeleteSystemMetricsBeforeParams) (int64, error)
+	DeleteSystemMetricsByNode(ctx context.Context, nodeID pgtype.UUID) (int64, error)
+	DrainNode(ctx context.Context, id pgtype.UUID) (Node, error)
 	GetAllNodes(ctx context.Context) ([]Node, error)
-	GetHealthyNodes(ctx context.Context) ([]Node, error)
+	GetClusterByID(ctx context.Context, id pgtype.UUID) (Cluster, error)
+	GetClusterStats(ctx context.Context) (GetClusterStatsRow, error)
+	GetHealthyNodes(ctx context.Context, checkedSince pgtype.Timestamp) ([]Node, error)
+	GetIdempotencyKey(ctx context.Context, key string) (IdempotencyKey, error)
+	GetLatestSystemMetrics(ctx context.Context) ([]SystemMetric, error)
 	GetNodeByID(ctx context.Context, id pgtype.UUID) (Node, error)
+	GetNodeMetricsSince(ctx context.Context, arg GetNodeMetricsSinceParams) ([]SystemMetric, error)
+	GetNodeRegistrationToken(ctx context.Context, arg GetNodeRegistrationTokenParams) (RegistrationToken, error)
 	GetRecentRoutingRequests(ctx context.Context, limit int32) ([]RoutingRequest, error)
 	GetRecentSystemMetrics(ctx context.Context, limit int32) ([]SystemMetric, error)
+	GetRegistrationToken(ctx context.Context, tokenHash string) (RegistrationToken, error)
+	GetRoutingConfig(ctx context.Context) (RoutingConfig, error)
 	GetRoutingRequestByID(ctx context.Context, id pgtype.UUID) (RoutingRequest, error)
+	GetRoutingRequestByRequestID(ctx context.Context, requestID string) (RoutingRequest, error)
 	GetRoutingRequestsByNode(ctx context.Context, arg GetRoutingRequestsByNodeParams) ([]RoutingRequest, error)
 	GetRoutingRequestsByStatus(ctx context.Context, arg GetRoutingRequestsByStatusParams) ([]RoutingRequest, error)
+	GetRoutingRequestsSince(ctx context.Context, arg GetRoutingRequestsSinceParams) ([]RoutingRequest, error)
+	ListClusters(ctx context.Context) ([]Cluster, error)
+	ListNodeEvents(ctx context.Context, arg ListNodeEventsParams) ([]NodeEvent, error)
+	ListNodes(ctx context.Context, arg ListNodesParams) ([]Node, error)
+	RecordNodeHeartbeat(ctx context.Context, arg RecordNodeHeartbeatParams) (Node, error)
+	ReportRoutingFailure(ctx context.Context, arg ReportRoutingFailureParams) (RoutingRequest, error)
+	SaveIdempotencyKey(ctx context.Context, arg SaveIdempotencyKeyParams) (int64, error)
 	SearchRoutingRequests(ctx context.Context, arg SearchRoutingRequestsParams) ([]RoutingRequest, error)
+	SetNodeMaintenance(ctx context.Context, arg SetNodeMaintenanceParams) (Node, error)
+	SoftDeleteNode(ctx context.Context, id pgtype.UUID) (Node, error)
+	SoftDeleteUnhealthyNode(ctx context.Context, id pgtype.UUID) (Node, error)
+	UpdateCluster(ctx context.Context, arg UpdateClusterParams) (Cluster, error)
 	UpdateNode(ctx context.Context, arg UpdateNodeParams) (Node, error)
 	UpdateNodeHealth(ctx context.Context, arg UpdateNodeHealthParams) (Node, error)
 	UpdateRoutingResponse(ctx context.Context, arg UpdateRoutingResponseParams) (RoutingRequest, error)
+	UpsertRoutingConfig(ctx context.Context, arg UpsertRoutingConfigParams) (RoutingConfig, error)
+	UseRegistrationToken(ctx context.Context, arg UseRegistrationTokenParams) (RegistrationToken, error)
 }
 
 var _ Querier = (*Queries)(nil)
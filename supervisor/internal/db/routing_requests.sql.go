@@ -11,26 +11,42 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countRoutingRequestsByNode = `-- name: CountRoutingRequestsByNode :one
+SELECT COUNT(*) FROM routing_requests WHERE selected_node_id = $1
+`
+
+func (q *Queries) CountRoutingRequestsByNode(ctx context.Context, selectedNodeID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countRoutingRequestsByNode, selectedNodeID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createRoutingRequest = `-- name: CreateRoutingRequest :one
 INSERT INTO routing_requests (
     request_id, coordinates_x, coordinates_y, selected_node_id, 
-    distance, load_score, status, request_data, metadata, client_info
+    distance, load_score, status, request_data, metadata, client_info,
+    processing_metrics, response_time_ms, response_data, fallback_node_ids
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-RETURNING id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at, failed_node_id, failure_reason, failure_reported_at, fallback_node_ids
 `
 
 type CreateRoutingRequestParams struct {
-	RequestID      string        `json:"request_id"`
-	CoordinatesX   float64       `json:"coordinates_x"`
-	CoordinatesY   float64       `json:"coordinates_y"`
-	SelectedNodeID pgtype.UUID   `json:"selected_node_id"`
-	Distance       pgtype.Float8 `json:"distance"`
-	LoadScore      pgtype.Float8 `json:"load_score"`
-	Status         pgtype.Text   `json:"status"`
-	RequestData    []byte        `json:"request_data"`
-	Metadata       []byte        `json:"metadata"`
-	ClientInfo     []byte        `json:"client_info"`
+	RequestID         string        `json:"request_id"`
+	CoordinatesX      float64       `json:"coordinates_x"`
+	CoordinatesY      float64       `json:"coordinates_y"`
+	SelectedNodeID    pgtype.UUID   `json:"selected_node_id"`
+	Distance          pgtype.Float8 `json:"distance"`
+	LoadScore         pgtype.Float8 `json:"load_score"`
+	Status            pgtype.Text   `json:"status"`
+	RequestData       []byte        `json:"request_data"`
+	Metadata          []byte        `json:"metadata"`
+	ClientInfo        []byte        `json:"client_info"`
+	ProcessingMetrics []byte        `json:"processing_metrics"`
+	ResponseTimeMs    pgtype.Int4   `json:"response_time_ms"`
+	ResponseData      []byte        `json:"response_data"`
+	FallbackNodeIds   []pgtype.UUID `json:"fallback_node_ids"`
 }
 
 func (q *Queries) CreateRoutingRequest(ctx context.Context, arg CreateRoutingRequestParams) (RoutingRequest, error) {
@@ -45,6 +61,10 @@ func (q *Queries) CreateRoutingRequest(ctx context.Context, arg CreateRoutingReq
 		arg.RequestData,
 		arg.Metadata,
 		arg.ClientInfo,
+		arg.ProcessingMetrics,
+		arg.ResponseTimeMs,
+		arg.ResponseData,
+		arg.FallbackNodeIds,
 	)
 	var i RoutingRequest
 	err := row.Scan(
@@ -63,12 +83,47 @@ func (q *Queries) CreateRoutingRequest(ctx context.Context, arg CreateRoutingReq
 		&i.ClientInfo,
 		&i.ProcessingMetrics,
 		&i.CreatedAt,
+		&i.FailedNodeID,
+		&i.FailureReason,
+		&i.FailureReportedAt,
+		&i.FallbackNodeIds,
 	)
 	return i, err
 }
 
+const deleteRoutingRequestsBefore = `-- name: DeleteRoutingRequestsBefore :execrows
+DELETE FROM routing_requests WHERE id IN (
+    SELECT id FROM routing_requests WHERE created_at < $1 LIMIT $2
+)
+`
+
+type DeleteRoutingRequestsBeforeParams struct {
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+	Limit     int32            `json:"limit"`
+}
+
+func (q *Queries) DeleteRoutingRequestsBefore(ctx context.Context, arg DeleteRoutingRequestsBeforeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRoutingRequestsBefore, arg.CreatedAt, arg.Limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteRoutingRequestsByNode = `-- name: DeleteRoutingRequestsByNode :execrows
+DELETE FROM routing_requests WHERE selected_node_id = $1
+`
+
+func (q *Queries) DeleteRoutingRequestsByNode(ctx context.Context, selectedNodeID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRoutingRequestsByNode, selectedNodeID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getRecentRoutingRequests = `-- name: GetRecentRoutingRequests :many
-SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at FROM routing_requests 
+SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at, failed_node_id, failure_reason, failure_reported_at, fallback_node_ids FROM routing_requests 
 ORDER BY created_at DESC 
 LIMIT $1
 `
@@ -98,6 +153,10 @@ func (q *Queries) GetRecentRoutingRequests(ctx context.Context, limit int32) ([]
 			&i.ClientInfo,
 			&i.ProcessingMetrics,
 			&i.CreatedAt,
+			&i.FailedNodeID,
+			&i.FailureReason,
+			&i.FailureReportedAt,
+			&i.FallbackNodeIds,
 		); err != nil {
 			return nil, err
 		}
@@ -110,7 +169,7 @@ func (q *Queries) GetRecentRoutingRequests(ctx context.Context, limit int32) ([]
 }
 
 const getRoutingRequestByID = `-- name: GetRoutingRequestByID :one
-SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at FROM routing_requests WHERE id = $1
+SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at, failed_node_id, failure_reason, failure_reported_at, fallback_node_ids FROM routing_requests WHERE id = $1
 `
 
 func (q *Queries) GetRoutingRequestByID(ctx context.Context, id pgtype.UUID) (RoutingRequest, error) {
@@ -132,12 +191,50 @@ func (q *Queries) GetRoutingRequestByID(ctx context.Context, id pgtype.UUID) (Ro
 		&i.ClientInfo,
 		&i.ProcessingMetrics,
 		&i.CreatedAt,
+		&i.FailedNodeID,
+		&i.FailureReason,
+		&i.FailureReportedAt,
+		&i.FallbackNodeIds,
+	)
+	return i, err
+}
+
+const getRoutingRequestByRequestID = `-- name: GetRoutingRequestByRequestID :one
+SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at, failed_node_id, failure_reason, failure_reported_at, fallback_node_ids FROM routing_requests
+WHERE request_id = $1
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetRoutingRequestByRequestID(ctx context.Context, requestID string) (RoutingRequest, error) {
+	row := q.db.QueryRow(ctx, getRoutingRequestByRequestID, requestID)
+	var i RoutingRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequestID,
+		&i.CoordinatesX,
+		&i.CoordinatesY,
+		&i.SelectedNodeID,
+		&i.Distance,
+		&i.LoadScore,
+		&i.Status,
+		&i.ResponseTimeMs,
+		&i.RequestData,
+		&i.ResponseData,
+		&i.Metadata,
+		&i.ClientInfo,
+		&i.ProcessingMetrics,
+		&i.CreatedAt,
+		&i.FailedNodeID,
+		&i.FailureReason,
+		&i.FailureReportedAt,
+		&i.FallbackNodeIds,
 	)
 	return i, err
 }
 
 const getRoutingRequestsByNode = `-- name: GetRoutingRequestsByNode :many
-SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at FROM routing_requests 
+SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at, failed_node_id, failure_reason, failure_reported_at, fallback_node_ids FROM routing_requests 
 WHERE selected_node_id = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -173,6 +270,10 @@ func (q *Queries) GetRoutingRequestsByNode(ctx context.Context, arg GetRoutingRe
 			&i.ClientInfo,
 			&i.ProcessingMetrics,
 			&i.CreatedAt,
+			&i.FailedNodeID,
+			&i.FailureReason,
+			&i.FailureReportedAt,
+			&i.FallbackNodeIds,
 		); err != nil {
 			return nil, err
 		}
@@ -185,7 +286,7 @@ func (q *Queries) GetRoutingRequestsByNode(ctx context.Context, arg GetRoutingRe
 }
 
 const getRoutingRequestsByStatus = `-- name: GetRoutingRequestsByStatus :many
-SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at FROM routing_requests 
+SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at, failed_node_id, failure_reason, failure_reported_at, fallback_node_ids FROM routing_requests 
 WHERE status = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -221,6 +322,62 @@ func (q *Queries) GetRoutingRequestsByStatus(ctx context.Context, arg GetRouting
 			&i.ClientInfo,
 			&i.ProcessingMetrics,
 			&i.CreatedAt,
+			&i.FailedNodeID,
+			&i.FailureReason,
+			&i.FailureReportedAt,
+			&i.FallbackNodeIds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRoutingRequestsSince = `-- name: GetRoutingRequestsSince :many
+SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at, failed_node_id, failure_reason, failure_reported_at, fallback_node_ids FROM routing_requests
+WHERE created_at >= $1
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type GetRoutingRequestsSinceParams struct {
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+	Limit     int32            `json:"limit"`
+}
+
+func (q *Queries) GetRoutingRequestsSince(ctx context.Context, arg GetRoutingRequestsSinceParams) ([]RoutingRequest, error) {
+	rows, err := q.db.Query(ctx, getRoutingRequestsSince, arg.CreatedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RoutingRequest
+	for rows.Next() {
+		var i RoutingRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestID,
+			&i.CoordinatesX,
+			&i.CoordinatesY,
+			&i.SelectedNodeID,
+			&i.Distance,
+			&i.LoadScore,
+			&i.Status,
+			&i.ResponseTimeMs,
+			&i.RequestData,
+			&i.ResponseData,
+			&i.Metadata,
+			&i.ClientInfo,
+			&i.ProcessingMetrics,
+			&i.CreatedAt,
+			&i.FailedNodeID,
+			&i.FailureReason,
+			&i.FailureReportedAt,
+			&i.FallbackNodeIds,
 		); err != nil {
 			return nil, err
 		}
@@ -232,8 +389,55 @@ func (q *Queries) GetRoutingRequestsByStatus(ctx context.Context, arg GetRouting
 	return items, nil
 }
 
+const reportRoutingFailure = `-- name: ReportRoutingFailure :one
+UPDATE routing_requests
+SET failed_node_id = $1, failure_reason = $2,
+    failure_reported_at = NOW()
+WHERE id = (
+    SELECT id FROM routing_requests
+    WHERE request_id = $3
+    ORDER BY created_at DESC
+    LIMIT 1
+)
+  AND (selected_node_id = $1 OR $1 = ANY(fallback_node_ids))
+RETURNING id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at, failed_node_id, failure_reason, failure_reported_at, fallback_node_ids
+`
+
+type ReportRoutingFailureParams struct {
+	FailedNodeID  pgtype.UUID `json:"failed_node_id"`
+	FailureReason pgtype.Text `json:"failure_reason"`
+	RequestID     string      `json:"request_id"`
+}
+
+func (q *Queries) ReportRoutingFailure(ctx context.Context, arg ReportRoutingFailureParams) (RoutingRequest, error) {
+	row := q.db.QueryRow(ctx, reportRoutingFailure, arg.FailedNodeID, arg.FailureReason, arg.RequestID)
+	var i RoutingRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequestID,
+		&i.CoordinatesX,
+		&i.CoordinatesY,
+		&i.SelectedNodeID,
+		&i.Distance,
+		&i.LoadScore,
+		&i.Status,
+		&i.ResponseTimeMs,
+		&i.RequestData,
+		&i.ResponseData,
+		&i.Metadata,
+		&i.ClientInfo,
+		&i.ProcessingMetrics,
+		&i.CreatedAt,
+		&i.FailedNodeID,
+		&i.FailureReason,
+		&i.FailureReportedAt,
+		&i.FallbackNodeIds,
+	)
+	return i, err
+}
+
 const searchRoutingRequests = `-- name: SearchRoutingRequests :many
-SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at FROM routing_requests 
+SELECT id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at, failed_node_id, failure_reason, failure_reported_at, fallback_node_ids FROM routing_requests 
 WHERE request_data @> $1::jsonb OR metadata @> $2::jsonb
 ORDER BY created_at DESC
 LIMIT $3
@@ -270,6 +474,10 @@ func (q *Queries) SearchRoutingRequests(ctx context.Context, arg SearchRoutingRe
 			&i.ClientInfo,
 			&i.ProcessingMetrics,
 			&i.CreatedAt,
+			&i.FailedNodeID,
+			&i.FailureReason,
+			&i.FailureReportedAt,
+			&i.FallbackNodeIds,
 		); err != nil {
 			return nil, err
 		}
@@ -285,7 +493,7 @@ const updateRoutingResponse = `-- name: UpdateRoutingResponse :one
 UPDATE routing_requests 
 SET response_data = $2, processing_metrics = $3, response_time_ms = $4, status = $5
 WHERE id = $1
-RETURNING id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at
+RETURNING id, request_id, coordinates_x, coordinates_y, selected_node_id, distance, load_score, status, response_time_ms, request_data, response_data, metadata, client_info, processing_metrics, created_at, failed_node_id, failure_reason, failure_reported_at, fallback_node_ids
 `
 
 type UpdateRoutingResponseParams struct {
@@ -321,6 +529,10 @@ func (q *Queries) UpdateRoutingResponse(ctx context.Context, arg UpdateRoutingRe
 		&i.ClientInfo,
 		&i.ProcessingMetrics,
 		&i.CreatedAt,
+		&i.FailedNodeID,
+		&i.FailureReason,
+		&i.FailureReportedAt,
+		&i.FallbackNodeIds,
 	)
 	return i, err
 }
@@ -36,6 +36,109 @@ func (q *Queries) CreateSystemMetric(ctx context.Context, arg CreateSystemMetric
 	return i, err
 }
 
+const deleteSystemMetricsBefore = `-- name: DeleteSystemMetricsBefore :execrows
+DELETE FROM system_metrics WHERE id IN (
+    SELECT id FROM system_metrics WHERE timestamp < $1 LIMIT $2
+)
+`
+
+type DeleteSystemMetricsBeforeParams struct {
+	Timestamp pgtype.Timestamp `json:"timestamp"`
+	Limit     int32            `json:"limit"`
+}
+
+func (q *Queries) DeleteSystemMetricsBefore(ctx context.Context, arg DeleteSystemMetricsBeforeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSystemMetricsBefore, arg.Timestamp, arg.Limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSystemMetricsByNode = `-- name: DeleteSystemMetricsByNode :execrows
+DELETE FROM system_metrics WHERE node_id = $1
+`
+
+func (q *Queries) DeleteSystemMetricsByNode(ctx context.Context, nodeID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSystemMetricsByNode, nodeID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getLatestSystemMetrics = `-- name: GetLatestSystemMetrics :many
+SELECT id, metric_type, node_id, value, timestamp FROM system_metrics
+WHERE (node_id, metric_type, timestamp) IN (
+    SELECT node_id, metric_type, MAX(timestamp) FROM system_metrics
+    GROUP BY node_id, metric_type
+)
+ORDER BY node_id, metric_type
+`
+
+func (q *Queries) GetLatestSystemMetrics(ctx context.Context) ([]SystemMetric, error) {
+	rows, err := q.db.Query(ctx, getLatestSystemMetrics)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SystemMetric
+	for rows.Next() {
+		var i SystemMetric
+		if err := rows.Scan(
+			&i.ID,
+			&i.MetricType,
+			&i.NodeID,
+			&i.Value,
+			&i.Timestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNodeMetricsSince = `-- name: GetNodeMetricsSince :many
+SELECT id, metric_type, node_id, value, timestamp FROM system_metrics
+WHERE node_id = $1 AND timestamp >= $2
+ORDER BY timestamp ASC
+`
+
+type GetNodeMetricsSinceParams struct {
+	NodeID pgtype.UUID      `json:"node_id"`
+	Since  pgtype.Timestamp `json:"since"`
+}
+
+func (q *Queries) GetNodeMetricsSince(ctx context.Context, arg GetNodeMetricsSinceParams) ([]SystemMetric, error) {
+	rows, err := q.db.Query(ctx, getNodeMetricsSince, arg.NodeID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SystemMetric
+	for rows.Next() {
+		var i SystemMetric
+		if err := rows.Scan(
+			&i.ID,
+			&i.MetricType,
+			&i.NodeID,
+			&i.Value,
+			&i.Timestamp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRecentSystemMetrics = `-- name: GetRecentSystemMetrics :many
 SELECT id, metric_type, node_id, value, timestamp FROM system_metrics 
 ORDER BY timestamp DESC 
@@ -0,0 +1,144 @@
+// Package events persists node lifecycle events and broadcasts each one once
+// it is stored, so the WebSocket feed and a node's history agree.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/websocket"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Actors recorded for changes the supervisor makes on its own or on a node's
+// behalf. Changes made through the admin API are recorded as AdminActor.
+const (
+	ActorHealthMonitor = "health_monitor"
+	ActorNode          = "node"
+	ActorAdmin         = "admin"
+)
+
+// AdminActor names the admin behind a change by the subject of their token,
+// or just ActorAdmin when the token has none.
+func AdminActor(subject string) string {
+	if subject == "" {
+		return ActorAdmin
+	}
+	return ActorAdmin + ":" + subject
+}
+
+// Event is a node lifecycle change. Type is also the WebSocket message type.
+type Event struct {
+	NodeID uuid.UUID
+	Type   string
+	Actor  string
+	Before *models.Node
+	After  *models.Node
+	// Data is the WebSocket payload, the After snapshot when nil
+	Data interface{}
+}
+
+// Recorder stores node events and publishes them to WebSocket clients.
+type Recorder struct {
+	db     *database.Database
+	hub    *websocket.Hub
+	logger *slog.Logger
+}
+
+func NewRecorder(database *database.Database, hub *websocket.Hub, logger *slog.Logger) *Recorder {
+	return &Recorder{db: database, hub: hub, logger: logger}
+}
+
+// Record stores the event and broadcasts it. The change it describes has
+// already been made, so a failure to store it is logged and the event is
+// still broadcast.
+func (r *Recorder) Record(ctx context.Context, e Event) {
+	if err := r.Store(ctx, r.db.Queries, e); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to store node event",
+			"node_id", e.NodeID, "event_type", e.Type, "error", err)
+	}
+	r.Publish(e)
+}
+
+// Store persists the event through q without broadcasting it, for changes
+// made in a transaction or announced by a single summary message.
+func (r *Recorder) Store(ctx context.Context, q db.Querier, e Event) error {
+	before, err := snapshot(e.Before)
+	if err != nil {
+		return err
+	}
+	after, err := snapshot(e.After)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateNodeEvent(ctx, db.CreateNodeEventParams{
+		NodeID:    pgtype.UUID{Bytes: e.NodeID, Valid: true},
+		EventType: e.Type,
+		Actor:     e.Actor,
+		Before:    before,
+		After:     after,
+	})
+	return err
+}
+
+// Publish broadcasts the event without storing it.
+func (r *Recorder) Publish(e Event) {
+	data := e.Data
+	if data == nil {
+		data = e.After
+	}
+	r.hub.Publish(websocket.Message{Type: e.Type, Data: data})
+}
+
+// snapshot encodes a node for a JSONB column, nil for NULL.
+func snapshot(node *models.Node) ([]byte, error) {
+	if node == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode node snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// ConvertDBEventToModel decodes a stored event and its snapshots.
+func ConvertDBEventToModel(row db.NodeEvent) (models.NodeEvent, error) {
+	before, err := decodeSnapshot(row.Before)
+	if err != nil {
+		return models.NodeEvent{}, err
+	}
+	after, err := decodeSnapshot(row.After)
+	if err != nil {
+		return models.NodeEvent{}, err
+	}
+
+	return models.NodeEvent{
+		ID:        uuid.UUID(row.ID.Bytes),
+		NodeID:    uuid.UUID(row.NodeID.Bytes),
+		EventType: row.EventType,
+		Actor:     row.Actor,
+		Before:    before,
+		After:     after,
+		CreatedAt: row.CreatedAt.Time,
+	}, nil
+}
+
+// decodeSnapshot reverses snapshot, returning nil for NULL.
+func decodeSnapshot(data []byte) (*models.Node, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var node models.Node
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to decode node snapshot: %w", err)
+	}
+	return &node, nil
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+type breaker struct {
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+// breakers tracks a circuit breaker per node. After threshold consecutive
+// failures a node's breaker opens and its checks are skipped until cooldown
+// has passed; then a single half-open probe decides whether it closes again
+// or reopens for another cooldown.
+type breakers struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	nodes     map[uuid.UUID]*breaker
+}
+
+func newBreakers(threshold int, cooldown time.Duration) *breakers {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &breakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		nodes:     make(map[uuid.UUID]*breaker),
+	}
+}
+
+func (b *breakers) get(nodeID uuid.UUID) *breaker {
+	br, ok := b.nodes[nodeID]
+	if !ok {
+		br = &breaker{state: BreakerClosed}
+		b.nodes[nodeID] = br
+	}
+	return br
+}
+
+// allow reports whether the node may be probed now, moving an open breaker
+// to half-open once its cooldown has elapsed.
+func (b *breakers) allow(nodeID uuid.UUID, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.get(nodeID)
+	if br.state == BreakerOpen && now.Sub(br.openedAt) >= b.cooldown {
+		br.state = BreakerHalfOpen
+	}
+	return br.state != BreakerOpen
+}
+
+// record applies a probe result and returns the resulting breaker state.
+func (b *breakers) record(nodeID uuid.UUID, success bool, now time.Time) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br := b.get(nodeID)
+	if success {
+		br.state = BreakerClosed
+		br.failures = 0
+		return br.state
+	}
+
+	br.failures++
+	if br.state == BreakerHalfOpen || br.failures >= b.threshold {
+		br.state = BreakerOpen
+		br.openedAt = now
+	}
+	return br.state
+}
+
+func (b *breakers) state(nodeID uuid.UUID) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if br, ok := b.nodes[nodeID]; ok {
+		return br.state
+	}
+	return BreakerClosed
+}
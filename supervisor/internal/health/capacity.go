@@ -0,0 +1,80 @@
+package health
+
+import (
+	"math"
+	"time"
+
+	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/websocket"
+	"github.com/google/uuid"
+)
+
+// MessageTypeCapacityWarning is broadcast when a node crosses the capacity
+// warning threshold.
+const MessageTypeCapacityWarning = "node_capacity_warning"
+
+// MetricCapacityWarning records the utilization that set off a capacity
+// warning, so warnings show up in a node's metric history.
+const MetricCapacityWarning = "capacity_warning"
+
+// CapacityWarning is the node_capacity_warning broadcast. Utilizations are
+// fractions: active connections over capacity and CPU usage over 100.
+// Resources names which of them are at or above the threshold.
+type CapacityWarning struct {
+	NodeID                uuid.UUID `json:"node_id"`
+	Name                  string    `json:"name"`
+	ConnectionUtilization float64   `json:"connection_utilization"`
+	CPUUtilization        float64   `json:"cpu_utilization"`
+	Threshold             float64   `json:"threshold"`
+	Resources             []string  `json:"resources"`
+	Timestamp             time.Time `json:"timestamp"`
+}
+
+// checkCapacity warns once when a node's connection or CPU utilization
+// reaches the capacity warning threshold. The node must fall back below the
+// threshold before it can warn again, so a node that stays busy does not
+// warn on every check.
+func (m *Monitor) checkCapacity(node models.Node) {
+	threshold := m.checks.Load().capacityThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	warning := CapacityWarning{
+		NodeID:         node.ID,
+		Name:           node.Name,
+		CPUUtilization: node.CPUUsage / 100,
+		Threshold:      threshold,
+	}
+	if node.Capacity > 0 {
+		warning.ConnectionUtilization = float64(node.ActiveConnections) / float64(node.Capacity)
+	}
+	if warning.ConnectionUtilization >= threshold {
+		warning.Resources = append(warning.Resources, MetricConnections)
+	}
+	if warning.CPUUtilization >= threshold {
+		warning.Resources = append(warning.Resources, MetricCPU)
+	}
+
+	m.mu.Lock()
+	warned := m.capacityWarned[node.ID]
+	if len(warning.Resources) == 0 {
+		delete(m.capacityWarned, node.ID)
+	} else {
+		m.capacityWarned[node.ID] = true
+	}
+	m.mu.Unlock()
+	if warned || len(warning.Resources) == 0 {
+		return
+	}
+
+	m.logger.Warn("Node is near capacity",
+		"node_id", node.ID, "node_name", node.Name, "resources", warning.Resources,
+		"connection_utilization", warning.ConnectionUtilization, "cpu_utilization", warning.CPUUtilization)
+	m.createSystemMetric(node.ID, MetricCapacityWarning, math.Max(warning.ConnectionUtilization, warning.CPUUtilization))
+	warning.Timestamp = time.Now().UTC()
+	m.wsHub.Publish(websocket.Message{
+		Type: MessageTypeCapacityWarning,
+		Data: warning,
+	})
+}
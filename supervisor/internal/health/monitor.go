@@ -2,11 +2,29 @@ package health
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"arx-supervisor/internal/config"
 	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/events"
+	"arx-supervisor/internal/metrics"
+	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/routing"
 	"arx-supervisor/internal/websocket"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type HealthResponse struct {
@@ -29,79 +47,792 @@ type NodeLocation struct {
 	Y float64 `json:"y"`
 }
 
+// Metric types recorded for every successful health check
+const (
+	MetricCPU         = "cpu"
+	MetricMemory      = "memory"
+	MetricConnections = "connections"
+)
+
 type Monitor struct {
-	db       *database.Database
-	wsHub    *websocket.Hub
-	interval time.Duration
+	db     *database.Database
+	logger *slog.Logger
+	router *routing.Service
+	wsHub  *websocket.Hub
+	events *events.Recorder
+	client *http.Client
+	// concurrency caps the checks running at once in a pass
+	concurrency int
+
+	// checks holds the settings Reconfigure can change, and reconfigured
+	// tells Start to pick up a new interval
+	checks       atomic.Pointer[checkSettings]
+	reconfigured chan struct{}
+
+	drainPeriod time.Duration
+
+	deregisterAfter time.Duration
+	autoDeregister  bool
+
+	// statsInterval is how often RunClusterStats broadcasts, zero for never
+	statsInterval time.Duration
+
+	// lastRun is when the last full pass over the nodes finished, in Unix
+	// nanoseconds, or zero before the first one
+	lastRun atomic.Int64
+	// passMu keeps scheduled and forced passes from running at once
+	passMu sync.Mutex
+
+	mu        sync.Mutex
+	failures  map[uuid.UUID]int
+	nextCheck map[uuid.UUID]time.Time
+	breakers  *breakers
+
+	// unhealthySince records when each node was first seen unhealthy, and
+	// deregistered the nodes set inactive for staying unhealthy so further
+	// failed checks leave them inactive. Both are cleared on success.
+	unhealthySince map[uuid.UUID]time.Time
+	deregistered   map[uuid.UUID]bool
+	// capacityWarned holds the nodes that have warned about capacity and
+	// not yet dropped back below the threshold
+	capacityWarned map[uuid.UUID]bool
 }
 
-func NewMonitor(db *database.Database, wsHub *websocket.Hub, interval time.Duration) *Monitor {
-	return &Monitor{
-		db:       db,
-		wsHub:    wsHub,
-		interval: interval,
+// CheckResult is the outcome of one health check: the node as stored after
+// it, its circuit breaker state and, when the probe failed, why.
+type CheckResult struct {
+	Node         models.Node `json:"node"`
+	BreakerState string      `json:"breaker_state"`
+	ProbeError   string      `json:"probe_error,omitempty"`
+	CheckedAt    time.Time   `json:"checked_at"`
+}
+
+// nodeHealthPayload is the node_health_updated broadcast: the node plus its
+// circuit breaker state.
+type nodeHealthPayload struct {
+	models.Node
+	BreakerState string `json:"breaker_state"`
+}
+
+// checkSettings are the health check settings that can change while the
+// monitor runs.
+type checkSettings struct {
+	interval         time.Duration
+	timeout          time.Duration
+	failureThreshold int
+	maxBackoff       time.Duration
+	// capacityThreshold is the utilization that triggers a capacity
+	// warning, zero for never
+	capacityThreshold float64
+}
+
+func newCheckSettings(cfg config.HealthConfig) *checkSettings {
+	threshold := cfg.FailureThreshold
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &checkSettings{
+		interval:          time.Duration(cfg.CheckInterval) * time.Second,
+		timeout:           time.Duration(cfg.Timeout) * time.Second,
+		failureThreshold:  threshold,
+		maxBackoff:        time.Duration(cfg.MaxBackoff) * time.Second,
+		capacityThreshold: cfg.CapacityWarningThreshold,
 	}
 }
 
+// ValidateConfig checks that health checks can be scheduled and bounded
+// with cfg.
+func ValidateConfig(cfg config.HealthConfig) error {
+	switch {
+	case cfg.CheckInterval < 1:
+		return errors.New("health check interval must be at least 1 second")
+	case cfg.Timeout < 1:
+		return errors.New("health check timeout must be at least 1 second")
+	case cfg.MaxBackoff < 0:
+		return errors.New("health check backoff cap must be non-negative")
+	case cfg.CapacityWarningThreshold < 0:
+		return errors.New("capacity warning threshold must be non-negative")
+	}
+	return nil
+}
+
+func NewMonitor(db *database.Database, router *routing.Service, wsHub *websocket.Hub, recorder *events.Recorder, cfg config.HealthConfig, logger *slog.Logger) *Monitor {
+	concurrency := cfg.CheckConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	m := &Monitor{
+		db:     db,
+		logger: logger,
+		router: router,
+		wsHub:  wsHub,
+		events: recorder,
+		// Probes are bounded by the timeout in effect through their context
+		client:          &http.Client{},
+		concurrency:     concurrency,
+		reconfigured:    make(chan struct{}, 1),
+		drainPeriod:     time.Duration(cfg.DrainPeriod) * time.Second,
+		deregisterAfter: time.Duration(cfg.DeregisterAfter) * time.Second,
+		autoDeregister:  cfg.AutoDeregister,
+		statsInterval:   time.Duration(cfg.StatsInterval) * time.Second,
+		unhealthySince:  make(map[uuid.UUID]time.Time),
+		deregistered:    make(map[uuid.UUID]bool),
+		capacityWarned:  make(map[uuid.UUID]bool),
+		failures:        make(map[uuid.UUID]int),
+		nextCheck:       make(map[uuid.UUID]time.Time),
+		breakers:        newBreakers(cfg.BreakerThreshold, time.Duration(cfg.BreakerCooldown)*time.Second),
+	}
+	m.checks.Store(newCheckSettings(cfg))
+	return m
+}
+
+// Reconfigure applies a new check interval, timeout, failure threshold,
+// backoff cap and capacity warning threshold. Passes and probes already
+// running finish with the old settings; the next pass is scheduled one new
+// interval from now.
+func (m *Monitor) Reconfigure(cfg config.HealthConfig) {
+	m.checks.Store(newCheckSettings(cfg))
+	select {
+	case m.reconfigured <- struct{}{}:
+	default:
+	}
+}
+
+// Start checks every node right away and then once per interval. It never
+// returns.
 func (m *Monitor) Start() {
-	ticker := time.NewTicker(m.interval)
+	ticker := time.NewTicker(m.checks.Load().interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		m.checkAllNodes()
+	m.checkAllNodes()
+	for {
+		select {
+		case <-ticker.C:
+			m.checkAllNodes()
+		case <-m.reconfigured:
+			ticker.Reset(m.checks.Load().interval)
+		}
 	}
 }
 
+// CheckNode probes a node right away, regardless of its backoff, circuit
+// breaker or recent heartbeats, and stores the result like a scheduled
+// check. Unknown nodes return an error matching database.IsNotFound.
+func (m *Monitor) CheckNode(ctx context.Context, nodeID uuid.UUID) (CheckResult, error) {
+	node, err := m.db.Queries.GetNodeByID(ctx, pgtype.UUID{Bytes: nodeID, Valid: true})
+	if err != nil {
+		return CheckResult{}, err
+	}
+	return m.checkNode(routing.ConvertDBNodeToModel(node), time.Now())
+}
+
+// CheckAll runs a pass over every node right away, like CheckNode for each
+// of them, and returns the results. It waits for a scheduled pass in
+// progress to finish first. Nodes not checked before ctx is done are left
+// out.
+func (m *Monitor) CheckAll(ctx context.Context) ([]CheckResult, error) {
+	return m.runPass(ctx, true)
+}
+
 func (m *Monitor) checkAllNodes() {
-	nodes, err := m.db.Queries.GetAllNodes(context.Background())
+	if _, err := m.runPass(context.Background(), false); err != nil {
+		m.logger.Error("Failed to load nodes for health check", "error", err)
+	}
+}
+
+// readyWindow is how many check intervals may pass without a completed pass
+// before the monitor counts as stuck.
+const readyWindow = 3
+
+// Ready returns an error unless a pass over the nodes has completed within
+// the last few check intervals.
+func (m *Monitor) Ready(ctx context.Context) error {
+	last := m.lastRun.Load()
+	if last == 0 {
+		return errors.New("no health check pass has completed yet")
+	}
+	if age := time.Since(time.Unix(0, last)); age > readyWindow*m.checks.Load().interval {
+		return fmt.Errorf("last health check pass finished %s ago", age.Round(time.Second))
+	}
+	return nil
+}
+
+// runPass checks the nodes that are due and updates the node gauges. A forced
+// pass checks every node, ignoring backoff, open breakers and heartbeats,
+// and is not cut short by the interval.
+func (m *Monitor) runPass(ctx context.Context, force bool) ([]CheckResult, error) {
+	m.passMu.Lock()
+	defer m.passMu.Unlock()
+
+	nodes, err := m.db.Queries.GetAllNodes(ctx)
 	if err != nil {
-		return
+		return nil, err
 	}
 
+	var (
+		wg      sync.WaitGroup
+		healthy atomic.Int64
+		skipped atomic.Int64
+
+		resultsMu sync.Mutex
+		results   []CheckResult
+	)
+	now := time.Now()
+	interval := m.checks.Load().interval
+	due := make([]models.Node, 0, len(nodes))
 	for _, node := range nodes {
-		go m.checkNode(node)
+		nodeID := uuid.UUID(node.ID.Bytes)
+		if force {
+			due = append(due, routing.ConvertDBNodeToModel(node))
+			continue
+		}
+		if node.LastHeartbeat.Valid && now.Sub(node.LastHeartbeat.Time) < interval {
+			// The node pushed its load recently, which counts as a passed check
+			if node.Status.String == models.NodeStatusHealthy {
+				healthy.Add(1)
+			}
+			continue
+		}
+		if !m.due(nodeID, now) {
+			// Failing node is backing off
+			continue
+		}
+		if !m.breakers.allow(nodeID, now) {
+			// Breaker is open: leave the node alone until the cooldown passes
+			continue
+		}
+
+		due = append(due, routing.ConvertDBNodeToModel(node))
+	}
+
+	// At most concurrency checks run at once and the rest wait their turn.
+	// Checks that have not started within the interval are skipped so a slow
+	// pass never runs into the next one.
+	deadline := now.Add(interval)
+	queue := make(chan models.Node)
+	for range min(m.concurrency, len(due)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for node := range queue {
+				if (!force && time.Now().After(deadline)) || ctx.Err() != nil {
+					skipped.Add(1)
+					if node.Status == models.NodeStatusHealthy {
+						healthy.Add(1)
+					}
+					continue
+				}
+
+				result, err := m.checkNode(node, now)
+				if err != nil {
+					m.logger.Warn("Health check failed",
+						"node_id", node.ID, "node_name", node.Name, "error", err)
+				} else if result.ProbeError != "" {
+					m.logger.Warn("Health check failed",
+						"node_id", node.ID, "node_name", node.Name, "error", result.ProbeError)
+				}
+				if result.Node.Status == models.NodeStatusHealthy {
+					healthy.Add(1)
+				}
+				if err == nil {
+					resultsMu.Lock()
+					results = append(results, result)
+					resultsMu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, node := range due {
+		queue <- node
+	}
+	close(queue)
+	wg.Wait()
+
+	if n := skipped.Load(); n > 0 {
+		m.logger.Warn("Health check pass ran out of time, skipped the remaining nodes",
+			"skipped", n, "due", len(due), "concurrency", m.concurrency)
+	}
+
+	metrics.NodesTotal.Set(float64(len(nodes)))
+	metrics.NodesHealthy.Set(float64(healthy.Load()))
+	m.lastRun.Store(time.Now().UnixNano())
+
+	if m.drainPeriod > 0 {
+		m.removeDrainedNodes(now)
+	}
+	if m.deregisterAfter > 0 {
+		m.deregisterDeadNodes(now)
+	}
+	return results, nil
+}
+
+// Actions reported in node_deregistered broadcasts
+const (
+	DeregisterDeactivated = "deactivated"
+	DeregisterDeleted     = "deleted"
+)
+
+// deregisterDeadNodes sets nodes that have been unhealthy for longer than
+// deregisterAfter inactive, or soft-deletes them when autoDeregister is set.
+func (m *Monitor) deregisterDeadNodes(now time.Time) {
+	m.mu.Lock()
+	var dead []uuid.UUID
+	since := make(map[uuid.UUID]time.Time)
+	for nodeID, first := range m.unhealthySince {
+		if now.Sub(first) >= m.deregisterAfter {
+			dead = append(dead, nodeID)
+			since[nodeID] = first
+		}
+	}
+	m.mu.Unlock()
+	if len(dead) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	action := DeregisterDeactivated
+	deregister := m.db.Queries.DeactivateUnhealthyNode
+	if m.autoDeregister {
+		action = DeregisterDeleted
+		deregister = m.db.Queries.SoftDeleteUnhealthyNode
+	}
+
+	changed := false
+	for _, nodeID := range dead {
+		node, err := deregister(ctx, pgtype.UUID{Bytes: nodeID, Valid: true})
+		if err != nil && !database.IsNotFound(err) {
+			m.logger.Error("Failed to deregister node", "node_id", nodeID, "error", err)
+			continue
+		}
+
+		m.mu.Lock()
+		delete(m.unhealthySince, nodeID)
+		if err == nil && !m.autoDeregister {
+			m.deregistered[nodeID] = true
+		}
+		if m.autoDeregister {
+			delete(m.failures, nodeID)
+			delete(m.nextCheck, nodeID)
+			delete(m.capacityWarned, nodeID)
+		}
+		m.mu.Unlock()
+		if err != nil {
+			// The node recovered, was deleted or changed status meanwhile
+			continue
+		}
+
+		changed = true
+		m.logger.Info("Deregistered unhealthy node",
+			"node_id", nodeID, "node_name", node.Name, "action", action, "unhealthy_since", since[nodeID])
+		after := routing.ConvertDBNodeToModel(node)
+		m.events.Record(ctx, events.Event{
+			NodeID: nodeID,
+			Type:   "node_deregistered",
+			Actor:  events.ActorHealthMonitor,
+			After:  &after,
+			Data: map[string]interface{}{
+				"node_id":         nodeID,
+				"name":            node.Name,
+				"action":          action,
+				"unhealthy_since": since[nodeID].UTC(),
+				"timestamp":       time.Now().UTC(),
+			},
+		})
+	}
+	if changed {
+		m.router.InvalidateIndex()
+	}
+}
+
+// trackUnhealthy starts the deregistration clock when a node becomes
+// unhealthy and stops it when the node has any other status.
+func (m *Monitor) trackUnhealthy(nodeID uuid.UUID, status string, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if status != models.NodeStatusUnhealthy {
+		delete(m.unhealthySince, nodeID)
+		return
+	}
+	if _, ok := m.unhealthySince[nodeID]; !ok {
+		m.unhealthySince[nodeID] = now
+	}
+}
+
+// isDeregistered reports whether the monitor set the node inactive for
+// staying unhealthy.
+func (m *Monitor) isDeregistered(nodeID uuid.UUID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deregistered[nodeID]
+}
+
+// removeDrainedNodes soft-deletes nodes that have been draining for longer than
+// the drain period.
+func (m *Monitor) removeDrainedNodes(now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cutoff := pgtype.Timestamp{Time: now.UTC().Add(-m.drainPeriod), Valid: true}
+	removed, err := m.db.Queries.DeleteDrainedNodes(ctx, cutoff)
+	if err != nil {
+		m.logger.Error("Failed to remove drained nodes", "error", err)
+		return
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	m.router.InvalidateIndex()
+	for _, node := range removed {
+		nodeID := uuid.UUID(node.ID.Bytes)
+		m.mu.Lock()
+		delete(m.failures, nodeID)
+		delete(m.nextCheck, nodeID)
+		delete(m.capacityWarned, nodeID)
+		m.mu.Unlock()
+
+		m.logger.Info("Removed drained node", "node_id", nodeID, "node_name", node.Name)
+		after := routing.ConvertDBNodeToModel(node)
+		m.events.Record(ctx, events.Event{
+			NodeID: nodeID,
+			Type:   "node_deleted",
+			Actor:  events.ActorHealthMonitor,
+			After:  &after,
+			Data:   map[string]interface{}{"node_id": nodeID},
+		})
 	}
 }
 
-func (m *Monitor) checkNode(node interface{}) {
-	// This would check the health of a specific node
-	// For now, we'll just simulate health checks
+// checkNode probes the node's health endpoint and persists the reported load,
+// returning the node as stored afterwards. now is the start of the check
+// cycle. A node only becomes unhealthy after failureThreshold consecutive
+// failed probes and recovers on the first successful one; a node whose
+// circuit breaker is open is always unhealthy so it is excluded from routing.
+// A failed probe is reported in the result, and the error is only set when the
+// result could not be stored, in which case the result holds the node
+// unchanged.
+func (m *Monitor) checkNode(node models.Node, now time.Time) (CheckResult, error) {
+	health, probeErr := m.probe(node)
+	if probeErr == nil {
+		metrics.HealthChecks.WithLabelValues(metrics.ResultSuccess).Inc()
+	} else {
+		metrics.HealthChecks.WithLabelValues(metrics.ResultFailure).Inc()
+	}
+	failures := m.recordResult(node.ID, probeErr == nil, now)
+	breakerState := m.breakers.record(node.ID, probeErr == nil, now)
+	newStatus := m.nextStatus(node.Status, failures)
+	switch {
+	case node.Status == models.NodeStatusDraining:
+		// Keep reporting load, but a draining node never rejoins routing
+		newStatus = node.Status
+	case probeErr != nil && node.Status == models.NodeStatusInactive && m.isDeregistered(node.ID):
+		// A deregistered node stays inactive until it answers again
+		newStatus = node.Status
+	case breakerState == BreakerOpen:
+		newStatus = "unhealthy"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	// In a real implementation, you would:
-	// 1. Make an HTTP request to the node's health endpoint
-	// 2. Parse the response
-	// 3. Update the node's status in the database
-	// 4. Broadcast the update via WebSocket
+	params := db.UpdateNodeHealthParams{
+		ID:                pgtype.UUID{Bytes: node.ID, Valid: true},
+		Status:            newStatus,
+		CpuUsage:          pgtype.Float8{Float64: node.CPUUsage, Valid: true},
+		MemoryUsage:       pgtype.Float8{Float64: node.MemoryUsage, Valid: true},
+		ActiveConnections: pgtype.Int4{Int32: int32(node.ActiveConnections), Valid: true},
+	}
+	if node.LastHealthCheck != nil {
+		params.LastHealthCheck = pgtype.Timestamp{Time: *node.LastHealthCheck, Valid: true}
+	}
 
-	// For now, we'll just broadcast a mock health update
-	mockHealthUpdate := websocket.Message{
+	if probeErr == nil {
+		params.LastHealthCheck = pgtype.Timestamp{Time: time.Now().UTC(), Valid: true}
+	}
+	// TCP probes report no load, so keep what heartbeats last sent
+	if health != nil {
+		params.CpuUsage = pgtype.Float8{Float64: health.Load.CPUPercent, Valid: true}
+		params.MemoryUsage = pgtype.Float8{Float64: health.Load.MemoryPercent, Valid: true}
+		params.ActiveConnections = pgtype.Int4{Int32: int32(health.Load.ActiveConnections), Valid: true}
+	}
+
+	result := CheckResult{Node: node, BreakerState: breakerState, CheckedAt: time.Now().UTC()}
+	if probeErr != nil {
+		result.ProbeError = probeErr.Error()
+	}
+
+	updated, err := m.db.Queries.UpdateNodeHealth(ctx, params)
+	if err != nil {
+		return result, fmt.Errorf("failed to update node health: %w", err)
+	}
+
+	updatedNode := routing.ConvertDBNodeToModel(updated)
+	result.Node = updatedNode
+	newStatus = updatedNode.Status
+	m.trackUnhealthy(node.ID, newStatus, now)
+
+	if health != nil {
+		m.createSystemMetric(node.ID, MetricCPU, health.Load.CPUPercent)
+		m.createSystemMetric(node.ID, MetricMemory, health.Load.MemoryPercent)
+		m.createSystemMetric(node.ID, MetricConnections, float64(health.Load.ActiveConnections))
+	}
+	m.wsHub.Publish(websocket.Message{
 		Type: "node_health_updated",
-		Data: map[string]interface{}{
-			"id":                 uuid.New(), // Mock ID
-			"name":               "Mock Node",
-			"status":             "healthy",
-			"cpu_usage":          45.5,
-			"memory_usage":       67.8,
-			"active_connections": 23,
-			"last_health_check":  time.Now().UTC(),
-		},
-	}
-
-	// Send update to WebSocket hub
-	select {
-	case m.wsHub.Broadcast <- mockHealthUpdate:
+		Data: nodeHealthPayload{Node: updatedNode, BreakerState: breakerState},
+	})
+	if health != nil {
+		m.checkCapacity(updatedNode)
+	}
+
+	if node.Status != newStatus {
+		m.router.InvalidateIndex()
+		m.events.Record(ctx, events.Event{
+			NodeID: node.ID,
+			Type:   "node_status_changed",
+			Actor:  events.ActorHealthMonitor,
+			Before: &node,
+			After:  &updatedNode,
+			Data: map[string]interface{}{
+				"id":            node.ID,
+				"name":          node.Name,
+				"old_status":    node.Status,
+				"new_status":    newStatus,
+				"failures":      failures,
+				"breaker_state": breakerState,
+				"timestamp":     time.Now().UTC(),
+			},
+		})
+	}
+
+	return result, nil
+}
+
+// ErrHeartbeatRefused is returned for heartbeats from inactive nodes and
+// nodes in maintenance, which only a health check or an admin brings back.
+var ErrHeartbeatRefused = errors.New("heartbeats are refused for inactive nodes and nodes in maintenance")
+
+// RecordHeartbeat stores load pushed by a node itself. A heartbeat counts as
+// a successful check: the node becomes healthy (unless draining) and its
+// failure count and circuit breaker are reset. Unknown nodes return an error
+// matching database.IsNotFound, and inactive nodes or nodes in maintenance
+// ErrHeartbeatRefused.
+func (m *Monitor) RecordHeartbeat(ctx context.Context, nodeID uuid.UUID, heartbeat HealthResponse) (models.Node, error) {
+	id := pgtype.UUID{Bytes: nodeID, Valid: true}
+	existing, err := m.db.Queries.GetNodeByID(ctx, id)
+	if err != nil {
+		return models.Node{}, err
+	}
+	if existing.Status.String == models.NodeStatusInactive || existing.Maintenance {
+		return models.Node{}, ErrHeartbeatRefused
+	}
+
+	now := time.Now()
+	updated, err := m.db.Queries.RecordNodeHeartbeat(ctx, db.RecordNodeHeartbeatParams{
+		ID:                id,
+		CpuUsage:          pgtype.Float8{Float64: heartbeat.Load.CPUPercent, Valid: true},
+		MemoryUsage:       pgtype.Float8{Float64: heartbeat.Load.MemoryPercent, Valid: true},
+		ActiveConnections: pgtype.Int4{Int32: int32(heartbeat.Load.ActiveConnections), Valid: true},
+		LastHealthCheck:   pgtype.Timestamp{Time: now.UTC(), Valid: true},
+	})
+	if database.IsNotFound(err) {
+		// Disabled or deleted since it was read
+		return models.Node{}, ErrHeartbeatRefused
+	}
+	if err != nil {
+		return models.Node{}, fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+	node := routing.ConvertDBNodeToModel(updated)
+
+	m.recordResult(nodeID, true, now)
+	breakerState := m.breakers.record(nodeID, true, now)
+
+	m.createSystemMetric(nodeID, MetricCPU, heartbeat.Load.CPUPercent)
+	m.createSystemMetric(nodeID, MetricMemory, heartbeat.Load.MemoryPercent)
+	m.createSystemMetric(nodeID, MetricConnections, float64(heartbeat.Load.ActiveConnections))
+	m.wsHub.Publish(websocket.Message{
+		Type: "node_health_updated",
+		Data: nodeHealthPayload{Node: node, BreakerState: breakerState},
+	})
+	m.checkCapacity(node)
+
+	if existing.Status.String != node.Status {
+		m.router.InvalidateIndex()
+		before := routing.ConvertDBNodeToModel(existing)
+		m.events.Record(ctx, events.Event{
+			NodeID: nodeID,
+			Type:   "node_status_changed",
+			Actor:  events.ActorNode,
+			Before: &before,
+			After:  &node,
+			Data: map[string]interface{}{
+				"id":            node.ID,
+				"name":          node.Name,
+				"old_status":    existing.Status.String,
+				"new_status":    node.Status,
+				"failures":      0,
+				"breaker_state": breakerState,
+				"timestamp":     time.Now().UTC(),
+			},
+		})
+	}
+
+	return node, nil
+}
+
+// recordResult updates the consecutive failure counter for a node, schedules
+// its next check and returns the new count. Failing nodes back off
+// exponentially; the first success restores the base interval.
+func (m *Monitor) recordResult(nodeID uuid.UUID, success bool, now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if success {
+		delete(m.failures, nodeID)
+		delete(m.nextCheck, nodeID)
+		delete(m.unhealthySince, nodeID)
+		delete(m.deregistered, nodeID)
+		return 0
+	}
+
+	m.failures[nodeID]++
+	failures := m.failures[nodeID]
+	m.nextCheck[nodeID] = now.Add(m.backoff(failures))
+	return failures
+}
+
+// backoff returns the delay before the next check of a node with the given
+// number of consecutive failures: the base interval doubled per failure,
+// capped at maxBackoff, with jitter spreading it over [delay/2, delay] so
+// flapping nodes are not all probed at once.
+func (m *Monitor) backoff(failures int) time.Duration {
+	checks := m.checks.Load()
+	delay := checks.interval
+	for i := 1; i < failures && delay < checks.maxBackoff; i++ {
+		delay *= 2
+	}
+	if checks.maxBackoff > 0 && delay > checks.maxBackoff {
+		delay = checks.maxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// due reports whether a node's next scheduled check has arrived.
+func (m *Monitor) due(nodeID uuid.UUID, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next, ok := m.nextCheck[nodeID]
+	return !ok || !now.Before(next)
+}
+
+func (m *Monitor) nextStatus(current string, failures int) string {
+	switch {
+	case failures == 0:
+		return "healthy"
+	case failures >= m.checks.Load().failureThreshold:
+		return "unhealthy"
+	case current == "healthy" || current == "unhealthy":
+		// Tolerate transient failures until the threshold is reached
+		return current
 	default:
-		// Channel is full, skip this update
+		return "degraded"
 	}
 }
 
+func (m *Monitor) probe(node models.Node) (*HealthResponse, error) {
+	return m.Probe(context.Background(), node.Endpoint, node.HealthProtocol, node.HealthPath)
+}
+
+// Probe checks endpoint once over protocol, within the health check timeout,
+// without recording anything. HTTP and HTTPS fetch path and return the
+// reported health; TCP only connects and returns no health. An empty protocol
+// or path falls back to the defaults for the endpoint. It is used to vet
+// endpoints before they are registered.
+func (m *Monitor) Probe(ctx context.Context, endpoint, protocol, path string) (*HealthResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.checks.Load().timeout)
+	defer cancel()
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if protocol == "" {
+		protocol = models.DefaultHealthProtocol(endpoint)
+	}
+	if protocol == models.HealthProtocolTCP {
+		return nil, dialTCP(ctx, u)
+	}
+	if path == "" {
+		path = models.DefaultHealthPath
+	}
+
+	target := *u
+	target.Scheme = protocol
+	target.Path = strings.TrimSuffix(u.Path, "/") + path
+	target.RawPath = ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build health request: %w", err)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("health request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected health status code %d", resp.StatusCode)
+	}
+
+	var health HealthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to decode health response: %w", err)
+	}
+
+	return &health, nil
+}
+
+// dialTCP connects to the endpoint's host and port, defaulting the port from
+// its scheme, and closes the connection straight away.
+func dialTCP(ctx context.Context, u *url.URL) error {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return fmt.Errorf("tcp connect failed: %w", err)
+	}
+	return conn.Close()
+}
+
 func (m *Monitor) createSystemMetric(nodeID uuid.UUID, metricType string, value float64) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Create system metric in database would go here
-	_ = ctx
-	_ = nodeID
-	_ = metricType
-	_ = value
+	_, err := m.db.Queries.CreateSystemMetric(ctx, db.CreateSystemMetricParams{
+		MetricType: metricType,
+		NodeID:     pgtype.UUID{Bytes: nodeID, Valid: true},
+		Value:      value,
+	})
+	if err != nil {
+		m.logger.Error("Failed to record metric",
+			"metric_type", metricType, "node_id", nodeID, "error", err)
+	}
 }
@@ -0,0 +1,69 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"arx-supervisor/internal/websocket"
+)
+
+// MessageTypeClusterStats is the periodic cluster summary broadcast.
+const MessageTypeClusterStats = "cluster_stats"
+
+// ClusterStats summarises the registered nodes. CPU and memory are averaged
+// over healthy nodes and are zero when none is healthy; active connections
+// are summed over every node.
+type ClusterStats struct {
+	TotalNodes        int64     `json:"total_nodes"`
+	HealthyNodes      int64     `json:"healthy_nodes"`
+	DegradedNodes     int64     `json:"degraded_nodes"`
+	UnhealthyNodes    int64     `json:"unhealthy_nodes"`
+	AvgCPUUsage       float64   `json:"avg_cpu_usage"`
+	AvgMemoryUsage    float64   `json:"avg_memory_usage"`
+	ActiveConnections int64     `json:"active_connections"`
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+// RunClusterStats broadcasts a cluster_stats message every stats interval
+// until ctx is cancelled. Ticks are skipped while no WebSocket client is
+// connected, so an idle supervisor does not query the database for nothing.
+// A zero interval disables the broadcast.
+func (m *Monitor) RunClusterStats(ctx context.Context) {
+	if m.statsInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(m.statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if m.wsHub.ClientCount() == 0 {
+				continue
+			}
+
+			row, err := m.db.Queries.GetClusterStats(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					m.logger.Warn("Failed to compute cluster stats", "error", err)
+				}
+				continue
+			}
+			m.wsHub.PublishTransient(websocket.Message{
+				Type: MessageTypeClusterStats,
+				Data: ClusterStats{
+					TotalNodes:        row.TotalNodes,
+					HealthyNodes:      row.HealthyNodes,
+					DegradedNodes:     row.DegradedNodes,
+					UnhealthyNodes:    row.UnhealthyNodes,
+					AvgCPUUsage:       row.AvgCpuUsage,
+					AvgMemoryUsage:    row.AvgMemoryUsage,
+					ActiveConnections: row.ActiveConnections,
+					Timestamp:         now.UTC(),
+				},
+			})
+		}
+	}
+}
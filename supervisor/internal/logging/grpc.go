@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor is Middleware for gRPC calls. The correlation ID is
+// read from and returned in the x-request-id metadata, and one line is logged
+// per call with its method, status code and latency. Panics are logged and
+// answered with Internal instead of taking the process down.
+func UnaryServerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	key := strings.ToLower(RequestIDHeader)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		start := time.Now()
+
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(key); len(values) > 0 {
+				id = values[0]
+			}
+		}
+		if id == "" {
+			id = uuid.NewString()
+		}
+		grpc.SetHeader(ctx, metadata.Pairs(key, id))
+		ctx = WithRequestID(ctx, id)
+
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorContext(ctx, "Recovered from panic in gRPC handler",
+					"request_id", id, "method", info.FullMethod, "panic", r)
+				err = status.Error(codes.Internal, "Internal error")
+			}
+
+			code := status.Code(err)
+			level := slog.LevelInfo
+			switch code {
+			case codes.Internal, codes.Unknown, codes.DataLoss:
+				level = slog.LevelError
+			}
+
+			attrs := []slog.Attr{
+				slog.String("request_id", id),
+				slog.String("method", info.FullMethod),
+				slog.String("code", code.String()),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			}
+			if ip := PeerIP(ctx); ip != "" {
+				attrs = append(attrs, slog.String("client_ip", ip))
+			}
+			logger.LogAttrs(ctx, level, "grpc request", attrs...)
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// PeerIP returns the address of the gRPC caller without its port, or "" when
+// ctx carries no peer.
+func PeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
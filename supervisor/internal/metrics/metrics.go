@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "arx_supervisor"
+
+var (
+	// RoutedRequests counts requests that were successfully routed to a node.
+	RoutedRequests = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "routed_requests_total",
+		Help:      "Total number of requests routed to a node.",
+	})
+
+	// RoutingFailures counts requests that could not be routed, by reason.
+	RoutingFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "routing_failures_total",
+		Help:      "Total number of requests that could not be routed.",
+	}, []string{"reason"})
+
+	// ReportedFailures counts failures clients reported against the node a
+	// request was routed to.
+	ReportedFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reported_failures_total",
+		Help:      "Total number of node failures reported by clients.",
+	})
+
+	// RouteResponses counts responses from the route endpoint by status code.
+	RouteResponses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "route_responses_total",
+		Help:      "Total number of route endpoint responses by HTTP status code.",
+	}, []string{"code"})
+
+	// RoutingLatency observes how long it takes to select a node.
+	RoutingLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "routing_latency_seconds",
+		Help:      "Time taken to select a node for a request.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// NodeCacheLookups counts routing reads of the healthy node cache by
+	// result; the hit ratio is hits over the total.
+	NodeCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "node_cache_lookups_total",
+		Help:      "Total number of healthy node cache lookups by result.",
+	}, []string{"result"})
+
+	// HealthChecks counts node health probes by result.
+	HealthChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "health_checks_total",
+		Help:      "Total number of node health checks by result.",
+	}, []string{"result"})
+
+	// WebSocketDroppedMessages counts broadcasts dropped because the hub's
+	// queue was full, whether the new or the oldest queued broadcast.
+	WebSocketDroppedMessages = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_dropped_messages_total",
+		Help:      "Total number of WebSocket broadcasts dropped because the hub was busy.",
+	})
+
+	// WebSocketQueueDepth is how many broadcasts are waiting for the hub.
+	WebSocketQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_broadcast_queue_depth",
+		Help:      "Number of WebSocket broadcasts waiting for the hub.",
+	})
+
+	// WebSocketSlowClients counts realtime clients disconnected because they
+	// fell too far behind to take another message.
+	WebSocketSlowClients = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_slow_client_disconnects_total",
+		Help:      "Total number of WebSocket clients disconnected for falling behind.",
+	})
+
+	// NodesTotal is the number of registered nodes seen by the last health check.
+	NodesTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "nodes_total",
+		Help:      "Number of registered nodes.",
+	})
+
+	// NodesHealthy is the number of nodes healthy after the last health check.
+	NodesHealthy = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "nodes_healthy",
+		Help:      "Number of healthy nodes.",
+	})
+)
+
+// Routing failure reasons
+const (
+	ReasonError   = "error"
+	ReasonNoNodes = "no_nodes"
+	ReasonTimeout = "timeout"
+)
+
+// Node cache lookup results
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// Health check results
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Register adds all supervisor collectors to the given registerer.
+func Register(reg prometheus.Registerer) {
+	reg.MustRegister(
+		RoutedRequests,
+		RoutingFailures,
+		ReportedFailures,
+		RouteResponses,
+		RoutingLatency,
+		NodeCacheLookups,
+		HealthChecks,
+		WebSocketDroppedMessages,
+		WebSocketQueueDepth,
+		WebSocketSlowClients,
+		NodesTotal,
+		NodesHealthy,
+	)
+}
+
+// Handler serves the default Prometheus registry in text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
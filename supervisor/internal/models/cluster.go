@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cluster groups nodes into a routing domain. Requests naming a cluster are
+// only routed to its nodes.
+type Cluster struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	// NodeCount is the number of nodes in the cluster, soft-deleted ones
+	// excluded
+	NodeCount int64     `json:"node_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
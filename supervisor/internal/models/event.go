@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NodeEvent is one change in a node's lifecycle. Before and After are the
+// node as stored around the change; Before is nil for creations and After
+// for hard deletes.
+type NodeEvent struct {
+	ID     uuid.UUID `json:"id"`
+	NodeID uuid.UUID `json:"node_id"`
+	// EventType matches the type of the WebSocket message broadcast for it,
+	// such as node_created or node_status_changed
+	EventType string    `json:"event_type"`
+	Actor     string    `json:"actor"`
+	Before    *Node     `json:"before"`
+	After     *Node     `json:"after"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// JSONBString holds the raw JSON text of a JSONB column. Use a *JSONBString
+// for nullable columns: a nil pointer reads and writes SQL NULL rather than
+// the JSON literal null.
+type JSONBString string
+
+// Scan implements sql.Scanner.
+func (j *JSONBString) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*j = JSONBString(v)
+	case []byte:
+		*j = JSONBString(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSONBString", value)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer, rejecting text that is not valid JSON so
+// it fails here instead of in the database.
+func (j JSONBString) Value() (driver.Value, error) {
+	if !json.Valid([]byte(j)) {
+		return nil, errors.New("JSONBString is not valid JSON")
+	}
+	return string(j), nil
+}
+
+// Bytes returns the JSON text, or nil for a nil receiver so it maps to NULL
+// in sqlc parameters.
+func (j *JSONBString) Bytes() []byte {
+	if j == nil {
+		return nil
+	}
+	return []byte(*j)
+}
+
+// NewJSONBString marshals v into a JSONBString.
+func NewJSONBString(v interface{}) (*JSONBString, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	j := JSONBString(data)
+	return &j, nil
+}
+
+// scanJSONB scans a nullable JSONB value into dst, leaving it nil for NULL.
+func scanJSONB(dst **JSONBString, value interface{}) error {
+	if value == nil {
+		*dst = nil
+		return nil
+	}
+	if b, ok := value.([]byte); ok && b == nil {
+		*dst = nil
+		return nil
+	}
+
+	var j JSONBString
+	if err := j.Scan(value); err != nil {
+		return err
+	}
+	*dst = &j
+	return nil
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONBStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+	}{
+		{"object", map[string]interface{}{"client_id": "c-1", "coordinates": map[string]interface{}{"x": 1.5, "y": -2.0}}},
+		{"array", []interface{}{"a", 1.0, true}},
+		{"string", "plain"},
+		{"empty object", map[string]interface{}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := NewJSONBString(tt.in)
+			if err != nil {
+				t.Fatalf("NewJSONBString: %v", err)
+			}
+			value, err := driver.DefaultParameterConverter.ConvertValue(j)
+			if err != nil {
+				t.Fatalf("Value: %v", err)
+			}
+
+			// pgx hands JSONB back as bytes, database/sql drivers as either
+			for _, scanned := range []interface{}{value, []byte(value.(string))} {
+				var r RoutingRequest
+				if err := r.ScanRequestData(scanned); err != nil {
+					t.Fatalf("Scan(%T): %v", scanned, err)
+				}
+				if r.RequestData == nil {
+					t.Fatalf("Scan(%T) left the field nil", scanned)
+				}
+
+				var out interface{}
+				if err := json.Unmarshal(r.RequestData.Bytes(), &out); err != nil {
+					t.Fatalf("scanned text is not JSON: %v", err)
+				}
+				if !reflect.DeepEqual(out, tt.in) {
+					t.Errorf("round trip = %#v, want %#v", out, tt.in)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONBStringNull(t *testing.T) {
+	var nilJSON *JSONBString
+	value, err := driver.DefaultParameterConverter.ConvertValue(nilJSON)
+	if err != nil {
+		t.Fatalf("Value: %v", err)
+	}
+	if value != nil {
+		t.Errorf("nil pointer writes %#v, want SQL NULL", value)
+	}
+	if b := nilJSON.Bytes(); b != nil {
+		t.Errorf("nil pointer Bytes() = %q, want nil", b)
+	}
+
+	for _, null := range []interface{}{nil, []byte(nil)} {
+		r := RoutingRequest{Metadata: new(JSONBString)}
+		if err := r.ScanMetadata(null); err != nil {
+			t.Fatalf("Scan(%#v): %v", null, err)
+		}
+		if r.Metadata != nil {
+			t.Errorf("Scan(%#v) = %q, want nil", null, *r.Metadata)
+		}
+	}
+}
+
+func TestJSONBStringMalformed(t *testing.T) {
+	for _, text := range []string{"", "{", `{"a":}`, "nul"} {
+		if _, err := JSONBString(text).Value(); err == nil {
+			t.Errorf("Value(%q) accepted malformed JSON", text)
+		}
+	}
+
+	for _, value := range []interface{}{42, 1.5, true} {
+		var r RoutingRequest
+		if err := r.ScanClientInfo(value); err == nil {
+			t.Errorf("Scan(%T) accepted a non-text value", value)
+		}
+		if r.ClientInfo != nil {
+			t.Errorf("Scan(%T) set the field after failing", value)
+		}
+	}
+
+	if _, err := NewJSONBString(func() {}); err == nil {
+		t.Error("NewJSONBString accepted a value that cannot be marshalled")
+	}
+}
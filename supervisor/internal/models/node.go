@@ -1,43 +1,151 @@
 package models
 
 import (
-	"fmt"
+	"encoding/json"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	NodeStatusActive    = "active"
+	NodeStatusInactive  = "inactive"
+	NodeStatusHealthy   = "healthy"
+	NodeStatusUnhealthy = "unhealthy"
+	NodeStatusDegraded  = "degraded"
+	// NodeStatusDraining nodes are listed but receive no new traffic
+	NodeStatusDraining = "draining"
+	// NodeStatusDeleted nodes are soft-deleted: hidden from listings and
+	// routing but kept so their routing history still resolves. It cannot be
+	// set through the API, so IsValidNodeStatus rejects it.
+	NodeStatusDeleted = "deleted"
+)
+
+// Routing request statuses
+const (
+	RoutingStatusRouted = "routed"
+	RoutingStatusFailed = "failed"
+)
+
+// Health check protocols. HTTP and HTTPS fetch the node's health path; TCP
+// only checks that the endpoint's host and port accept a connection.
+const (
+	HealthProtocolHTTP  = "http"
+	HealthProtocolHTTPS = "https"
+	HealthProtocolTCP   = "tcp"
+
+	DefaultHealthPath = "/health"
+)
+
+// DefaultHealthProtocol returns the protocol used to probe a node registered
+// without one: https for https endpoints and http otherwise.
+func DefaultHealthProtocol(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Scheme == "https" {
+		return HealthProtocolHTTPS
+	}
+	return HealthProtocolHTTP
+}
+
+// IsValidNodeStatus reports whether status is one of the known node statuses.
+func IsValidNodeStatus(status string) bool {
+	switch status {
+	case NodeStatusActive, NodeStatusInactive, NodeStatusHealthy, NodeStatusUnhealthy, NodeStatusDegraded, NodeStatusDraining:
+		return true
+	}
+	return false
+}
+
 type Node struct {
-	ID                uuid.UUID  `json:"id"`
-	Name              string     `json:"name"`
-	LocationX         float64    `json:"location_x"`
-	LocationY         float64    `json:"location_y"`
-	Endpoint          string     `json:"endpoint"`
-	Capacity          int        `json:"capacity"`
-	Status            string     `json:"status"`
+	ID             uuid.UUID `json:"id"`
+	Name           string    `json:"name"`
+	LocationX      float64   `json:"location_x"`
+	LocationY      float64   `json:"location_y"`
+	Endpoint       string    `json:"endpoint"`
+	Capacity       int       `json:"capacity"`
+	Weight         int       `json:"weight"`
+	Zone           string    `json:"zone"`
+	HealthPath     string    `json:"health_path"`
+	HealthProtocol string    `json:"health_protocol"`
+	Status         string    `json:"status"`
+	// Labels are free-form tags such as gpu=true that requests can require
+	Labels map[string]string `json:"labels"`
+	// ClusterID is the cluster the node belongs to, if any
+	ClusterID *uuid.UUID `json:"cluster_id"`
+	// Maintenance nodes are health-checked and listed but not routed to
+	Maintenance       bool       `json:"maintenance"`
 	CPUUsage          float64    `json:"cpu_usage"`
 	MemoryUsage       float64    `json:"memory_usage"`
 	ActiveConnections int        `json:"active_connections"`
 	LastHealthCheck   *time.Time `json:"last_health_check"`
+	LastHeartbeat     *time.Time `json:"last_heartbeat,omitempty"`
+	DrainingSince     *time.Time `json:"draining_since,omitempty"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"`
 	CreatedAt         time.Time  `json:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at"`
 }
 
+// HasLabels reports whether the node carries every one of the required
+// labels with the same value.
+func (n Node) HasLabels(required map[string]string) bool {
+	for key, value := range required {
+		if got, ok := n.Labels[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// InCluster reports whether the node belongs to cluster. Every node is in
+// the nil cluster.
+func (n Node) InCluster(cluster uuid.UUID) bool {
+	return cluster == uuid.Nil || (n.ClusterID != nil && *n.ClusterID == cluster)
+}
+
+// EncodeLabels returns labels as the JSON object stored in the labels
+// column, writing {} rather than null when there are none.
+func EncodeLabels(labels map[string]string) []byte {
+	if len(labels) == 0 {
+		return []byte("{}")
+	}
+	data, _ := json.Marshal(labels)
+	return data
+}
+
+// DecodeLabels parses the labels column, returning an empty map for missing
+// or malformed JSON so listings always show an object.
+func DecodeLabels(data []byte) map[string]string {
+	labels := map[string]string{}
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &labels)
+	}
+	return labels
+}
+
 type RoutingRequest struct {
-	ID                uuid.UUID  `json:"id"`
-	RequestID         string     `json:"request_id"`
-	CoordinatesX      float64    `json:"coordinates_x"`
-	CoordinatesY      float64    `json:"coordinates_y"`
-	SelectedNodeID    *uuid.UUID `json:"selected_node_id"`
-	Distance          *float64   `json:"distance"`
-	LoadScore         *float64   `json:"load_score"`
-	Status            string     `json:"status"`
-	ResponseTimeMs    *int       `json:"response_time_ms"`
-	RequestData       *string    `json:"request_data"`       // Full request payload
-	ResponseData      *string    `json:"response_data"`      // Response data
-	Metadata          *string    `json:"metadata"`           // Request metadata
-	ClientInfo        *string    `json:"client_info"`        // Client identification
-	ProcessingMetrics *string    `json:"processing_metrics"` // Detailed metrics
+	ID                uuid.UUID    `json:"id"`
+	RequestID         string       `json:"request_id"`
+	CoordinatesX      float64      `json:"coordinates_x"`
+	CoordinatesY      float64      `json:"coordinates_y"`
+	SelectedNodeID    *uuid.UUID   `json:"selected_node_id"`
+	Distance          *float64     `json:"distance"`
+	LoadScore         *float64     `json:"load_score"`
+	Status            string       `json:"status"`
+	ResponseTimeMs    *int         `json:"response_time_ms"`
+	RequestData       *JSONBString `json:"request_data"`       // Full request payload
+	ResponseData      *JSONBString `json:"response_data"`      // Response data
+	Metadata          *JSONBString `json:"metadata"`           // Request metadata
+	ClientInfo        *JSONBString `json:"client_info"`        // Client identification
+	ProcessingMetrics *JSONBString `json:"processing_metrics"` // Detailed metrics
+	// FallbackNodeIDs are the fallbacks returned with the selected node
+	FallbackNodeIDs []uuid.UUID `json:"fallback_node_ids,omitempty"`
+	// FailedNodeID is the node a client reported as failing the request
+	FailedNodeID      *uuid.UUID `json:"failed_node_id,omitempty"`
+	FailureReason     string     `json:"failure_reason,omitempty"`
+	FailureReportedAt *time.Time `json:"failure_reported_at,omitempty"`
 	CreatedAt         time.Time  `json:"created_at"`
 }
 
@@ -54,98 +162,98 @@ type Location struct {
 	Y float64 `json:"y" binding:"required"`
 }
 
-// Helper functions for working with JSONB
-func (r *RoutingRequest) ScanRequestData(value interface{}) error {
-	if value == nil {
-		r.RequestData = nil
-		return nil
-	}
+// FieldError reports an invalid value for a named field.
+type FieldError struct {
+	Field   string
+	Message string
+}
 
-	switch v := value.(type) {
-	case string:
-		r.RequestData = &v
-		return nil
-	case []byte:
-		s := string(v)
-		r.RequestData = &s
-		return nil
-	default:
-		return fmt.Errorf("cannot scan %T into string", value)
-	}
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Message
 }
 
-func (r *RoutingRequest) ScanResponseData(value interface{}) error {
-	if value == nil {
-		r.ResponseData = nil
-		return nil
+// Validate rejects NaN and infinite coordinates. When geographic is set, X is
+// a longitude in [-180, 180] and Y a latitude in [-90, 90].
+func (l Location) Validate(geographic bool) error {
+	if math.IsNaN(l.X) || math.IsInf(l.X, 0) {
+		return &FieldError{Field: "x", Message: "must be a finite number"}
+	}
+	if math.IsNaN(l.Y) || math.IsInf(l.Y, 0) {
+		return &FieldError{Field: "y", Message: "must be a finite number"}
 	}
 
-	switch v := value.(type) {
-	case string:
-		r.ResponseData = &v
-		return nil
-	case []byte:
-		s := string(v)
-		r.ResponseData = &s
-		return nil
-	default:
-		return fmt.Errorf("cannot scan %T into string", value)
+	if geographic {
+		if l.X < -180 || l.X > 180 {
+			return &FieldError{Field: "x", Message: "must be a longitude between -180 and 180"}
+		}
+		if l.Y < -90 || l.Y > 90 {
+			return &FieldError{Field: "y", Message: "must be a latitude between -90 and 90"}
+		}
 	}
+	return nil
 }
 
-func (r *RoutingRequest) ScanMetadata(value interface{}) error {
-	if value == nil {
-		r.Metadata = nil
-		return nil
+// NormalizeEndpoint returns the canonical form of a node endpoint, so the
+// same node is not registered twice under different spellings and health
+// check URLs can be built by appending a path. Endpoints without a scheme
+// get http://, the scheme and host are lowercased and trailing slashes are
+// dropped: "X:80/", "http://x:80" and "HTTP://x:80/" all become
+// "http://x:80". Endpoints that are not http or https URLs with a host, that
+// give an IPv6 address without brackets, or that carry credentials, a query
+// or a fragment, are rejected.
+func NormalizeEndpoint(endpoint string) (string, error) {
+	invalid := &FieldError{Field: "endpoint", Message: "must be an http or https URL with a host"}
+
+	endpoint = strings.TrimSpace(endpoint)
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", invalid
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return "", invalid
+	}
+	// An IPv6 address needs brackets to be told apart from its port
+	if strings.Contains(u.Hostname(), ":") && !strings.HasPrefix(u.Host, "[") {
+		return "", &FieldError{Field: "endpoint", Message: "must enclose an IPv6 address in brackets"}
+	}
+	if u.User != nil || u.RawQuery != "" || u.ForceQuery || u.Fragment != "" {
+		return "", &FieldError{Field: "endpoint", Message: "must not contain credentials, a query or a fragment"}
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "", &FieldError{Field: "endpoint", Message: "must have a port between 1 and 65535"}
+		}
 	}
 
-	switch v := value.(type) {
-	case string:
-		r.Metadata = &v
-		return nil
-	case []byte:
-		s := string(v)
-		r.Metadata = &s
-		return nil
-	default:
-		return fmt.Errorf("cannot scan %T into string", value)
+	normalized := url.URL{
+		Scheme: u.Scheme,
+		Host:   strings.ToLower(u.Host),
+		Path:   strings.TrimRight(u.Path, "/"),
 	}
+	return normalized.String(), nil
 }
 
-func (r *RoutingRequest) ScanClientInfo(value interface{}) error {
-	if value == nil {
-		r.ClientInfo = nil
-		return nil
-	}
+// Helper functions for working with JSONB
+func (r *RoutingRequest) ScanRequestData(value interface{}) error {
+	return scanJSONB(&r.RequestData, value)
+}
 
-	switch v := value.(type) {
-	case string:
-		r.ClientInfo = &v
-		return nil
-	case []byte:
-		s := string(v)
-		r.ClientInfo = &s
-		return nil
-	default:
-		return fmt.Errorf("cannot scan %T into string", value)
-	}
+func (r *RoutingRequest) ScanResponseData(value interface{}) error {
+	return scanJSONB(&r.ResponseData, value)
 }
 
-func (r *RoutingRequest) ScanProcessingMetrics(value interface{}) error {
-	if value == nil {
-		r.ProcessingMetrics = nil
-		return nil
-	}
+func (r *RoutingRequest) ScanMetadata(value interface{}) error {
+	return scanJSONB(&r.Metadata, value)
+}
 
-	switch v := value.(type) {
-	case string:
-		r.ProcessingMetrics = &v
-		return nil
-	case []byte:
-		s := string(v)
-		r.ProcessingMetrics = &s
-		return nil
-	default:
-		return fmt.Errorf("cannot scan %T into string", value)
-	}
+func (r *RoutingRequest) ScanClientInfo(value interface{}) error {
+	return scanJSONB(&r.ClientInfo, value)
+}
+
+func (r *RoutingRequest) ScanProcessingMetrics(value interface{}) error {
+	return scanJSONB(&r.ProcessingMetrics, value)
 }
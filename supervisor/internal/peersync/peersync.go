@@ -0,0 +1,183 @@
+// Package peersync keeps several supervisors sharing one database in step.
+// Node changes are announced with NOTIFY on the node_events channel; peers
+// LISTEN, drop their cached node state and re-broadcast the change to their
+// own WebSocket clients.
+package peersync
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"arx-supervisor/internal/routing"
+	"arx-supervisor/internal/websocket"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Channel is the Postgres notification channel node events travel on.
+const Channel = "node_events"
+
+// maxPayload keeps notifications below the 8000 byte limit of NOTIFY. Larger
+// events are sent without their data, so peers only invalidate.
+const maxPayload = 7900
+
+// outboxSize bounds the events waiting to be sent; more are dropped.
+const outboxSize = 256
+
+// maxReconnectDelay caps the backoff between listener reconnects.
+const maxReconnectDelay = 30 * time.Second
+
+// relayed are the broadcasts that change node state. Periodic health stats
+// are left out since every supervisor checks the nodes itself.
+var relayed = map[string]bool{
+	"node_created":             true,
+	"node_updated":             true,
+	"node_deleted":             true,
+	"node_draining":            true,
+	"nodes_bulk_created":       true,
+	"node_registered":          true,
+	"node_deregistered":        true,
+	"node_maintenance_changed": true,
+	"node_status_changed":      true,
+}
+
+// event is the NOTIFY payload. Origin identifies the sending supervisor so
+// it can skip its own events.
+type event struct {
+	Origin string          `json:"origin"`
+	Type   string          `json:"type"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+type Syncer struct {
+	pool   *pgxpool.Pool
+	router *routing.Service
+	hub    *websocket.Hub
+	logger *slog.Logger
+
+	origin string
+	outbox chan event
+}
+
+func New(pool *pgxpool.Pool, router *routing.Service, hub *websocket.Hub, logger *slog.Logger) *Syncer {
+	return &Syncer{
+		pool:   pool,
+		router: router,
+		hub:    hub,
+		logger: logger,
+		origin: uuid.NewString(),
+		outbox: make(chan event, outboxSize),
+	}
+}
+
+// Relay queues a local broadcast for peers if it changes node state. It is
+// meant for Hub.SetRelay and never blocks.
+func (s *Syncer) Relay(message websocket.Message) {
+	if !relayed[message.Type] {
+		return
+	}
+
+	ev := event{Origin: s.origin, Type: message.Type}
+	if data, err := json.Marshal(message.Data); err == nil && len(data) <= maxPayload {
+		ev.Data = data
+	}
+
+	select {
+	case s.outbox <- ev:
+	default:
+		s.logger.Warn("Dropping node event for peers, outbox is full", "type", message.Type)
+	}
+}
+
+// Run sends queued events and listens for those of peers until ctx is
+// cancelled, reconnecting the listener with backoff when it drops.
+func (s *Syncer) Run(ctx context.Context) {
+	go s.notify(ctx)
+
+	delay := time.Second
+	for {
+		connected, err := s.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = time.Second
+		}
+
+		s.logger.Warn("Node event listener disconnected, reconnecting",
+			"error", err, "retry_in", delay.String())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func (s *Syncer) notify(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-s.outbox:
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if len(payload) > maxPayload {
+				ev.Data = nil
+				payload, _ = json.Marshal(ev)
+			}
+			if _, err := s.pool.Exec(ctx, "SELECT pg_notify($1, $2)", Channel, string(payload)); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to notify peers of node event", "type", ev.Type, "error", err)
+			}
+		}
+	}
+}
+
+// listen holds a dedicated connection on the channel until it fails. It
+// reports whether LISTEN succeeded so Run can reset its backoff.
+func (s *Syncer) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.ConnectConfig(ctx, s.pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return false, err
+	}
+	s.logger.Info("Listening for node events from peers", "channel", Channel)
+
+	// Events sent while the listener was down are lost, so start over from
+	// the database
+	s.router.InvalidateIndex()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		s.handle(notification.Payload)
+	}
+}
+
+func (s *Syncer) handle(payload string) {
+	var ev event
+	if err := json.Unmarshal([]byte(payload), &ev); err != nil {
+		s.logger.Warn("Ignoring malformed node event", "error", err)
+		return
+	}
+	if ev.Origin == s.origin {
+		return
+	}
+
+	s.router.InvalidateIndex()
+	if ev.Data != nil {
+		s.hub.PublishRemote(websocket.Message{Type: ev.Type, Data: ev.Data})
+	}
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/logging"
+	"arx-supervisor/internal/state"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Limiter is a per-key token bucket rate limiter. Buckets live in a state
+// backend, so replicas sharing a Redis backend enforce one limit together.
+type Limiter struct {
+	backend state.Backend
+	limits  atomic.Pointer[limits]
+	logger  *slog.Logger
+}
+
+// limits are the rate and burst in effect. An rps of zero or less allows
+// every request.
+type limits struct {
+	rps   float64
+	burst int
+}
+
+func New(backend state.Backend, rps float64, burst int, logger *slog.Logger) *Limiter {
+	l := &Limiter{backend: backend, logger: logger}
+	l.SetLimits(rps, burst)
+	return l
+}
+
+// SetLimits changes the rate and burst for subsequent requests; zero or less
+// rps turns limiting off. Existing buckets keep their tokens.
+func (l *Limiter) SetLimits(rps float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+	l.limits.Store(&limits{rps: rps, burst: burst})
+}
+
+// Allow takes a token from the key's bucket. When the bucket is empty it
+// returns false and how long until a token is available. Requests are
+// allowed while the backend cannot be reached, so an outage of shared state
+// does not take routing down with it.
+func (l *Limiter) Allow(ctx context.Context, key string) (bool, time.Duration) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN is Allow for n requests made at once, such as the items of a batch.
+// It takes all n tokens as soon as the bucket holds one, so a batch larger
+// than the burst still passes but leaves the client waiting for the bucket
+// to refill.
+func (l *Limiter) AllowN(ctx context.Context, key string, n int) (bool, time.Duration) {
+	current := l.limits.Load()
+	if current.rps <= 0 {
+		return true, 0
+	}
+	ok, retryAfter, err := l.backend.Take(ctx, key, n, current.rps, current.burst)
+	if err != nil {
+		l.logger.WarnContext(ctx, "Rate limit state unavailable, allowing request", "error", err)
+		return true, 0
+	}
+	return ok, retryAfter
+}
+
+// Middleware rejects requests over the limit with 429 and a Retry-After
+// header. Clients are keyed by ClientKey.
+func Middleware(l *Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, retryAfter := l.Allow(c.Request.Context(), ClientKey(c)); !ok {
+			Abort(c, retryAfter)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ClientKey identifies the client of an HTTP request by its X-Client-ID
+// header when present, otherwise by IP address.
+func ClientKey(c *gin.Context) string {
+	if key := c.GetHeader("X-Client-ID"); key != "" {
+		return key
+	}
+	return c.ClientIP()
+}
+
+// Abort rejects a request over the limit with 429 and a Retry-After header.
+func Abort(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", retrySeconds(retryAfter))
+	apierror.Abort(c, http.StatusTooManyRequests, apierror.CodeRateLimited, "Rate limit exceeded")
+}
+
+// UnaryServerInterceptor applies the same limit to the given gRPC methods,
+// so switching protocols does not get around it. Clients are keyed by the
+// x-client-id metadata when present, otherwise by peer address. Calls over
+// the limit fail with ResourceExhausted and a retry-after header.
+func UnaryServerInterceptor(l *Limiter, methods ...string) grpc.UnaryServerInterceptor {
+	limited := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		limited[method] = true
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !limited[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		var key string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("x-client-id"); len(values) > 0 {
+				key = values[0]
+			}
+		}
+		if key == "" {
+			key = logging.PeerIP(ctx)
+		}
+
+		if ok, retryAfter := l.Allow(ctx, key); !ok {
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retrySeconds(retryAfter)))
+			return nil, apierror.Error{Code: apierror.CodeRateLimited, Message: "Rate limit exceeded"}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// retrySeconds formats a retry delay as whole seconds, rounded up.
+func retrySeconds(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+}
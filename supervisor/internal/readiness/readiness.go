@@ -0,0 +1,130 @@
+// Package readiness tracks whether the supervisor should take new traffic.
+// It flips to draining when shutdown starts so load balancers stop sending
+// requests while in-flight ones finish, and reports dependencies that are
+// down without failing liveness.
+package readiness
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"arx-supervisor/internal/apierror"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+// Statuses reported by /livez and /readyz
+const (
+	StatusAlive    = "alive"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusDraining = "draining"
+)
+
+// checkTimeout bounds each dependency check run by /readyz.
+const checkTimeout = 2 * time.Second
+
+// Check returns an error describing why a dependency is not ready.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// State is the readiness flag plus the dependency checks behind /readyz.
+// The zero value is ready and has no checks.
+type State struct {
+	draining atomic.Bool
+	checks   []namedCheck
+}
+
+// Response is the body of /readyz. Checks maps every dependency to "ok" or
+// the reason it failed, and Failed lists the failing ones.
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+	Failed []string          `json:"failed,omitempty"`
+}
+
+func New() *State {
+	return &State{}
+}
+
+// AddCheck registers a dependency that must pass for /readyz to report
+// ready. Register every check before serving requests.
+func (s *State) AddCheck(name string, check Check) {
+	s.checks = append(s.checks, namedCheck{name: name, check: check})
+}
+
+// Drain marks the supervisor as shutting down. It cannot be undone.
+func (s *State) Drain() {
+	s.draining.Store(true)
+}
+
+// Ready reports whether new requests should be accepted.
+func (s *State) Ready() bool {
+	return !s.draining.Load()
+}
+
+// Middleware rejects requests with 503 once draining has started. Requests
+// already past it run to completion.
+func (s *State) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.Ready() {
+			// Ask keep-alive clients to reconnect, likely to another instance
+			c.Header("Connection", "close")
+			apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeShuttingDown, "Server is shutting down")
+			return
+		}
+		c.Next()
+	}
+}
+
+// UnaryServerInterceptor is Middleware for gRPC calls, failing them with
+// Unavailable once draining has started.
+func (s *State) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !s.Ready() {
+			return nil, apierror.Error{Code: apierror.CodeShuttingDown, Message: "Server is shutting down"}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Handler serves /readyz: 200 when every dependency check passes, and 503
+// naming the failed dependencies otherwise or once draining.
+func (s *State) Handler(c *gin.Context) {
+	if !s.Ready() {
+		c.JSON(http.StatusServiceUnavailable, Response{Status: StatusDraining})
+		return
+	}
+
+	resp := Response{Status: StatusReady, Checks: make(map[string]string, len(s.checks))}
+	for _, nc := range s.checks {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+		err := nc.check(ctx)
+		cancel()
+		if err != nil {
+			resp.Checks[nc.name] = err.Error()
+			resp.Failed = append(resp.Failed, nc.name)
+			continue
+		}
+		resp.Checks[nc.name] = "ok"
+	}
+
+	if len(resp.Failed) > 0 {
+		resp.Status = StatusNotReady
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// LiveHandler serves /livez. It answers 200 whenever the process can serve
+// HTTP, so dependency outages never get the process restarted.
+func LiveHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, Response{Status: StatusAlive})
+}
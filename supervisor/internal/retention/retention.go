@@ -0,0 +1,136 @@
+// Package retention prunes routing requests and system metrics older than
+// the configured retention period.
+package retention
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrPruneInProgress is returned by Prune while another prune is running.
+var ErrPruneInProgress = errors.New("prune already in progress")
+
+// Result reports the rows removed by one prune.
+type Result struct {
+	Cutoff          time.Time `json:"cutoff"`
+	RoutingRequests int64     `json:"routing_requests"`
+	SystemMetrics   int64     `json:"system_metrics"`
+}
+
+// Pruner deletes old rows in batches so no single statement holds locks on
+// a large part of a table.
+type Pruner struct {
+	db        *database.Database
+	days      int
+	interval  time.Duration
+	batchSize int32
+	logger    *slog.Logger
+
+	// running serialises scheduled and manual prunes
+	running sync.Mutex
+}
+
+func New(database *database.Database, cfg config.RetentionConfig, logger *slog.Logger) *Pruner {
+	batchSize := cfg.BatchSize
+	if batchSize < 1 {
+		batchSize = 1000
+	}
+
+	return &Pruner{
+		db:        database,
+		days:      cfg.Days,
+		interval:  time.Duration(cfg.Interval) * time.Second,
+		batchSize: int32(batchSize),
+		logger:    logger,
+	}
+}
+
+// Days is the configured retention period; zero means rows are kept forever.
+func (p *Pruner) Days() int {
+	return p.days
+}
+
+// Run prunes on every interval until ctx is cancelled. It returns
+// immediately when retention is disabled.
+func (p *Pruner) Run(ctx context.Context) {
+	if p.days <= 0 || p.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.Prune(ctx, p.days); err != nil && !errors.Is(err, ErrPruneInProgress) && ctx.Err() == nil {
+				p.logger.Error("Failed to prune old rows", "error", err)
+			}
+		}
+	}
+}
+
+// Prune deletes routing requests and system metrics older than days. It
+// returns ErrPruneInProgress if another prune is running, and stops between
+// batches when ctx is cancelled, reporting what was deleted so far.
+func (p *Pruner) Prune(ctx context.Context, days int) (Result, error) {
+	if !p.running.TryLock() {
+		return Result{}, ErrPruneInProgress
+	}
+	defer p.running.Unlock()
+
+	result := Result{Cutoff: time.Now().UTC().AddDate(0, 0, -days)}
+	cutoff := pgtype.Timestamp{Time: result.Cutoff, Valid: true}
+
+	var err error
+	result.RoutingRequests, err = p.deleteInBatches(ctx, func() (int64, error) {
+		return p.db.Queries.DeleteRoutingRequestsBefore(ctx, db.DeleteRoutingRequestsBeforeParams{
+			CreatedAt: cutoff,
+			Limit:     p.batchSize,
+		})
+	})
+	if err == nil {
+		result.SystemMetrics, err = p.deleteInBatches(ctx, func() (int64, error) {
+			return p.db.Queries.DeleteSystemMetricsBefore(ctx, db.DeleteSystemMetricsBeforeParams{
+				Timestamp: cutoff,
+				Limit:     p.batchSize,
+			})
+		})
+	}
+
+	p.logger.Info("Pruned old rows",
+		"cutoff", result.Cutoff,
+		"routing_requests", result.RoutingRequests,
+		"system_metrics", result.SystemMetrics)
+	return result, err
+}
+
+// deleteInBatches runs deleteBatch until it removes fewer rows than a full
+// batch, returning the total removed.
+func (p *Pruner) deleteInBatches(ctx context.Context, deleteBatch func() (int64, error)) (int64, error) {
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		deleted, err := deleteBatch()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < int64(p.batchSize) {
+			return total, nil
+		}
+	}
+}
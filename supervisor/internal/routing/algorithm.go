@@ -1,31 +1,175 @@
 package routing
 
 import (
+	"hash/fnv"
 	"math"
 	"sort"
+	"time"
 
+	"arx-supervisor/internal/config"
 	"arx-supervisor/internal/models"
+	"github.com/google/uuid"
 )
 
+const (
+	DistanceModeEuclidean = "euclidean"
+	DistanceModeHaversine = "haversine"
+	// DistanceModeProjected measures longitude/latitude in kilometers on a
+	// local equirectangular projection; see ProjectLocal.
+	DistanceModeProjected = "projected"
+
+	earthRadiusKm = 6371.0
+
+	// DefaultTieEpsilon is the score difference below which SelectBestNode
+	// treats nodes as equally loaded.
+	DefaultTieEpsilon = 1e-3
+)
+
+// Units of the distances reported by each mode
+const (
+	DistanceUnitUnits = "units"
+	DistanceUnitKm    = "km"
+)
+
+// DistanceFunc computes the distance between two points given as X/Y
+// coordinates. In haversine mode X is the longitude and Y the latitude.
+type DistanceFunc func(x1, y1, x2, y2 float64) float64
+
 func CalculateDistance(x1, y1, x2, y2 float64) float64 {
 	return math.Sqrt(math.Pow(x1-x2, 2) + math.Pow(y1-y2, 2))
 }
 
+// CalculateHaversineDistance returns the great-circle distance in kilometers
+// between two points given in degrees.
+func CalculateHaversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	if lat1 == lat2 && lon1 == lon2 {
+		return 0
+	}
+
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	// Guard against floating point drift pushing a outside [0, 1]
+	a = math.Min(1, math.Max(0, a))
+
+	return 2 * earthRadiusKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+func haversineXY(x1, y1, x2, y2 float64) float64 {
+	return CalculateHaversineDistance(y1, x1, y2, x2)
+}
+
+// DistanceFuncFor returns the distance function for the given mode, falling
+// back to Euclidean distance for unknown modes. Projected distances are taken
+// about the equator; use distanceFor to honour the configured latitude.
+func DistanceFuncFor(mode string) DistanceFunc {
+	switch mode {
+	case DistanceModeHaversine:
+		return haversineXY
+	case DistanceModeProjected:
+		return projectedDistance(0)
+	}
+	return CalculateDistance
+}
+
+// distanceFor returns the distance function of the configured mode.
+func distanceFor(cfg config.RoutingConfig) DistanceFunc {
+	if cfg.DistanceMode == DistanceModeProjected {
+		return projectedDistance(cfg.ProjectionLatitude)
+	}
+	return DistanceFuncFor(cfg.DistanceMode)
+}
+
+// DistanceUnitFor returns the unit of distances computed in the given mode.
+func DistanceUnitFor(mode string) string {
+	if mode == DistanceModeHaversine || mode == DistanceModeProjected {
+		return DistanceUnitKm
+	}
+	return DistanceUnitUnits
+}
+
+// IsSaturated reports whether a node has no spare connection capacity.
+func IsSaturated(node models.Node) bool {
+	return node.ActiveConnections >= node.Capacity
+}
+
+// preferUnsaturated drops saturated nodes. If every node is saturated they
+// are all kept as a last resort when allowOverflow is set.
+func preferUnsaturated(nodes []models.Node, allowOverflow bool) []models.Node {
+	available := make([]models.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if !IsSaturated(node) {
+			available = append(available, node)
+		}
+	}
+
+	if len(available) == 0 && allowOverflow {
+		return nodes
+	}
+	return available
+}
+
+// IsStandby reports whether a node has weight 0 and should only receive
+// traffic when no other node qualifies.
+func IsStandby(node models.Node) bool {
+	return node.Weight <= 0
+}
+
+// preferWeighted drops standby nodes unless every node is a standby.
+func preferWeighted(nodes []models.Node) []models.Node {
+	weighted := make([]models.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if !IsStandby(node) {
+			weighted = append(weighted, node)
+		}
+	}
+
+	if len(weighted) == 0 {
+		return nodes
+	}
+	return weighted
+}
+
+// applyWeight divides a score by the node's weight, so a node of weight 2
+// matches a node of weight 1 with half its score. Standby nodes score as
+// weight 1 once they are the only candidates left.
+func applyWeight(score float64, node models.Node) float64 {
+	if IsStandby(node) {
+		return score
+	}
+	return score / float64(node.Weight)
+}
+
+// FindKNearestNodes returns the k nearest healthy nodes not in maintenance,
+// skipping saturated nodes unless nothing else is available.
 func FindKNearestNodes(nodes []models.Node, x, y float64, k int) []models.Node {
+	return FindKNearestNodesBy(nodes, x, y, config.RoutingConfig{KNearest: k, AllowOverflow: true}, CalculateDistance)
+}
+
+// FindKNearestNodesBy is FindKNearestNodes with a custom distance function,
+// returning up to cfg.KNearest nodes. When cfg.MaxDistance is positive, nodes
+// farther than it are dropped before the nearest are picked, so the result may
+// be empty. Saturated nodes are only returned when no other node qualifies and
+// cfg.AllowOverflow is set.
+func FindKNearestNodesBy(nodes []models.Node, x, y float64, cfg config.RoutingConfig, distance DistanceFunc) []models.Node {
 	type NodeWithDistance struct {
 		models.Node
 		Distance float64
 	}
 
+	candidates := healthyNodes(nodes, x, y, cfg.MaxDistance, distance)
+	candidates = preferUnsaturated(candidates, cfg.AllowOverflow)
+
 	var nodesWithDistance []NodeWithDistance
-	for _, node := range nodes {
-		if node.Status == "healthy" {
-			dist := CalculateDistance(x, y, node.LocationX, node.LocationY)
-			nodesWithDistance = append(nodesWithDistance, NodeWithDistance{
-				Node:     node,
-				Distance: dist,
-			})
-		}
+	for _, node := range candidates {
+		nodesWithDistance = append(nodesWithDistance, NodeWithDistance{
+			Node:     node,
+			Distance: distance(x, y, node.LocationX, node.LocationY),
+		})
 	}
 
 	// Sort by distance
@@ -34,45 +178,224 @@ func FindKNearestNodes(nodes []models.Node, x, y float64, k int) []models.Node {
 	})
 
 	// Return k nearest
+	k := clampK(cfg.KNearest, len(nodesWithDistance))
 	result := make([]models.Node, 0, k)
-	for i := 0; i < k && i < len(nodesWithDistance); i++ {
+	for i := 0; i < k; i++ {
 		result = append(result, nodesWithDistance[i].Node)
 	}
 
 	return result
 }
 
-func SelectBestNode(nodes []models.Node) models.Node {
+// clampK bounds k to at least 1 and at most the available nodes, so a k
+// that slipped past ValidateConfig neither empties every result nor sizes
+// buffers for more nodes than exist.
+func clampK(k, available int) int {
+	if k < 1 {
+		k = 1
+	}
+	return min(k, available)
+}
+
+// NodesWithin returns the nodes within radius of (x, y), whatever their
+// status, nearest first with their distances. At most limit nodes are
+// returned when limit is positive.
+func NodesWithin(nodes []models.Node, x, y, radius float64, limit int, distance DistanceFunc) []ScoredNode {
+	var result []ScoredNode
+	for _, node := range nodes {
+		dist := distance(x, y, node.LocationX, node.LocationY)
+		if dist > radius {
+			continue
+		}
+		result = append(result, ScoredNode{Node: node, Distance: dist})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Distance < result[j].Distance
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// healthyNodes returns the healthy nodes within maxDistance of (x, y), or
+// within any distance when maxDistance is not positive. Nodes in maintenance
+// are left out.
+func healthyNodes(nodes []models.Node, x, y, maxDistance float64, distance DistanceFunc) []models.Node {
+	result := make([]models.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Status != "healthy" || node.Maintenance {
+			continue
+		}
+		if maxDistance > 0 && distance(x, y, node.LocationX, node.LocationY) > maxDistance {
+			continue
+		}
+		result = append(result, node)
+	}
+	return result
+}
+
+// SelectBestNode returns the least loaded node relative to its weight,
+// preferring nodes that are not saturated or on standby. Nodes within
+// DefaultTieEpsilon of the best score are tied and broken as described on
+// BreakTies, using seed (typically the request ID).
+func SelectBestNode(nodes []models.Node, weights config.LoadScoreWeights, seed string) models.Node {
+	nodes = preferWeighted(preferUnsaturated(nodes, true))
 	if len(nodes) == 0 {
 		return models.Node{}
 	}
 
-	bestNode := nodes[0]
-	bestScore := calculateLoadScore(bestNode)
+	scored := make([]ScoredNode, len(nodes))
+	for i, node := range nodes {
+		loadScore := CalculateLoadScore(node, weights)
+		scored[i] = ScoredNode{Node: node, LoadScore: loadScore, Score: applyWeight(loadScore, node)}
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score < scored[j].Score
+	})
+	BreakTies(scored, DefaultTieEpsilon, seed)
+
+	return scored[0].Node
+}
+
+// BreakTies reorders the nodes of a best-first ranking whose Score is within
+// epsilon of the best one. Tied nodes with fewer active connections come
+// first; remaining ties are ordered by a hash of the seed and node ID, so
+// equally loaded nodes take turns across requests while a given seed always
+// picks the same node.
+func BreakTies(ranked []ScoredNode, epsilon float64, seed string) {
+	if len(ranked) < 2 {
+		return
+	}
+
+	tied := 1
+	for tied < len(ranked) && ranked[tied].Score-ranked[0].Score <= epsilon {
+		tied++
+	}
+	if tied == 1 {
+		return
+	}
 
-	for _, node := range nodes[1:] {
-		score := calculateLoadScore(node)
-		if score < bestScore {
-			bestNode = node
-			bestScore = score
+	group := ranked[:tied]
+	sort.SliceStable(group, func(i, j int) bool {
+		if group[i].Node.ActiveConnections != group[j].Node.ActiveConnections {
+			return group[i].Node.ActiveConnections < group[j].Node.ActiveConnections
 		}
+		return tieHash(seed, group[i].Node) < tieHash(seed, group[j].Node)
+	})
+}
+
+func tieHash(seed string, node models.Node) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	h.Write(node.ID[:])
+	return h.Sum64()
+}
+
+// ScoredNode is a routing candidate together with the values used to rank it.
+type ScoredNode struct {
+	Node      models.Node
+	Distance  float64
+	LoadScore float64
+	Score     float64
+}
+
+// SelectBestNodeWeighted ranks candidates by a weighted sum of their load
+// score and normalized distance, using cfg.LoadWeight and cfg.DistanceWeight,
+// divided by the node's weight. Nodes farther than cfg.MaxDistance are never
+// selected, saturated nodes only when every candidate is saturated and
+// cfg.AllowOverflow is set, and standby nodes only when nothing else is. Scores
+// within cfg.TieEpsilon of the best are broken with BreakTies using seed.
+// Nodes with stale stats have cfg.StalePenalty added to their load score. The
+// second return value is false when no candidate is eligible.
+func SelectBestNodeWeighted(nodes []models.Node, coordinates models.Location, cfg config.RoutingConfig, distance DistanceFunc, seed string) (ScoredNode, bool) {
+	ranked := RankNodesWeighted(nodes, coordinates, cfg, distance, seed)
+	if len(ranked) == 0 {
+		return ScoredNode{}, false
 	}
+	return ranked[0], true
+}
 
-	return bestNode
+// RankNodesWeighted scores the candidates SelectBestNodeWeighted would
+// consider and returns them best first.
+func RankNodesWeighted(nodes []models.Node, coordinates models.Location, cfg config.RoutingConfig, distance DistanceFunc, seed string) []ScoredNode {
+	return rankNodesWeighted(nodes, coordinates, cfg, distance, seed, loadAdjustments{})
 }
 
-func CalculateLoadScore(node models.Node) float64 {
-	cpuWeight := 0.4
-	memWeight := 0.3
-	connWeight := 0.3
+// loadAdjustments account for what the router knows about nodes beyond their
+// reported stats. Any of them may be nil.
+type loadAdjustments struct {
+	// pending counts requests routed to the node that its connections do
+	// not show yet
+	pending func(uuid.UUID) int
+	// failed reports nodes clients recently reported as failing, which get
+	// the stale penalty
+	failed func(uuid.UUID, time.Time) bool
+	// cooling reports nodes selected within the selection cooldown, which
+	// get the cooldown penalty
+	cooling func(uuid.UUID, time.Time) bool
+}
 
+// rankNodesWeighted is RankNodesWeighted with each node's load score
+// adjusted by adjust.
+func rankNodesWeighted(nodes []models.Node, coordinates models.Location, cfg config.RoutingConfig, distance DistanceFunc, seed string, adjust loadAdjustments) []ScoredNode {
+	now := time.Now()
+	candidates := make([]ScoredNode, 0, len(nodes))
+	maxDistance := 0.0
+	for _, node := range preferWeighted(preferUnsaturated(nodes, cfg.AllowOverflow)) {
+		dist := distance(coordinates.X, coordinates.Y, node.LocationX, node.LocationY)
+		if cfg.MaxDistance > 0 && dist > cfg.MaxDistance {
+			continue
+		}
+		loaded := node
+		if adjust.pending != nil {
+			loaded = withPending(node, adjust.pending(node.ID))
+		}
+		penalty := stalePenalty(node, cfg, now)
+		if penalty == 0 && adjust.failed != nil && adjust.failed(node.ID, now) {
+			penalty = cfg.StalePenalty
+		}
+		if adjust.cooling != nil && adjust.cooling(node.ID, now) {
+			penalty += cfg.CooldownPenalty
+		}
+		candidates = append(candidates, ScoredNode{
+			Node:      node,
+			Distance:  dist,
+			LoadScore: CalculateLoadScore(loaded, cfg.LoadScoreWeights) + penalty,
+		})
+		maxDistance = math.Max(maxDistance, dist)
+	}
+
+	// Normalize distances against MaxDistance when configured so scores are
+	// comparable across requests, otherwise against the farthest candidate.
+	normalizer := maxDistance
+	if cfg.MaxDistance > 0 {
+		normalizer = cfg.MaxDistance
+	}
+
+	for i := range candidates {
+		distanceScore := 0.0
+		if normalizer > 0 {
+			distanceScore = candidates[i].Distance / normalizer
+		}
+		score := cfg.LoadWeight*candidates[i].LoadScore + cfg.DistanceWeight*distanceScore
+		candidates[i].Score = applyWeight(score, candidates[i].Node)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score < candidates[j].Score
+	})
+	BreakTies(candidates, cfg.TieEpsilon, seed)
+	return candidates
+}
+
+// CalculateLoadScore combines a node's CPU usage, memory usage and
+// connections relative to capacity into one load score using weights.
+func CalculateLoadScore(node models.Node, weights config.LoadScoreWeights) float64 {
 	cpuScore := node.CPUUsage / 100.0
 	memScore := node.MemoryUsage / 100.0
 	connScore := float64(node.ActiveConnections) / float64(node.Capacity)
 
-	return cpuWeight*cpuScore + memWeight*memScore + connWeight*connScore
-}
-
-func calculateLoadScore(node models.Node) float64 {
-	return CalculateLoadScore(node)
+	return weights.CPU*cpuScore + weights.Memory*memScore + weights.Connections*connScore
 }
@@ -0,0 +1,238 @@
+package routing
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		x1, y1, x2, y2 float64
+		want           float64
+	}{
+		{name: "euclidean", mode: DistanceModeEuclidean, x2: 3, y2: 4, want: 5},
+		{name: "euclidean same point", mode: DistanceModeEuclidean, x1: 10.5, y1: 45.1, x2: 10.5, y2: 45.1, want: 0},
+		{name: "one degree of latitude", mode: DistanceModeHaversine, y2: 1, want: 111.195},
+		{name: "same point", mode: DistanceModeHaversine, x1: 10.5, y1: 45.1, x2: 10.5, y2: 45.1, want: 0},
+		{name: "pole from two longitudes", mode: DistanceModeHaversine, y1: 90, x2: 90, y2: 90, want: 0},
+		{name: "across the antimeridian", mode: DistanceModeHaversine, x1: 179, x2: -179, want: 222.390},
+		{name: "across the antimeridian westward", mode: DistanceModeHaversine, x1: -179, x2: 179, want: 222.390},
+		{name: "across the antimeridian at 60N", mode: DistanceModeHaversine, x1: 179, y1: 60, x2: -179, y2: 60, want: 111.191},
+		{name: "antipodes", mode: DistanceModeHaversine, x2: 180, want: 20015.087},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DistanceFuncFor(tt.mode)(tt.x1, tt.y1, tt.x2, tt.y2)
+			if math.IsNaN(got) || math.Abs(got-tt.want) > 0.001 {
+				t.Errorf("distance from (%g, %g) to (%g, %g) = %v, want %v", tt.x1, tt.y1, tt.x2, tt.y2, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaturatedNodeLoses(t *testing.T) {
+	// The saturated node is nearer and otherwise idle, so it would outscore
+	// the lightly loaded one if it were ranked at all
+	saturated := testNode("saturated", 1, 0)
+	saturated.ActiveConnections = saturated.Capacity
+	light := testNode("light", 10, 0)
+	light.CPUUsage = 40
+	light.MemoryUsage = 40
+	light.ActiveConnections = 20
+	nodes := []models.Node{saturated, light}
+
+	tests := []struct {
+		name     string
+		overflow bool
+	}{
+		{"overflow allowed", true},
+		{"overflow disallowed", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.AllowOverflow = tt.overflow
+
+			best, ok := SelectBestNodeWeighted(nodes, models.Location{}, cfg, CalculateDistance, "seed")
+			if !ok || best.Node.ID != light.ID {
+				t.Errorf("SelectBestNodeWeighted picked %q, want %q", best.Node.Name, light.Name)
+			}
+			found := FindKNearestNodesBy(nodes, 0, 0, cfg, CalculateDistance)
+			if len(found) != 1 || found[0].ID != light.ID {
+				t.Errorf("FindKNearestNodesBy returned %d nodes, want only %q", len(found), light.Name)
+			}
+		})
+	}
+
+	if best := SelectBestNode(nodes, testConfig().LoadScoreWeights, "seed"); best.ID != light.ID {
+		t.Errorf("SelectBestNode picked %q, want %q", best.Name, light.Name)
+	}
+}
+
+func TestSaturatedNodesAsLastResort(t *testing.T) {
+	a := testNode("a", 1, 0)
+	a.ActiveConnections = a.Capacity
+	b := testNode("b", 2, 0)
+	b.ActiveConnections = b.Capacity + 5
+	nodes := []models.Node{a, b}
+
+	tests := []struct {
+		name     string
+		overflow bool
+		want     int
+	}{
+		{"overflow allowed", true, 2},
+		{"overflow disallowed", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.RoutingConfig{KNearest: 3, AllowOverflow: tt.overflow}
+			if found := FindKNearestNodesBy(nodes, 0, 0, cfg, CalculateDistance); len(found) != tt.want {
+				t.Errorf("found %d saturated nodes, want %d", len(found), tt.want)
+			}
+		})
+	}
+}
+
+// tiedNodes returns n identical nodes with fixed IDs, so tie-breaking
+// hashes the same way on every run.
+func tiedNodes(n int) []models.Node {
+	nodes := make([]models.Node, n)
+	for i := range nodes {
+		nodes[i] = testNode(fmt.Sprintf("tied-%d", i), 0, 0)
+		nodes[i].ID = uuid.NewSHA1(uuid.NameSpaceOID, []byte(nodes[i].Name))
+		nodes[i].CPUUsage = 30
+	}
+	return nodes
+}
+
+func TestTieDistribution(t *testing.T) {
+	const requests = 3000
+	nodes := tiedNodes(3)
+	cfg := testConfig()
+
+	counts := make(map[string]int)
+	for i := 0; i < requests; i++ {
+		best, ok := SelectBestNodeWeighted(nodes, models.Location{}, cfg, CalculateDistance, fmt.Sprintf("req-%d", i))
+		if !ok {
+			t.Fatal("no node selected")
+		}
+		counts[best.Node.Name]++
+	}
+
+	// Each node should take about a third; allow a wide margin so only a
+	// skewed hash fails
+	for _, node := range nodes {
+		if share := float64(counts[node.Name]) / requests; share < 0.28 || share > 0.39 {
+			t.Errorf("%s took %.1f%% of %d tied selections, want about a third (%v)", node.Name, share*100, requests, counts)
+		}
+	}
+}
+
+func TestTieBreakIsStablePerSeed(t *testing.T) {
+	nodes := tiedNodes(3)
+	cfg := testConfig()
+
+	// The input order must not change the pick
+	first, _ := SelectBestNodeWeighted(nodes, models.Location{}, cfg, CalculateDistance, "req-1")
+	reversed := []models.Node{nodes[2], nodes[1], nodes[0]}
+	again, _ := SelectBestNodeWeighted(reversed, models.Location{}, cfg, CalculateDistance, "req-1")
+	if again.Node.ID != first.Node.ID {
+		t.Fatalf("seed picked %s, then %s", first.Node.Name, again.Node.Name)
+	}
+}
+
+func TestTieBreakPrefersFewerConnections(t *testing.T) {
+	nodes := tiedNodes(3)
+	// Trade a connection for CPU so the load score stays within epsilon
+	nodes[1].ActiveConnections = 1
+	nodes[1].CPUUsage -= 0.75
+	nodes[2].ActiveConnections = 2
+	nodes[2].CPUUsage -= 1.5
+	cfg := testConfig()
+	cfg.TieEpsilon = 0.01
+
+	for i := 0; i < 100; i++ {
+		best, _ := SelectBestNodeWeighted(nodes, models.Location{}, cfg, CalculateDistance, fmt.Sprintf("req-%d", i))
+		if best.Node.ID != nodes[0].ID {
+			t.Fatalf("picked %s with %d connections, want the idle node", best.Node.Name, best.Node.ActiveConnections)
+		}
+	}
+}
+
+func TestClampK(t *testing.T) {
+	tests := []struct {
+		name      string
+		k         int
+		available int
+		want      int
+	}{
+		{"zero", 0, 5, 1},
+		{"negative", -3, 5, 1},
+		{"one", 1, 5, 1},
+		{"within the nodes", 3, 5, 3},
+		{"equal to the nodes", 5, 5, 5},
+		{"more than the nodes", 10, 5, 5},
+		{"no nodes", 3, 0, 0},
+		{"zero with no nodes", 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clampK(tt.k, tt.available); got != tt.want {
+				t.Errorf("clampK(%d, %d) = %d, want %d", tt.k, tt.available, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindKNearest(t *testing.T) {
+	tests := []struct {
+		name string
+		k    int
+		// want is the number of nodes found, or every node when all is set
+		want int
+		all  bool
+	}{
+		{name: "zero is raised to one", k: 0, want: 1},
+		{name: "negative is raised to one", k: -2, want: 1},
+		{name: "one", k: 1, want: 1},
+		{name: "more than the nodes", k: 1000, all: true},
+	}
+
+	// The smaller set is scanned linearly, the larger one goes through the
+	// kd-tree
+	for _, size := range []int{5, kdTreeMinNodes * 2} {
+		nodes := testGrid(size, 0, 0)
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%d nodes/%s", size, tt.name), func(t *testing.T) {
+				cfg := testConfig()
+				cfg.KNearest = tt.k
+				cfg.MaxDistance = 0
+				s := NewService(nil, cfg, 0)
+
+				found := s.findKNearest(nodes, models.Location{}, cfg, func(models.Node) bool { return true })
+				want := tt.want
+				if tt.all {
+					want = size
+				}
+				if len(found) != want {
+					t.Fatalf("found %d nodes, want %d", len(found), want)
+				}
+				if found[0].LocationX != 0 || found[0].LocationY != 0 {
+					t.Errorf("nearest node is at (%.0f, %.0f), want the origin", found[0].LocationX, found[0].LocationY)
+				}
+			})
+		}
+	}
+}
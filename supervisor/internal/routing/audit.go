@@ -0,0 +1,222 @@
+package routing
+
+import (
+	"sort"
+	"time"
+
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/models"
+	"github.com/google/uuid"
+)
+
+// Reasons recorded for each node in a Decision
+const (
+	ReasonSelected = "selected"
+	// ReasonOutscored nodes were ranked but another node scored better
+	ReasonOutscored = "outscored"
+	// ReasonUnhealthy nodes are not healthy or stopped reporting for longer
+	// than ExcludeAfter
+	ReasonUnhealthy   = "unhealthy"
+	ReasonMaintenance = "maintenance"
+	// ReasonExcluded nodes were left out by the request, such as a node the
+	// client reported as failing when asking for an alternate
+	ReasonExcluded = "excluded"
+	// ReasonWrongCluster nodes are outside the cluster the request named
+	ReasonWrongCluster = "wrong_cluster"
+	// ReasonMissingLabels nodes lack one of the request's required labels
+	ReasonMissingLabels = "missing_labels"
+	ReasonWrongZone     = "wrong_zone"
+	ReasonTooFar        = "too_far"
+	// ReasonOverloaded nodes are above the load threshold of the request's
+	// priority
+	ReasonOverloaded = "overloaded"
+	ReasonSaturated  = "saturated"
+	ReasonStandby    = "standby"
+	// ReasonNotNearest nodes were eligible but outside the KNearest ranked
+	ReasonNotNearest = "not_nearest"
+	// ReasonStickySession nodes were eligible but the client is pinned to
+	// another node
+	ReasonStickySession = "sticky_session"
+)
+
+// maxDecisionNodes caps the nodes listed in a Decision so audits stay small
+// and cheap to build on large clusters.
+const maxDecisionNodes = 50
+
+// Decision explains a routing outcome: the nodes that were considered, why
+// each one was or was not selected and the winning score. Nodes lists the
+// selected node first and then the nearest others, and Reasons counts the
+// listed nodes; Truncated marks that Considered holds more.
+type Decision struct {
+	Outcome        string            `json:"outcome"`
+	Mode           string            `json:"routing_mode,omitempty"`
+	Priority       string            `json:"priority"`
+	PreferredZone  string            `json:"preferred_zone,omitempty"`
+	ZoneSpillover  bool              `json:"zone_spillover,omitempty"`
+	RequiredLabels map[string]string `json:"required_labels,omitempty"`
+	Cluster        *uuid.UUID        `json:"cluster,omitempty"`
+	SelectedNodeID *uuid.UUID        `json:"selected_node_id,omitempty"`
+	WinningScore   *float64          `json:"winning_score,omitempty"`
+	Considered     int               `json:"considered"`
+	Reasons        map[string]int    `json:"reasons"`
+	Nodes          []DecisionNode    `json:"nodes"`
+	Truncated      bool              `json:"truncated,omitempty"`
+	// Stale decisions were made from the last known healthy nodes while the
+	// database was unreachable
+	Stale bool `json:"stale,omitempty"`
+	// Seed is the routing seed the request or ROUTING_SEED set, omitted when
+	// the request ID seeded the decision
+	Seed string `json:"seed,omitempty"`
+}
+
+// DecisionNode is one node of a Decision. Score is only set for nodes that
+// were ranked.
+type DecisionNode struct {
+	NodeID    uuid.UUID `json:"node_id"`
+	Name      string    `json:"name"`
+	Zone      string    `json:"zone,omitempty"`
+	Distance  float64   `json:"distance"`
+	LoadScore float64   `json:"load_score"`
+	Score     *float64  `json:"score,omitempty"`
+	Reason    string    `json:"reason"`
+}
+
+// decide explains the routing of req against the node snapshot. result is
+// nil when the request was rejected, and zoneOnly reports that it was
+// routed within the preferred zone.
+func (s *Service) decide(req Request, nodes []models.Node, cfg config.RoutingConfig, now time.Time, result *RouteResult, zoneOnly bool) *Decision {
+	decision := &Decision{
+		Outcome:        models.RoutingStatusFailed,
+		Priority:       NormalizePriority(req.Priority),
+		PreferredZone:  req.PreferredZone,
+		RequiredLabels: req.RequiredLabels,
+		Considered:     len(nodes),
+		Reasons:        make(map[string]int),
+	}
+	if req.Cluster != uuid.Nil {
+		cluster := req.Cluster
+		decision.Cluster = &cluster
+	}
+	if seed := req.seed(cfg); seed != req.RequestID {
+		decision.Seed = seed
+	}
+
+	ranked := make(map[uuid.UUID]ScoredNode)
+	if result != nil {
+		decision.Outcome = models.RoutingStatusRouted
+		decision.Mode = result.Mode
+		decision.ZoneSpillover = req.PreferredZone != "" && !zoneOnly
+		id := result.Node.ID
+		decision.SelectedNodeID = &id
+		if result.Mode != ModeSticky {
+			score := result.Score
+			decision.WinningScore = &score
+		}
+		for _, candidate := range result.Candidates {
+			ranked[candidate.Node.ID] = candidate
+		}
+	}
+
+	var selected uuid.UUID
+	if result != nil {
+		selected = result.Node.ID
+	}
+	listed := s.decisionNodes(nodes, req.Coordinates, selected)
+
+	withinLoad := eligibleFor(cfg, req.Priority)
+	entries := make([]DecisionNode, 0, len(listed))
+	for _, node := range listed {
+		entry := DecisionNode{
+			NodeID:    node.ID,
+			Name:      node.Name,
+			Zone:      node.Zone,
+			Distance:  s.Distance(req.Coordinates, node),
+			LoadScore: CalculateLoadScore(node, cfg.LoadScoreWeights),
+		}
+		candidate, isRanked := ranked[node.ID]
+		if isRanked {
+			entry.LoadScore = candidate.LoadScore
+			if result.Mode != ModeSticky || node.ID != result.Node.ID {
+				score := candidate.Score
+				entry.Score = &score
+			}
+		}
+
+		switch {
+		case result != nil && node.ID == result.Node.ID:
+			entry.Reason = ReasonSelected
+			if !isRanked {
+				entry.LoadScore = result.LoadScore
+			}
+		case node.Status != models.NodeStatusHealthy || IsExpired(node, cfg, now):
+			entry.Reason = ReasonUnhealthy
+		case node.Maintenance:
+			entry.Reason = ReasonMaintenance
+		case node.ID == req.Exclude:
+			entry.Reason = ReasonExcluded
+		case !node.InCluster(req.Cluster):
+			entry.Reason = ReasonWrongCluster
+		case !node.HasLabels(req.RequiredLabels):
+			entry.Reason = ReasonMissingLabels
+		case zoneOnly && node.Zone != req.PreferredZone:
+			entry.Reason = ReasonWrongZone
+		case cfg.MaxDistance > 0 && entry.Distance > cfg.MaxDistance:
+			entry.Reason = ReasonTooFar
+		case !withinLoad(node):
+			entry.Reason = ReasonOverloaded
+		case isRanked:
+			entry.Reason = ReasonOutscored
+		case IsSaturated(node):
+			entry.Reason = ReasonSaturated
+		case IsStandby(node):
+			entry.Reason = ReasonStandby
+		case result != nil && result.Mode == ModeSticky:
+			entry.Reason = ReasonStickySession
+		default:
+			entry.Reason = ReasonNotNearest
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if (entries[i].Reason == ReasonSelected) != (entries[j].Reason == ReasonSelected) {
+			return entries[i].Reason == ReasonSelected
+		}
+		return entries[i].Distance < entries[j].Distance
+	})
+	if len(entries) > maxDecisionNodes {
+		entries = entries[:maxDecisionNodes]
+	}
+	for _, entry := range entries {
+		decision.Reasons[entry.Reason]++
+	}
+	decision.Nodes = entries
+	decision.Truncated = len(entries) < len(nodes)
+	return decision
+}
+
+// decisionNodes returns the nodes a Decision may list: the selected node and
+// those nearest to the request. Small snapshots are returned whole; large
+// ones are searched in the kd-tree index for the nearest maxDecisionNodes, so
+// auditing a route does not cost more as the cluster grows.
+func (s *Service) decisionNodes(nodes []models.Node, coordinates models.Location, selected uuid.UUID) []models.Node {
+	if len(nodes) < kdTreeMinNodes {
+		return nodes
+	}
+
+	ids := s.nodeIndex(nodes).nearest(coordinates.X, coordinates.Y, maxDecisionNodes)
+	wanted := make(map[uuid.UUID]bool, len(ids)+1)
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	if selected != uuid.Nil {
+		wanted[selected] = true
+	}
+	listed := make([]models.Node, 0, len(wanted))
+	for _, node := range nodes {
+		if wanted[node.ID] {
+			listed = append(listed, node)
+		}
+	}
+	return listed
+}
@@ -0,0 +1,155 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+)
+
+// ValidateConfig checks that routing weights can be used for scoring.
+func ValidateConfig(cfg config.RoutingConfig) error {
+	switch {
+	case cfg.KNearest < 1:
+		return errors.New("k_nearest must be at least 1")
+	case cfg.MaxDistance < 0:
+		return errors.New("max_distance must be non-negative")
+	case cfg.LoadWeight < 0:
+		return errors.New("load_weight must be non-negative")
+	case cfg.DistanceWeight < 0:
+		return errors.New("distance_weight must be non-negative")
+	case cfg.NormalLoadThreshold <= 0 || cfg.NormalLoadThreshold > 1:
+		return errors.New("normal priority load threshold must be in (0, 1]")
+	case cfg.LowLoadThreshold <= 0 || cfg.LowLoadThreshold > 1:
+		return errors.New("low priority load threshold must be in (0, 1]")
+	case cfg.Fallbacks < 0:
+		return errors.New("fallbacks must be non-negative")
+	case cfg.NodeCacheTTL < 0:
+		return errors.New("node cache ttl must be non-negative")
+	case cfg.TieEpsilon < 0:
+		return errors.New("tie epsilon must be non-negative")
+	case cfg.StaleAfter < 0 || cfg.ExcludeAfter < 0:
+		return errors.New("staleness windows must be non-negative")
+	case cfg.StalePenalty < 0:
+		return errors.New("stale penalty must be non-negative")
+	case cfg.InFlightTTL < 0:
+		return errors.New("in-flight ttl must be non-negative")
+	case cfg.FailureTTL < 0:
+		return errors.New("failure ttl must be non-negative")
+	case cfg.SelectionCooldown < 0 || cfg.CooldownPenalty < 0:
+		return errors.New("selection cooldown and its penalty must be non-negative")
+	case cfg.Strategy != StrategyBest && cfg.Strategy != StrategyP2C && cfg.Strategy != StrategyLeastConn:
+		return fmt.Errorf("strategy must be %q, %q or %q", StrategyBest, StrategyP2C, StrategyLeastConn)
+	}
+	if err := validateLoadScoreWeights(cfg.LoadScoreWeights); err != nil {
+		return err
+	}
+	return validateCoordinates(cfg)
+}
+
+// loadScoreWeightTolerance is how far the load score weights may sum from 1,
+// allowing for rounded values such as thirds written as 0.333.
+const loadScoreWeightTolerance = 0.01
+
+func validateLoadScoreWeights(weights config.LoadScoreWeights) error {
+	if weights.CPU < 0 || weights.Memory < 0 || weights.Connections < 0 {
+		return errors.New("load score weights must be non-negative")
+	}
+	if sum := weights.CPU + weights.Memory + weights.Connections; math.Abs(sum-1) > loadScoreWeightTolerance {
+		return fmt.Errorf("load score weights must sum to 1, got %.3f", sum)
+	}
+	return nil
+}
+
+// Config returns the routing configuration currently in effect.
+func (s *Service) Config() config.RoutingConfig {
+	return *s.config.Load()
+}
+
+// LoadConfig replaces the env defaults with the weights saved in the
+// database, if any have been saved.
+func (s *Service) LoadConfig(ctx context.Context) error {
+	cfg, err := s.withStoredWeights(ctx, s.Config())
+	if err != nil {
+		return err
+	}
+
+	s.config.Store(&cfg)
+	return nil
+}
+
+// withStoredWeights returns cfg with the weights saved in the database, if
+// any have been saved, in place of its own.
+func (s *Service) withStoredWeights(ctx context.Context, cfg config.RoutingConfig) (config.RoutingConfig, error) {
+	row, err := s.db.Queries.GetRoutingConfig(ctx)
+	if err != nil {
+		if database.IsNotFound(err) {
+			return cfg, nil
+		}
+		return config.RoutingConfig{}, fmt.Errorf("failed to load routing config: %w", err)
+	}
+
+	cfg.KNearest = int(row.KNearest)
+	cfg.MaxDistance = row.MaxDistance
+	cfg.LoadWeight = row.LoadWeight
+	cfg.DistanceWeight = row.DistanceWeight
+	if err := ValidateConfig(cfg); err != nil {
+		return config.RoutingConfig{}, fmt.Errorf("stored routing config is invalid: %w", err)
+	}
+	return cfg, nil
+}
+
+// ReloadConfig replaces the routing configuration with cfg, read again from
+// the environment, keeping the coordinate system, distance mode and
+// projection the service was started with. Weights saved through the admin
+// API still take precedence, as they do at startup. maxCheckAge replaces the
+// age past which healthy nodes are no longer loaded, which follows the health
+// check interval. Nothing changes when it returns an error.
+func (s *Service) ReloadConfig(ctx context.Context, cfg config.RoutingConfig, maxCheckAge time.Duration) error {
+	current := s.Config()
+	cfg.CoordinateSystem = current.CoordinateSystem
+	cfg.DistanceMode = current.DistanceMode
+	cfg.ProjectionLatitude = current.ProjectionLatitude
+	if err := ValidateConfig(cfg); err != nil {
+		return err
+	}
+	cfg, err := s.withStoredWeights(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	s.config.Store(&cfg)
+	s.maxCheckAge.Store(int64(maxCheckAge))
+	s.InvalidateCache()
+	return nil
+}
+
+// UpdateConfig persists new routing weights and applies them to subsequent
+// requests. The coordinate system, distance mode and strategy cannot be
+// changed at runtime.
+func (s *Service) UpdateConfig(ctx context.Context, cfg config.RoutingConfig) (config.RoutingConfig, error) {
+	if err := ValidateConfig(cfg); err != nil {
+		return config.RoutingConfig{}, err
+	}
+
+	if _, err := s.db.Queries.UpsertRoutingConfig(ctx, db.UpsertRoutingConfigParams{
+		KNearest:       int32(cfg.KNearest),
+		MaxDistance:    cfg.MaxDistance,
+		LoadWeight:     cfg.LoadWeight,
+		DistanceWeight: cfg.DistanceWeight,
+	}); err != nil {
+		return config.RoutingConfig{}, fmt.Errorf("failed to save routing config: %w", err)
+	}
+
+	cfg.CoordinateSystem = s.Config().CoordinateSystem
+	cfg.DistanceMode = s.Config().DistanceMode
+	cfg.ProjectionLatitude = s.Config().ProjectionLatitude
+	cfg.Strategy = s.Config().Strategy
+	s.config.Store(&cfg)
+	return cfg, nil
+}
@@ -0,0 +1,27 @@
+package routing
+
+import "testing"
+
+func TestValidateConfigKNearest(t *testing.T) {
+	tests := []struct {
+		k     int
+		valid bool
+	}{
+		{-1, false},
+		{0, false},
+		{1, true},
+		{1000, true},
+	}
+
+	for _, tt := range tests {
+		cfg := testConfig()
+		cfg.KNearest = tt.k
+		err := ValidateConfig(cfg)
+		if tt.valid && err != nil {
+			t.Errorf("K=%d rejected: %v", tt.k, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("K=%d accepted, want an error", tt.k)
+		}
+	}
+}
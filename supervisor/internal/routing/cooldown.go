@@ -0,0 +1,44 @@
+package routing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// selectionCooldowns remembers when each node was last selected. For a
+// short while afterwards the node carries a small load penalty, so a burst
+// of requests that all see the same stats fans out over the next best nodes
+// instead of piling onto one until its stats refresh.
+type selectionCooldowns struct {
+	selected sync.Map // uuid.UUID -> time.Time
+}
+
+func (c *selectionCooldowns) mark(nodeID uuid.UUID, now time.Time) {
+	c.selected.Store(nodeID, now)
+}
+
+// cooling reports whether the node was selected less than window ago.
+func (c *selectionCooldowns) cooling(nodeID uuid.UUID, now time.Time, window time.Duration) bool {
+	selected, ok := c.selected.Load(nodeID)
+	if !ok {
+		return false
+	}
+	if now.Sub(selected.(time.Time)) >= window {
+		c.selected.CompareAndDelete(nodeID, selected)
+		return false
+	}
+	return true
+}
+
+// cooldownCheck returns a check for nodes selected within window, or
+// nil when the cooldown is disabled.
+func (s *Service) cooldownCheck(window time.Duration) func(uuid.UUID, time.Time) bool {
+	if window <= 0 {
+		return nil
+	}
+	return func(nodeID uuid.UUID, now time.Time) bool {
+		return s.cooldowns.cooling(nodeID, now, window)
+	}
+}
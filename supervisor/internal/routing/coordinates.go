@@ -0,0 +1,115 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/models"
+)
+
+// Coordinate systems, chosen with COORDINATE_SYSTEM. Each distance mode only
+// makes sense in one of them.
+const (
+	// CoordinateSystemCartesian coordinates are plain X/Y on a plane
+	CoordinateSystemCartesian = "cartesian"
+	// CoordinateSystemGeographic coordinates are longitude (X) and latitude
+	// (Y) in degrees
+	CoordinateSystemGeographic = "geographic"
+)
+
+// maxReportedNodes caps the offending nodes named by CheckNodeCoordinates.
+const maxReportedNodes = 5
+
+// CoordinateSystemFor returns the coordinate system a distance mode works
+// in, used when COORDINATE_SYSTEM is not set.
+func CoordinateSystemFor(mode string) string {
+	if mode == DistanceModeHaversine || mode == DistanceModeProjected {
+		return CoordinateSystemGeographic
+	}
+	return CoordinateSystemCartesian
+}
+
+// validateCoordinates rejects unknown coordinate systems and distance modes,
+// and distance modes that do not fit the coordinate system, such as
+// Euclidean distances over longitude and latitude.
+func validateCoordinates(cfg config.RoutingConfig) error {
+	switch cfg.CoordinateSystem {
+	case CoordinateSystemCartesian, CoordinateSystemGeographic:
+	default:
+		return fmt.Errorf("coordinate system must be %q or %q", CoordinateSystemCartesian, CoordinateSystemGeographic)
+	}
+
+	switch cfg.DistanceMode {
+	case DistanceModeEuclidean, DistanceModeHaversine, DistanceModeProjected:
+	default:
+		return fmt.Errorf("distance mode must be %q, %q or %q", DistanceModeEuclidean, DistanceModeHaversine, DistanceModeProjected)
+	}
+	if want := CoordinateSystemFor(cfg.DistanceMode); cfg.CoordinateSystem != want {
+		return fmt.Errorf("distance mode %q needs %s coordinates, not %s", cfg.DistanceMode, want, cfg.CoordinateSystem)
+	}
+
+	if cfg.DistanceMode == DistanceModeProjected && (cfg.ProjectionLatitude < -90 || cfg.ProjectionLatitude > 90) {
+		return errors.New("projection latitude must be between -90 and 90")
+	}
+	return nil
+}
+
+// ProjectLocal maps a longitude and latitude onto a plane in kilometers with
+// an equirectangular projection about originLat. Planar distances between
+// projected points are close to great-circle distances for points within a
+// few hundred kilometers of originLat, and much cheaper to compute.
+func ProjectLocal(lon, lat, originLat float64) (x, y float64) {
+	const kmPerDegree = earthRadiusKm * math.Pi / 180
+	return lon * kmPerDegree * math.Cos(originLat*math.Pi/180), lat * kmPerDegree
+}
+
+// localProjection projects onto the plane of ProjectLocal, so the kd-tree
+// ranks nodes exactly as the projected distance does.
+func localProjection(originLat float64) projection {
+	return func(x, y float64) []float64 {
+		px, py := ProjectLocal(x, y, originLat)
+		return []float64{px, py}
+	}
+}
+
+// projectedDistance returns the planar distance in kilometers between two
+// longitude/latitude points after projecting them about originLat.
+func projectedDistance(originLat float64) DistanceFunc {
+	return func(x1, y1, x2, y2 float64) float64 {
+		px1, py1 := ProjectLocal(x1, y1, originLat)
+		px2, py2 := ProjectLocal(x2, y2, originLat)
+		return CalculateDistance(px1, py1, px2, py2)
+	}
+}
+
+// CheckNodeCoordinates verifies that every registered node lies within the
+// valid range of the configured coordinate system, so nodes stored under
+// another system are caught at startup instead of being routed on wrong
+// distances.
+func (s *Service) CheckNodeCoordinates(ctx context.Context) error {
+	nodes, err := s.db.Queries.GetAllNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load nodes: %w", err)
+	}
+
+	geographic := s.Geographic()
+	var invalid []string
+	count := 0
+	for _, node := range nodes {
+		location := models.Location{X: node.LocationX, Y: node.LocationY}
+		if err := location.Validate(geographic); err != nil {
+			count++
+			if len(invalid) < maxReportedNodes {
+				invalid = append(invalid, fmt.Sprintf("%s (%s)", node.Name, err))
+			}
+		}
+	}
+	if count > 0 {
+		return fmt.Errorf("%d nodes have coordinates outside the %s coordinate system, including %v",
+			count, s.Config().CoordinateSystem, invalid)
+	}
+	return nil
+}
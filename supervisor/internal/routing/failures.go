@@ -0,0 +1,42 @@
+package routing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// reportedFailures remembers the nodes clients recently reported as failing
+// them. Until a report expires the node is scored as if its stats were
+// stale, steering traffic elsewhere before the next health check can tell
+// whether it is really down.
+type reportedFailures struct {
+	until sync.Map // uuid.UUID -> time.Time
+}
+
+func (r *reportedFailures) report(nodeID uuid.UUID, ttl time.Duration) {
+	r.until.Store(nodeID, time.Now().Add(ttl))
+}
+
+// active reports whether the node has an unexpired failure report.
+func (r *reportedFailures) active(nodeID uuid.UUID, now time.Time) bool {
+	until, ok := r.until.Load(nodeID)
+	if !ok {
+		return false
+	}
+	if now.After(until.(time.Time)) {
+		r.until.CompareAndDelete(nodeID, until)
+		return false
+	}
+	return true
+}
+
+// ReportFailure records that a client failed to reach the node. For the next
+// FailureTTL seconds the node's load score carries the stale penalty. A zero
+// FailureTTL ignores reports.
+func (s *Service) ReportFailure(nodeID uuid.UUID) {
+	if ttl := s.Config().FailureTTL; ttl > 0 {
+		s.failures.report(nodeID, time.Duration(ttl)*time.Second)
+	}
+}
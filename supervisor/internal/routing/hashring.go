@@ -0,0 +1,66 @@
+package routing
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"arx-supervisor/internal/models"
+	"github.com/google/uuid"
+)
+
+// hashRingReplicas is the number of virtual points per node, which keeps
+// clients evenly spread when nodes join or leave.
+const hashRingReplicas = 100
+
+// hashRing maps client IDs onto nodes with consistent hashing, so adding or
+// removing a node only moves the clients that hashed to it.
+type hashRing struct {
+	points []uint32
+	owners map[uint32]uuid.UUID
+}
+
+func newHashRing(nodes []models.Node) *hashRing {
+	ring := &hashRing{
+		points: make([]uint32, 0, len(nodes)*hashRingReplicas),
+		owners: make(map[uint32]uuid.UUID, len(nodes)*hashRingReplicas),
+	}
+
+	for _, node := range nodes {
+		for i := 0; i < hashRingReplicas; i++ {
+			point := hashKey(node.ID.String() + "#" + strconv.Itoa(i))
+			if _, exists := ring.owners[point]; exists {
+				continue
+			}
+			ring.points = append(ring.points, point)
+			ring.owners[point] = node.ID
+		}
+	}
+
+	sort.Slice(ring.points, func(i, j int) bool {
+		return ring.points[i] < ring.points[j]
+	})
+	return ring
+}
+
+// owner returns the node responsible for the key, or false if the ring is empty.
+func (r *hashRing) owner(key string) (uuid.UUID, bool) {
+	if len(r.points) == 0 {
+		return uuid.Nil, false
+	}
+
+	h := hashKey(key)
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= h
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]], true
+}
+
+func hashKey(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
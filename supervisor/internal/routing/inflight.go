@@ -0,0 +1,55 @@
+package routing
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"arx-supervisor/internal/models"
+	"github.com/google/uuid"
+)
+
+// inFlight counts the requests recently routed to each node. Nodes only
+// report their connections on the next health check, so without it a burst
+// of concurrent routes would all see the same stats and pick the same node.
+// Each assignment counts until the configured TTL passes, by which time the
+// node's reported stats are expected to include it.
+type inFlight struct {
+	counts sync.Map // uuid.UUID -> *atomic.Int64
+}
+
+func (f *inFlight) counter(nodeID uuid.UUID) *atomic.Int64 {
+	if c, ok := f.counts.Load(nodeID); ok {
+		return c.(*atomic.Int64)
+	}
+	c, _ := f.counts.LoadOrStore(nodeID, new(atomic.Int64))
+	return c.(*atomic.Int64)
+}
+
+// acquire counts a request routed to the node until ttl has passed.
+func (f *inFlight) acquire(nodeID uuid.UUID, ttl time.Duration) {
+	c := f.counter(nodeID)
+	c.Add(1)
+	time.AfterFunc(ttl, func() { c.Add(-1) })
+}
+
+// pending returns the number of requests counted against the node.
+func (f *inFlight) pending(nodeID uuid.UUID) int {
+	if c, ok := f.counts.Load(nodeID); ok {
+		return int(c.(*atomic.Int64).Load())
+	}
+	return 0
+}
+
+// withPending returns the node as it will look once its pending requests
+// show up in its reported connections.
+func withPending(node models.Node, pending int) models.Node {
+	node.ActiveConnections += pending
+	return node
+}
+
+// InFlight returns the number of requests routed to the node within the
+// last InFlightTTL seconds.
+func (s *Service) InFlight(nodeID uuid.UUID) int {
+	return s.inFlight.pending(nodeID)
+}
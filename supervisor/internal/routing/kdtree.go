@@ -0,0 +1,185 @@
+package routing
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/models"
+	"github.com/google/uuid"
+)
+
+// kdTreeMinNodes is the node count below which a linear scan is cheaper than
+// building and querying a kd-tree.
+const kdTreeMinNodes = 64
+
+// projection maps a node location onto the coordinate space indexed by the
+// tree. Nearest neighbours in the projected space must also be nearest under
+// the routing distance function.
+type projection func(x, y float64) []float64
+
+func planarProjection(x, y float64) []float64 {
+	return []float64{x, y}
+}
+
+// sphericalProjection maps longitude (x) and latitude (y) onto the unit
+// sphere. Chord length is monotonic in great-circle distance, so the nearest
+// points by Euclidean distance in 3D are also the nearest by haversine.
+func sphericalProjection(x, y float64) []float64 {
+	lat := y * math.Pi / 180
+	lon := x * math.Pi / 180
+	return []float64{
+		math.Cos(lat) * math.Cos(lon),
+		math.Cos(lat) * math.Sin(lon),
+		math.Sin(lat),
+	}
+}
+
+func projectionFor(cfg config.RoutingConfig) projection {
+	switch cfg.DistanceMode {
+	case DistanceModeHaversine:
+		return sphericalProjection
+	case DistanceModeProjected:
+		return localProjection(cfg.ProjectionLatitude)
+	}
+	return planarProjection
+}
+
+type kdPoint struct {
+	coords []float64
+	id     uuid.UUID
+}
+
+type kdNode struct {
+	point       kdPoint
+	axis        int
+	left, right *kdNode
+}
+
+// kdTree indexes node locations for k-nearest queries in O(log n + k).
+type kdTree struct {
+	root    *kdNode
+	project projection
+	// nodes is the nodeSetKey of the indexed nodes
+	nodes uint64
+}
+
+// nodeSetKey identifies a node set by its IDs, whatever their order, so an
+// index can tell when nodes were swapped for others at the same count.
+func nodeSetKey(nodes []models.Node) uint64 {
+	var key uint64
+	for _, node := range nodes {
+		h := fnv.New64a()
+		h.Write(node.ID[:])
+		key += h.Sum64()
+	}
+	return key
+}
+
+func newKDTree(nodes []models.Node, project projection) *kdTree {
+	points := make([]kdPoint, len(nodes))
+	for i, node := range nodes {
+		points[i] = kdPoint{coords: project(node.LocationX, node.LocationY), id: node.ID}
+	}
+
+	tree := &kdTree{project: project, nodes: nodeSetKey(nodes)}
+	if len(points) > 0 {
+		tree.root = buildKDNode(points, 0, len(points[0].coords))
+	}
+	return tree
+}
+
+func buildKDNode(points []kdPoint, depth, dims int) *kdNode {
+	if len(points) == 0 {
+		return nil
+	}
+
+	axis := depth % dims
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].coords[axis] < points[j].coords[axis]
+	})
+
+	median := len(points) / 2
+	return &kdNode{
+		point: points[median],
+		axis:  axis,
+		left:  buildKDNode(points[:median], depth+1, dims),
+		right: buildKDNode(points[median+1:], depth+1, dims),
+	}
+}
+
+// nearest returns the IDs of the k points closest to (x, y), nearest first.
+func (t *kdTree) nearest(x, y float64, k int) []uuid.UUID {
+	if t.root == nil || k <= 0 {
+		return nil
+	}
+
+	query := t.project(x, y)
+	candidates := &neighbourHeap{}
+	t.search(t.root, query, k, candidates)
+
+	ids := make([]uuid.UUID, candidates.Len())
+	for i := len(ids) - 1; i >= 0; i-- {
+		ids[i] = heap.Pop(candidates).(neighbour).id
+	}
+	return ids
+}
+
+func (t *kdTree) search(node *kdNode, query []float64, k int, candidates *neighbourHeap) {
+	if node == nil {
+		return
+	}
+
+	dist := squaredDistance(query, node.point.coords)
+	if candidates.Len() < k {
+		heap.Push(candidates, neighbour{id: node.point.id, dist: dist})
+	} else if dist < (*candidates)[0].dist {
+		(*candidates)[0] = neighbour{id: node.point.id, dist: dist}
+		heap.Fix(candidates, 0)
+	}
+
+	diff := query[node.axis] - node.point.coords[node.axis]
+	near, far := node.left, node.right
+	if diff > 0 {
+		near, far = node.right, node.left
+	}
+
+	t.search(near, query, k, candidates)
+
+	// Only descend into the far side if the splitting plane is closer than
+	// the current k-th best candidate
+	if candidates.Len() < k || diff*diff < (*candidates)[0].dist {
+		t.search(far, query, k, candidates)
+	}
+}
+
+func squaredDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+type neighbour struct {
+	id   uuid.UUID
+	dist float64
+}
+
+// neighbourHeap is a max-heap on distance holding the best k candidates.
+type neighbourHeap []neighbour
+
+func (h neighbourHeap) Len() int            { return len(h) }
+func (h neighbourHeap) Less(i, j int) bool  { return h[i].dist > h[j].dist }
+func (h neighbourHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *neighbourHeap) Push(x interface{}) { *h = append(*h, x.(neighbour)) }
+func (h *neighbourHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
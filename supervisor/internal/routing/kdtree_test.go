@@ -0,0 +1,112 @@
+package routing
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/models"
+	"github.com/google/uuid"
+)
+
+// randomNodes returns n healthy nodes at random coordinates within the
+// bounds of the distance mode.
+func randomNodes(rng *rand.Rand, n int, mode string) []models.Node {
+	nodes := make([]models.Node, n)
+	for i := range nodes {
+		x, y := rng.Float64()*1000, rng.Float64()*1000
+		if mode != DistanceModeEuclidean {
+			x, y = rng.Float64()*360-180, rng.Float64()*180-90
+		}
+		nodes[i] = testNode(fmt.Sprintf("node-%d", i), x, y)
+	}
+	return nodes
+}
+
+// bruteNearest returns the IDs of the k nodes closest to (x, y), nearest
+// first, by scanning every node.
+func bruteNearest(nodes []models.Node, x, y float64, k int, distance DistanceFunc) []uuid.UUID {
+	sorted := append([]models.Node(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return distance(x, y, sorted[i].LocationX, sorted[i].LocationY) < distance(x, y, sorted[j].LocationX, sorted[j].LocationY)
+	})
+
+	ids := make([]uuid.UUID, min(k, len(sorted)))
+	for i := range ids {
+		ids[i] = sorted[i].ID
+	}
+	return ids
+}
+
+func TestKDTreeMatchesBruteForce(t *testing.T) {
+	for _, mode := range []string{DistanceModeEuclidean, DistanceModeHaversine, DistanceModeProjected} {
+		t.Run(mode, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			cfg := config.RoutingConfig{DistanceMode: mode, ProjectionLatitude: 45}
+			distance := distanceFor(cfg)
+			nodes := randomNodes(rng, 500, mode)
+			tree := newKDTree(nodes, projectionFor(cfg))
+
+			for q := 0; q < 100; q++ {
+				query := nodes[rng.Intn(len(nodes))]
+				x, y := query.LocationX+rng.Float64()-0.5, query.LocationY+rng.Float64()-0.5
+				for _, k := range []int{1, 3, 10, len(nodes) + 1} {
+					got := tree.nearest(x, y, k)
+					want := bruteNearest(nodes, x, y, k, distance)
+					if fmt.Sprint(got) != fmt.Sprint(want) {
+						t.Fatalf("nearest(%.3f, %.3f, %d) = %v, want %v", x, y, k, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestKDTreeEmpty(t *testing.T) {
+	tree := newKDTree(nil, planarProjection)
+	if ids := tree.nearest(0, 0, 3); len(ids) != 0 {
+		t.Fatalf("nearest on an empty tree = %v, want none", ids)
+	}
+}
+
+func BenchmarkKNearest(b *testing.B) {
+	for _, n := range []int{10, 1000, 100000} {
+		rng := rand.New(rand.NewSource(1))
+		nodes := randomNodes(rng, n, DistanceModeEuclidean)
+		tree := newKDTree(nodes, planarProjection)
+
+		b.Run(fmt.Sprintf("kdtree/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree.nearest(rng.Float64()*1000, rng.Float64()*1000, 3)
+			}
+		})
+		b.Run(fmt.Sprintf("linear/%d", n), func(b *testing.B) {
+			cfg := config.RoutingConfig{KNearest: 3, AllowOverflow: true}
+			for i := 0; i < b.N; i++ {
+				FindKNearestNodesBy(nodes, rng.Float64()*1000, rng.Float64()*1000, cfg, CalculateDistance)
+			}
+		})
+	}
+}
+
+func TestNodeIndexFollowsNodeSet(t *testing.T) {
+	cfg := testConfig()
+	cfg.KNearest = 1
+	s := NewService(nil, cfg, 0)
+	all := func(models.Node) bool { return true }
+
+	nodes := testGrid(kdTreeMinNodes*2, 0, 0)
+	if found := s.findKNearest(nodes, models.Location{}, cfg, all); len(found) != 1 || found[0].ID != nodes[0].ID {
+		t.Fatalf("found %v, want the node at the origin", found)
+	}
+
+	// Swap the node at the origin for another one, keeping the count
+	swapped := append([]models.Node(nil), nodes...)
+	swapped[0] = testNode("replacement", 0, 0)
+	found := s.findKNearest(swapped, models.Location{}, cfg, all)
+	if len(found) != 1 || found[0].ID != swapped[0].ID {
+		t.Fatalf("found %v after the swap, want the replacement node", found)
+	}
+}
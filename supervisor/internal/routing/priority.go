@@ -0,0 +1,47 @@
+package routing
+
+import (
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/models"
+)
+
+// Request priorities. High priority traffic may use nodes up to full load so
+// it is still served under contention; normal and low priority traffic stays
+// off nodes above their configured load thresholds.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// NormalizePriority maps unknown or empty priorities to PriorityNormal.
+func NormalizePriority(priority string) string {
+	switch priority {
+	case PriorityHigh, PriorityNormal, PriorityLow:
+		return priority
+	default:
+		return PriorityNormal
+	}
+}
+
+// LoadThreshold returns the highest load score a node may have to accept a
+// request of the given priority.
+func LoadThreshold(cfg config.RoutingConfig, priority string) float64 {
+	switch NormalizePriority(priority) {
+	case PriorityHigh:
+		return 1.0
+	case PriorityLow:
+		return cfg.LowLoadThreshold
+	default:
+		return cfg.NormalLoadThreshold
+	}
+}
+
+// eligibleFor returns a filter accepting nodes whose load is within the
+// threshold for the priority.
+func eligibleFor(cfg config.RoutingConfig, priority string) func(models.Node) bool {
+	threshold := LoadThreshold(cfg, priority)
+	return func(node models.Node) bool {
+		return CalculateLoadScore(node, cfg.LoadScoreWeights) <= threshold
+	}
+}
@@ -0,0 +1,70 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"arx-supervisor/internal/models"
+)
+
+// loadedNode returns a healthy node at (x, 0) whose load score is load under
+// the default load score weights.
+func loadedNode(name string, x, load float64) models.Node {
+	node := testNode(name, x, 0)
+	node.CPUUsage = load * 100
+	node.MemoryUsage = load * 100
+	node.ActiveConnections = int(load * 100)
+	return node
+}
+
+func TestPriorityEligibility(t *testing.T) {
+	// Nearest first, so the nearest eligible node is always picked
+	nodes := []models.Node{
+		loadedNode("hot", 1, 0.9),
+		loadedNode("warm", 2, 0.7),
+		loadedNode("cool", 3, 0.3),
+	}
+
+	tests := []struct {
+		priority string
+		eligible []string
+		picked   string
+	}{
+		{priority: PriorityHigh, eligible: []string{"hot", "warm", "cool"}, picked: "hot"},
+		{priority: PriorityNormal, eligible: []string{"warm", "cool"}, picked: "warm"},
+		{priority: PriorityLow, eligible: []string{"cool"}, picked: "cool"},
+		{priority: "urgent", eligible: []string{"warm", "cool"}, picked: "warm"},
+		{priority: "", eligible: []string{"warm", "cool"}, picked: "warm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.priority), func(t *testing.T) {
+			cfg := testConfig()
+			cfg.LoadWeight = 0
+			cfg.DistanceWeight = 1
+			cfg.LowLoadThreshold = 0.6
+
+			var eligible []string
+			within := eligibleFor(cfg, tt.priority)
+			for _, node := range nodes {
+				if within(node) {
+					eligible = append(eligible, node.Name)
+				}
+			}
+			if fmt.Sprint(eligible) != fmt.Sprint(tt.eligible) {
+				t.Errorf("eligible nodes = %v, want %v", eligible, tt.eligible)
+			}
+
+			s := NewService(nil, cfg, 0)
+			result, _, err := s.routeOn(context.Background(), Request{RequestID: "req-1", Priority: tt.priority}, nodes, cfg, time.Now())
+			if err != nil || result == nil {
+				t.Fatalf("routeOn = %v, %v", result, err)
+			}
+			if result.Node.Name != tt.picked {
+				t.Errorf("picked %q, want %q", result.Node.Name, tt.picked)
+			}
+		})
+	}
+}
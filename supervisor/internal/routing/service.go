@@ -2,29 +2,251 @@ package routing
 
 import (
 	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"arx-supervisor/internal/config"
 	"arx-supervisor/internal/database"
 	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/metrics"
 	"arx-supervisor/internal/models"
+	"arx-supervisor/internal/tracing"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Service struct {
 	db *database.Database
+	// config holds the active routing weights; it is swapped atomically when
+	// operators update them at runtime
+	config     atomic.Pointer[config.RoutingConfig]
+	distance   DistanceFunc
+	projection projection
+	// maxCheckAge excludes healthy nodes not checked within it when they are
+	// loaded from the database; zero loads every healthy node. It follows the
+	// health check interval, so it changes on reload.
+	maxCheckAge atomic.Int64
+
+	indexMu sync.RWMutex
+	index   *kdTree
+	ring    *hashRing
+
+	// cache holds the last healthy node snapshot. Readers load it without
+	// locking; cacheGen is bumped on invalidation so a refresh that raced
+	// with it is discarded rather than stored.
+	cache    atomic.Pointer[nodeCache]
+	cacheGen atomic.Uint64
+
+	// lastGood is the most recent healthy node set read from the database,
+	// kept through invalidation so routing can go on while the database is
+	// unreachable. stale is set while routes are served from it.
+	lastGood atomic.Pointer[nodeSnapshot]
+	stale    atomic.Bool
+
+	inFlight  inFlight
+	failures  reportedFailures
+	cooldowns selectionCooldowns
+}
+
+// nodeCache is an immutable snapshot of the healthy nodes. The slice is
+// shared between requests and must not be modified.
+type nodeCache struct {
+	nodes   []models.Node
+	gen     uint64
+	expires time.Time
+}
+
+// nodeSnapshot is a healthy node set and when it was read.
+type nodeSnapshot struct {
+	nodes []models.Node
+	at    time.Time
+}
+
+// Routing modes reported in RouteResult.Mode
+const (
+	ModeNearest        = "nearest"
+	ModeSticky         = "sticky"
+	ModeStickyFallback = "sticky_fallback"
+)
+
+// Request describes a single request to be routed.
+type Request struct {
+	RequestID   string
+	Coordinates models.Location
+	// ClientID enables sticky routing: requests with the same client ID are
+	// sent to the same node for as long as it stays healthy.
+	ClientID string
+	// Priority is one of PriorityHigh, PriorityNormal or PriorityLow and
+	// decides how loaded a node may be to accept the request.
+	Priority string
+	// Explain fills RouteResult.Candidates even when sticky routing would
+	// otherwise skip ranking the nearest nodes.
+	Explain bool
+	// PreferredZone restricts routing to nodes in that zone, spilling over to
+	// other zones only when none of them can take the request.
+	PreferredZone string
+	// RequiredLabels restricts routing to nodes carrying all of these labels
+	// with the same values. Unlike the preferred zone it never spills over.
+	RequiredLabels map[string]string
+	// Cluster restricts routing to the nodes of that cluster. Like required
+	// labels it never spills over.
+	Cluster uuid.UUID
+	// Exclude keeps the request off a node, typically one the client just
+	// reported as failing it.
+	Exclude uuid.UUID
+	// Preview leaves no trace: the selected node is not charged an in-flight
+	// request.
+	Preview bool
+	// Seed replaces the request ID as the seed of every randomized choice,
+	// tie-breaking and p2c sampling, so a decision can be replayed under
+	// another request ID. Empty falls back to RoutingConfig.Seed.
+	Seed string
+}
+
+// seed returns what the randomized choices of req are seeded with: its own
+// seed, the configured default or else its request ID, which still varies
+// between requests while keeping retries and previews of one request alike.
+func (req Request) seed(cfg config.RoutingConfig) string {
+	switch {
+	case req.Seed != "":
+		return req.Seed
+	case cfg.Seed != "":
+		return cfg.Seed
+	}
+	return req.RequestID
 }
 
-func NewService(database *database.Database) *Service {
-	return &Service{
-		db: database,
+// RouteResult is the node chosen for a request and how it was chosen.
+// Fallbacks are the next best candidates, best first, for clients to try if
+// the chosen node fails. Candidates holds every node that was scored, best
+// first; it may be empty for sticky routes unless Request.Explain is set.
+// Stale is set when the database was unreachable and the route was chosen
+// from the last known healthy nodes.
+type RouteResult struct {
+	ScoredNode
+	Mode       string
+	Fallbacks  []ScoredNode
+	Candidates []ScoredNode
+	Stale      bool
+}
+
+func NewService(database *database.Database, cfg config.RoutingConfig, maxCheckAge time.Duration) *Service {
+	if cfg.CoordinateSystem == "" {
+		cfg.CoordinateSystem = CoordinateSystemFor(cfg.DistanceMode)
+	}
+	s := &Service{
+		db:         database,
+		distance:   distanceFor(cfg),
+		projection: projectionFor(cfg),
+	}
+	s.config.Store(&cfg)
+	s.maxCheckAge.Store(int64(maxCheckAge))
+	return s
+}
+
+// InvalidateIndex drops the spatial index and sticky-session hash ring so they
+// are rebuilt from the current node set on the next route. Call it whenever
+// nodes are registered, updated, deleted or change health status.
+func (s *Service) InvalidateIndex() {
+	s.InvalidateCache()
+	s.indexMu.Lock()
+	s.index = nil
+	s.ring = nil
+	s.indexMu.Unlock()
+}
+
+// InvalidateCache drops the cached healthy node set so the next route reads
+// it from the database. InvalidateIndex also invalidates the cache.
+func (s *Service) InvalidateCache() {
+	s.cacheGen.Add(1)
+	s.cache.Store(nil)
+}
+
+// healthyNodes returns the healthy nodes, served from the cache while it is
+// fresh. A NodeCacheTTL of zero disables caching. While the database is
+// unreachable the last known healthy nodes are returned and stale is set.
+func (s *Service) healthyNodes(ctx context.Context, cfg config.RoutingConfig) (nodes []models.Node, stale bool, err error) {
+	ctx, span := tracing.Start(ctx, "routing.GetHealthyNodes")
+	defer span.End()
+
+	ttl := time.Duration(cfg.NodeCacheTTL) * time.Second
+	gen := s.cacheGen.Load()
+	if ttl > 0 {
+		if cached := s.cache.Load(); cached != nil && cached.gen == gen && time.Now().Before(cached.expires) {
+			metrics.NodeCacheLookups.WithLabelValues(metrics.CacheHit).Inc()
+			span.SetAttributes(attribute.Bool("routing.cache_hit", true), attribute.Int("routing.nodes", len(cached.nodes)))
+			return cached.nodes, false, nil
+		}
+		metrics.NodeCacheLookups.WithLabelValues(metrics.CacheMiss).Inc()
+	}
+	span.SetAttributes(attribute.Bool("routing.cache_hit", false))
+
+	// Nodes that have not reported within maxCheckAge are left out by the
+	// query itself so their last stats are never routed on
+	var checkedSince pgtype.Timestamp
+	if maxCheckAge := time.Duration(s.maxCheckAge.Load()); maxCheckAge > 0 {
+		checkedSince = pgtype.Timestamp{Time: time.Now().Add(-maxCheckAge).UTC(), Valid: true}
+	}
+	var rows []db.Node
+	err = s.db.Guarded(func() (err error) {
+		rows, err = s.db.Queries.GetHealthyNodes(ctx, checkedSince)
+		return err
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		if last := s.lastGood.Load(); last != nil && database.IsUnavailable(err) && ctx.Err() == nil {
+			if !s.stale.Swap(true) {
+				slog.WarnContext(ctx, "Database unavailable, routing from last known healthy nodes",
+					"nodes", len(last.nodes), "age", time.Since(last.at).Round(time.Second).String(), "error", err)
+			}
+			span.SetAttributes(attribute.Bool("routing.stale", true))
+			return last.nodes, true, nil
+		}
+		return nil, false, err
+	}
+	if s.stale.Swap(false) {
+		slog.InfoContext(ctx, "Database available again, routing from current nodes")
+	}
+	span.SetAttributes(attribute.Int("routing.nodes", len(rows)))
+
+	modelNodes := make([]models.Node, len(rows))
+	for i, node := range rows {
+		modelNodes[i] = ConvertDBNodeToModel(node)
+	}
+
+	s.lastGood.Store(&nodeSnapshot{nodes: modelNodes, at: time.Now()})
+	if ttl > 0 && s.cacheGen.Load() == gen {
+		s.cache.Store(&nodeCache{nodes: modelNodes, gen: gen, expires: time.Now().Add(ttl)})
 	}
+	return modelNodes, false, nil
 }
 
-func convertDBNodeToModel(node db.Node) models.Node {
+// ConvertDBNodeToModel maps a sqlc node row onto the API model.
+func ConvertDBNodeToModel(node db.Node) models.Node {
 	var lastHealthCheck *time.Time
 	if node.LastHealthCheck.Valid {
 		lastHealthCheck = &node.LastHealthCheck.Time
 	}
+	var lastHeartbeat *time.Time
+	if node.LastHeartbeat.Valid {
+		lastHeartbeat = &node.LastHeartbeat.Time
+	}
+	var drainingSince *time.Time
+	if node.DrainingSince.Valid {
+		drainingSince = &node.DrainingSince.Time
+	}
+	var deletedAt *time.Time
+	if node.DeletedAt.Valid {
+		deletedAt = &node.DeletedAt.Time
+	}
+	var clusterID *uuid.UUID
+	if node.ClusterID.Valid {
+		id := uuid.UUID(node.ClusterID.Bytes)
+		clusterID = &id
+	}
 
 	// Convert pgtype.UUID to uuid.UUID
 	nodeUUID, err := uuid.FromBytes(node.ID.Bytes[:])
@@ -39,38 +261,383 @@ func convertDBNodeToModel(node db.Node) models.Node {
 		LocationY:         node.LocationY,
 		Endpoint:          node.Endpoint,
 		Capacity:          int(node.Capacity.Int32),
+		Weight:            int(node.Weight),
+		Zone:              node.Zone,
+		HealthPath:        node.HealthPath,
+		HealthProtocol:    node.HealthProtocol,
+		Labels:            models.DecodeLabels(node.Labels),
+		ClusterID:         clusterID,
 		Status:            node.Status.String,
+		Maintenance:       node.Maintenance,
 		CPUUsage:          node.CpuUsage.Float64,
 		MemoryUsage:       node.MemoryUsage.Float64,
 		ActiveConnections: int(node.ActiveConnections.Int32),
 		LastHealthCheck:   lastHealthCheck,
+		LastHeartbeat:     lastHeartbeat,
+		DrainingSince:     drainingSince,
+		DeletedAt:         deletedAt,
 		CreatedAt:         node.CreatedAt.Time,
 		UpdatedAt:         node.UpdatedAt.Time,
 	}
 }
 
-func (s *Service) RouteRequest(ctx context.Context, requestID string, coordinates models.Location) (*models.Node, error) {
-	// Get all healthy nodes
-	nodes, err := s.db.Queries.GetHealthyNodes(ctx)
+// RouteRequest selects a node for req. The result is nil when no node can
+// take the request; the decision explains the outcome either way.
+func (s *Service) RouteRequest(ctx context.Context, req Request) (*RouteResult, *Decision, error) {
+	// Use one snapshot of the config for the whole request
+	cfg := s.Config()
+
+	modelNodes, stale, err := s.healthyNodes(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, decision, err := s.routeOn(ctx, req, modelNodes, cfg, time.Now())
+	markStale(result, decision, stale)
+	return result, decision, err
+}
+
+// markStale flags a route chosen from the last known healthy nodes.
+func markStale(result *RouteResult, decision *Decision, stale bool) {
+	if result != nil {
+		result.Stale = stale
+	}
+	if decision != nil {
+		decision.Stale = stale
+	}
+}
+
+// BatchResult is the outcome of one request of a batch. Result is nil when
+// no node could take the request, and Err is set when routing it failed.
+type BatchResult struct {
+	Result   *RouteResult
+	Decision *Decision
+	Err      error
+}
+
+// RouteBatch routes each request in turn against a single snapshot of the
+// config and healthy nodes, so the whole batch sees a consistent view. The
+// results are in request order; the error is only set when the snapshot
+// could not be loaded.
+func (s *Service) RouteBatch(ctx context.Context, reqs []Request) ([]BatchResult, error) {
+	cfg := s.Config()
+	modelNodes, stale, err := s.healthyNodes(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert to models
-	modelNodes := make([]models.Node, len(nodes))
-	for i, node := range nodes {
-		modelNodes[i] = convertDBNodeToModel(node)
+	now := time.Now()
+	results := make([]BatchResult, len(reqs))
+	for i, req := range reqs {
+		results[i].Result, results[i].Decision, results[i].Err = s.routeOn(ctx, req, modelNodes, cfg, now)
+		markStale(results[i].Result, results[i].Decision, stale)
+	}
+	return results, nil
+}
+
+// routeOn routes a request against a snapshot of the healthy nodes, trying
+// the preferred zone first when one is given.
+func (s *Service) routeOn(ctx context.Context, req Request, modelNodes []models.Node, cfg config.RoutingConfig, now time.Time) (*RouteResult, *Decision, error) {
+	// Nodes that stopped reporting, are in maintenance, lack a required
+	// label or sit outside the requested cluster are never routed to,
+	// whatever their load
+	withinLoad := eligibleFor(cfg, req.Priority)
+	eligible := func(node models.Node) bool {
+		return !node.Maintenance && !IsExpired(node, cfg, now) && node.ID != req.Exclude &&
+			node.InCluster(req.Cluster) && node.HasLabels(req.RequiredLabels) && withinLoad(node)
+	}
+
+	if req.PreferredZone != "" {
+		inZone := func(node models.Node) bool {
+			return node.Zone == req.PreferredZone && eligible(node)
+		}
+		result, err := s.route(ctx, req, modelNodes, cfg, inZone)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result != nil {
+			s.charge(req, result, cfg)
+			return result, s.decide(req, modelNodes, cfg, now, result, true), nil
+		}
+	}
+
+	result, err := s.route(ctx, req, modelNodes, cfg, eligible)
+	if err != nil {
+		return nil, nil, err
 	}
+	s.charge(req, result, cfg)
+	return result, s.decide(req, modelNodes, cfg, now, result, false), nil
+}
+
+// charge counts the request against the selected node so concurrent routes
+// see it in the node's load until InFlightTTL passes, and starts the node's
+// selection cooldown.
+func (s *Service) charge(req Request, result *RouteResult, cfg config.RoutingConfig) {
+	if result == nil || req.Preview {
+		return
+	}
+	if cfg.InFlightTTL > 0 {
+		s.inFlight.acquire(result.Node.ID, time.Duration(cfg.InFlightTTL)*time.Second)
+	}
+	if cfg.SelectionCooldown > 0 {
+		s.cooldowns.mark(result.Node.ID, time.Now())
+	}
+}
+
+// route selects a node among the healthy nodes passing the eligibility
+// filter, trying the client's sticky node first.
+func (s *Service) route(ctx context.Context, req Request, modelNodes []models.Node, cfg config.RoutingConfig, eligible func(models.Node) bool) (*RouteResult, error) {
+	mode := ModeNearest
+	if req.ClientID != "" {
+		// Sticky routing needs every node for the hash ring, so it is skipped
+		// while the database is unreachable
+		if result, ok, err := s.routeSticky(ctx, req, modelNodes, cfg, eligible); err != nil && !database.IsUnavailable(err) {
+			return nil, err
+		} else if ok {
+			if cfg.Fallbacks > 0 || req.Explain {
+				result.Candidates = s.rankNearest(ctx, modelNodes, req.Coordinates, cfg, eligible, req.seed(cfg))
+				result.Fallbacks = fallbacks(result.Candidates, result.Node.ID, cfg.Fallbacks)
+			}
+			return result, nil
+		}
+		mode = ModeStickyFallback
+	}
+
+	// Rank the k nearest nodes by weighted load and distance
+	ranked := s.rankNearest(ctx, modelNodes, req.Coordinates, cfg, eligible, req.seed(cfg))
+	if len(ranked) == 0 {
+		return nil, nil // No eligible healthy nodes within MaxDistance
+	}
+	switch cfg.Strategy {
+	case StrategyP2C:
+		ranked = twoChoices(ranked, req.seed(cfg))
+	case StrategyLeastConn:
+		var pending func(uuid.UUID) int
+		if cfg.InFlightTTL > 0 {
+			pending = s.inFlight.pending
+		}
+		ranked = leastConnections(ranked, pending)
+	}
+	return &RouteResult{
+		ScoredNode: ranked[0],
+		Mode:       mode,
+		Fallbacks:  fallbacks(ranked, ranked[0].Node.ID, cfg.Fallbacks),
+		Candidates: ranked,
+	}, nil
+}
+
+// rankNearest returns the eligible k nearest nodes, best first. Standby
+// nodes are only considered when no other node is eligible. The seed rotates
+// ties between equally scored nodes.
+func (s *Service) rankNearest(ctx context.Context, nodes []models.Node, coordinates models.Location, cfg config.RoutingConfig, eligible func(models.Node) bool, seed string) []ScoredNode {
+	_, span := tracing.Start(ctx, "routing.FindKNearestNodes")
+	candidates := s.findKNearest(nodes, coordinates, cfg, func(node models.Node) bool {
+		return !IsStandby(node) && eligible(node)
+	})
+	if len(candidates) == 0 {
+		candidates = s.findKNearest(nodes, coordinates, cfg, eligible)
+	}
+	span.SetAttributes(attribute.Int("routing.candidates", len(candidates)))
+	span.End()
+
+	_, span = tracing.Start(ctx, "routing.SelectBestNode")
+	defer span.End()
+	adjust := loadAdjustments{
+		failed:  s.failures.active,
+		cooling: s.cooldownCheck(time.Duration(cfg.SelectionCooldown) * time.Millisecond),
+	}
+	if cfg.InFlightTTL > 0 {
+		adjust.pending = s.inFlight.pending
+	}
+	return rankNodesWeighted(candidates, coordinates, cfg, s.distance, seed, adjust)
+}
+
+// fallbacks returns up to limit ranked nodes other than the selected one.
+func fallbacks(ranked []ScoredNode, selected uuid.UUID, limit int) []ScoredNode {
+	var result []ScoredNode
+	for _, candidate := range ranked {
+		if len(result) == limit {
+			break
+		}
+		if candidate.Node.ID != selected {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+// routeSticky routes to the node owning the client ID on the hash ring. It
+// reports false when that node is not currently healthy, is out of range, is
+// too loaded for the request's priority or is a standby, in which case the
+// caller falls back to nearest-node selection.
+func (s *Service) routeSticky(ctx context.Context, req Request, healthy []models.Node, cfg config.RoutingConfig, eligible func(models.Node) bool) (*RouteResult, bool, error) {
+	ring, err := s.hashRing(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ownerID, ok := ring.owner(req.ClientID)
+	if !ok {
+		return nil, false, nil
+	}
+
+	for _, node := range healthy {
+		if node.ID != ownerID {
+			continue
+		}
+		if !eligible(node) || IsSaturated(node) || IsStandby(node) {
+			return nil, false, nil
+		}
+
+		distance := s.Distance(req.Coordinates, node)
+		if cfg.MaxDistance > 0 && distance > cfg.MaxDistance {
+			return nil, false, nil
+		}
+		return &RouteResult{
+			ScoredNode: ScoredNode{
+				Node:      node,
+				Distance:  distance,
+				LoadScore: CalculateLoadScore(node, cfg.LoadScoreWeights),
+			},
+			Mode: ModeSticky,
+		}, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// hashRing returns the consistent hash ring over all registered nodes,
+// rebuilding it after invalidation.
+func (s *Service) hashRing(ctx context.Context) (*hashRing, error) {
+	s.indexMu.RLock()
+	ring := s.ring
+	s.indexMu.RUnlock()
+	if ring != nil {
+		return ring, nil
+	}
+
+	var nodes []models.Node
+	err := s.db.Guarded(func() (err error) {
+		nodes, err = s.GetAllNodes(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ring = newHashRing(nodes)
+	s.indexMu.Lock()
+	s.ring = ring
+	s.indexMu.Unlock()
+	return ring, nil
+}
+
+// findKNearest returns the KNearest healthy nodes within MaxDistance that
+// pass the eligibility filter, falling back to saturated nodes as described
+// on FindKNearestNodesBy. Large node sets are answered from the kd-tree index,
+// small ones by linear scan.
+func (s *Service) findKNearest(nodes []models.Node, coordinates models.Location, cfg config.RoutingConfig, eligible func(models.Node) bool) []models.Node {
+	if len(nodes) < kdTreeMinNodes {
+		candidates := make([]models.Node, 0, len(nodes))
+		for _, node := range nodes {
+			if eligible(node) {
+				candidates = append(candidates, node)
+			}
+		}
+		return FindKNearestNodesBy(candidates, coordinates.X, coordinates.Y, cfg, s.distance)
+	}
+
+	byID := make(map[uuid.UUID]models.Node, len(nodes))
+	for _, node := range nodes {
+		byID[node.ID] = node
+	}
+	index := s.nodeIndex(nodes)
+	want := clampK(cfg.KNearest, len(nodes))
+
+	// Widen the search until enough eligible nodes are found or every node
+	// within range has been considered
+	for k := want; ; k *= 2 {
+		ids := index.nearest(coordinates.X, coordinates.Y, k)
+		exhausted := len(ids) < k || k >= len(nodes)
+
+		// Resolve IDs against the fresh rows so selection sees current load
+		result := make([]models.Node, 0, want)
+		var saturated []models.Node
+		for _, id := range ids {
+			node, ok := byID[id]
+			if !ok || node.Status != "healthy" || !eligible(node) {
+				continue
+			}
+			if cfg.MaxDistance > 0 && s.Distance(coordinates, node) > cfg.MaxDistance {
+				// Results are ordered by distance, so the rest are farther
+				exhausted = true
+				break
+			}
+			if IsSaturated(node) {
+				if len(saturated) < want {
+					saturated = append(saturated, node)
+				}
+				continue
+			}
+			result = append(result, node)
+			if len(result) == want {
+				return result
+			}
+		}
 
-	// Find k nearest nodes
-	nearestNodes := FindKNearestNodes(modelNodes, coordinates.X, coordinates.Y, 3)
-	if len(nearestNodes) == 0 {
-		return nil, nil // No healthy nodes available
+		if exhausted {
+			if len(result) == 0 && cfg.AllowOverflow {
+				return saturated
+			}
+			return result
+		}
 	}
+}
+
+// nodeIndex returns the kd-tree for the healthy node set, rebuilding it if it
+// was invalidated or indexes other nodes. Nodes leave and rejoin the healthy
+// set without an invalidation, for instance when they miss the max check
+// age, so the index is keyed on the node IDs rather than their count.
+func (s *Service) nodeIndex(nodes []models.Node) *kdTree {
+	key := nodeSetKey(nodes)
+	s.indexMu.RLock()
+	index := s.index
+	s.indexMu.RUnlock()
+	if index != nil && index.nodes == key {
+		return index
+	}
+
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	if s.index == nil || s.index.nodes != key {
+		s.index = newKDTree(nodes, s.projection)
+	}
+	return s.index
+}
+
+// Geographic reports whether coordinates are longitude/latitude pairs.
+func (s *Service) Geographic() bool {
+	return s.Config().CoordinateSystem == CoordinateSystemGeographic
+}
 
-	// Select best node
-	selectedNode := SelectBestNode(nearestNodes)
-	return &selectedNode, nil
+// DistanceUnit is the unit of the distances returned by Distance.
+func (s *Service) DistanceUnit() string {
+	return DistanceUnitFor(s.Config().DistanceMode)
+}
+
+// Distance returns the distance between the coordinates and the node using
+// the configured distance mode.
+func (s *Service) Distance(coordinates models.Location, node models.Node) float64 {
+	return s.distance(coordinates.X, coordinates.Y, node.LocationX, node.LocationY)
+}
+
+// Nearby returns up to limit registered nodes within radius of the
+// coordinates, nearest first, as described on NodesWithin.
+func (s *Service) Nearby(ctx context.Context, coordinates models.Location, radius float64, limit int) ([]ScoredNode, error) {
+	nodes, err := s.GetAllNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NodesWithin(nodes, coordinates.X, coordinates.Y, radius, limit, s.distance), nil
 }
 
 func (s *Service) GetAllNodes(ctx context.Context) ([]models.Node, error) {
@@ -81,7 +648,7 @@ func (s *Service) GetAllNodes(ctx context.Context) ([]models.Node, error) {
 
 	modelNodes := make([]models.Node, len(nodes))
 	for i, node := range nodes {
-		modelNodes[i] = convertDBNodeToModel(node)
+		modelNodes[i] = ConvertDBNodeToModel(node)
 	}
 
 	return modelNodes, nil
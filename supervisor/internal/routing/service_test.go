@@ -0,0 +1,316 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/database"
+	"arx-supervisor/internal/db"
+	"arx-supervisor/internal/db/dbtest"
+	"arx-supervisor/internal/models"
+	"github.com/google/uuid"
+)
+
+// testConfig returns the routing defaults the supervisor starts with, minus
+// the in-process state that would carry over between routes.
+func testConfig() config.RoutingConfig {
+	return config.RoutingConfig{
+		KNearest:       3,
+		MaxDistance:    50,
+		LoadWeight:     0.6,
+		DistanceWeight: 0.4,
+		DistanceMode:   DistanceModeEuclidean,
+		Strategy:       StrategyBest,
+
+		CoordinateSystem: CoordinateSystemCartesian,
+		LoadScoreWeights: config.LoadScoreWeights{
+			CPU:         0.4,
+			Memory:      0.3,
+			Connections: 0.3,
+		},
+		NormalLoadThreshold: 0.8,
+		LowLoadThreshold:    0.8,
+		AllowOverflow:       true,
+		TieEpsilon:          DefaultTieEpsilon,
+	}
+}
+
+// testNode returns an idle healthy node at (x, y).
+func testNode(name string, x, y float64) models.Node {
+	return models.Node{
+		ID:        uuid.New(),
+		Name:      name,
+		LocationX: x,
+		LocationY: y,
+		Capacity:  100,
+		Weight:    1,
+		Status:    models.NodeStatusHealthy,
+	}
+}
+
+// testGrid returns n healthy nodes spread over a square grid one unit apart,
+// starting at (x, y).
+func testGrid(n int, x, y float64) []models.Node {
+	side := 1
+	for side*side < n {
+		side++
+	}
+	nodes := make([]models.Node, n)
+	for i := range nodes {
+		nodes[i] = testNode(fmt.Sprintf("node-%d", i), x+float64(i%side), y+float64(i/side))
+	}
+	return nodes
+}
+
+func TestRouteBeyondMaxDistance(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []models.Node
+	}{
+		{"linear scan", testGrid(5, 100, 100)},
+		{"kd-tree", testGrid(kdTreeMinNodes*2, 100, 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			s := NewService(nil, cfg, 0)
+			req := Request{RequestID: "req-1", Coordinates: models.Location{X: 0, Y: 0}}
+
+			if found := s.findKNearest(tt.nodes, req.Coordinates, cfg, func(models.Node) bool { return true }); len(found) != 0 {
+				t.Fatalf("findKNearest returned %d nodes beyond max distance", len(found))
+			}
+
+			result, decision, err := s.routeOn(context.Background(), req, tt.nodes, cfg, time.Now())
+			if err != nil {
+				t.Fatalf("routeOn: %v", err)
+			}
+			if result != nil {
+				t.Fatalf("routed to %s at distance %.1f, want no node", result.Node.Name, result.Distance)
+			}
+			if decision.Outcome != models.RoutingStatusFailed {
+				t.Errorf("outcome = %q, want %q", decision.Outcome, models.RoutingStatusFailed)
+			}
+			if got := decision.Reasons[ReasonTooFar]; got != len(decision.Nodes) || got == 0 {
+				t.Errorf("too_far reasons = %d, want every one of %d listed nodes", got, len(decision.Nodes))
+			}
+		})
+	}
+}
+
+func TestRouteWithinMaxDistance(t *testing.T) {
+	cfg := testConfig()
+	s := NewService(nil, cfg, 0)
+	near := testNode("near", 10, 0)
+	nodes := []models.Node{testNode("far", 100, 0), near}
+
+	result, _, err := s.routeOn(context.Background(), Request{RequestID: "req-1"}, nodes, cfg, time.Now())
+	if err != nil {
+		t.Fatalf("routeOn: %v", err)
+	}
+	if result == nil || result.Node.ID != near.ID {
+		t.Fatalf("routed to %v, want the node within max distance", result)
+	}
+	if len(result.Candidates) != 1 {
+		t.Errorf("ranked %d candidates, want only the node within max distance", len(result.Candidates))
+	}
+}
+
+// ringNodes returns n identical healthy nodes on a circle of the given
+// radius around the origin, so they are all equally near to it.
+func ringNodes(n int, radius float64) []models.Node {
+	nodes := make([]models.Node, n)
+	for i := range nodes {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		nodes[i] = testNode(fmt.Sprintf("ring-%d", i), radius*math.Cos(angle), radius*math.Sin(angle))
+	}
+	return nodes
+}
+
+func TestConcurrentRoutesSpread(t *testing.T) {
+	const routes = 100
+	tests := []struct {
+		name  string
+		nodes []models.Node
+	}{
+		{"linear scan", ringNodes(5, 1)},
+		{"kd-tree", append(ringNodes(5, 1), testGrid(kdTreeMinNodes, 20, 20)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The first node starts out clearly least loaded, so without
+			// in-flight counting every route would pick it
+			for i := 1; i < 5; i++ {
+				tt.nodes[i].CPUUsage = 5
+			}
+			cfg := testConfig()
+			cfg.KNearest = 5
+			cfg.InFlightTTL = 60
+			s := NewService(nil, cfg, 0)
+
+			var mu sync.Mutex
+			counts := make(map[string]int)
+			var wg sync.WaitGroup
+			start := make(chan struct{})
+			for i := 0; i < routes; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-start
+					req := Request{RequestID: fmt.Sprintf("req-%d", i)}
+					result, _, err := s.routeOn(context.Background(), req, tt.nodes, cfg, time.Now())
+					if err != nil || result == nil {
+						t.Errorf("route %d: result %v, error %v", i, result, err)
+						return
+					}
+					mu.Lock()
+					counts[result.Node.Name]++
+					mu.Unlock()
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+
+			// Each selection counts against its node, so the burst must
+			// spread over the equally near nodes instead of piling onto one
+			total := 0
+			for _, node := range tt.nodes[:5] {
+				total += counts[node.Name]
+				if counts[node.Name] > routes/2 {
+					t.Errorf("%s took %d of %d concurrent routes (%v)", node.Name, counts[node.Name], routes, counts)
+				}
+				if got := s.InFlight(node.ID); got != counts[node.Name] {
+					t.Errorf("%s has %d in-flight requests, want %d", node.Name, got, counts[node.Name])
+				}
+			}
+			if total != routes {
+				t.Errorf("%d of %d routes went to the nearest nodes (%v)", total, routes, counts)
+			}
+		})
+	}
+}
+
+func TestRouteRequestSkipsStaleNodes(t *testing.T) {
+	// The stale node is the nearest, so it wins whenever it is loaded
+	stale := dbtest.Node("stale", 1, 1)
+	stale.LastHealthCheck.Time = time.Now().Add(-10 * time.Minute).UTC()
+	fresh := dbtest.Node("fresh", 5, 5)
+	unhealthy := dbtest.Node("unhealthy", 0, 0)
+	unhealthy.Status.String = models.NodeStatusUnhealthy
+
+	tests := []struct {
+		name        string
+		nodes       []db.Node
+		maxCheckAge time.Duration
+		// want is the selected node, empty when no node should be
+		want string
+	}{
+		{"no cutoff loads every healthy node", []db.Node{stale, fresh, unhealthy}, 0, "stale"},
+		{"cutoff leaves stale nodes out", []db.Node{stale, fresh, unhealthy}, time.Minute, "fresh"},
+		{"cutoff leaves only stale nodes", []db.Node{stale, unhealthy}, time.Minute, ""},
+		{"unhealthy nodes are never loaded", []db.Node{unhealthy}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := dbtest.New(tt.nodes...)
+			s := NewService(&database.Database{Queries: queries}, testConfig(), tt.maxCheckAge)
+
+			before := time.Now()
+			result, _, err := s.RouteRequest(context.Background(), Request{RequestID: "req-1"})
+			if err != nil {
+				t.Fatalf("RouteRequest: %v", err)
+			}
+			got := ""
+			if result != nil {
+				got = result.Node.Name
+			}
+			if got != tt.want {
+				t.Errorf("routed to %q, want %q", got, tt.want)
+			}
+
+			if tt.maxCheckAge == 0 {
+				if queries.CheckedSince.Valid {
+					t.Errorf("queried with cutoff %v, want none", queries.CheckedSince.Time)
+				}
+				return
+			}
+			cutoff := before.Add(-tt.maxCheckAge)
+			if !queries.CheckedSince.Valid || queries.CheckedSince.Time.Sub(cutoff).Abs() > time.Second {
+				t.Errorf("queried with cutoff %v, want about %v", queries.CheckedSince.Time, cutoff.UTC())
+			}
+		})
+	}
+}
+
+func TestRouteRequestNodeReturnsFromStaleness(t *testing.T) {
+	// Enough nodes for the kd-tree, all farther than the two that trade
+	// places between staleness and freshness
+	var nodes []db.Node
+	for i := 0; i < kdTreeMinNodes*2; i++ {
+		nodes = append(nodes, dbtest.Node(fmt.Sprintf("node-%d", i), 20+float64(i%16), 20+float64(i/16)))
+	}
+	returning := dbtest.Node("returning", 1, 1)
+	leaving := dbtest.Node("leaving", 2, 2)
+	staleAt := time.Now().Add(-10 * time.Minute).UTC()
+
+	queries := dbtest.New()
+	s := NewService(&database.Database{Queries: queries}, testConfig(), time.Minute)
+	route := func() string {
+		t.Helper()
+		result, _, err := s.RouteRequest(context.Background(), Request{RequestID: "req-1"})
+		if err != nil || result == nil {
+			t.Fatalf("RouteRequest = %v, %v", result, err)
+		}
+		return result.Node.Name
+	}
+
+	returning.LastHealthCheck.Time = staleAt
+	queries.SetNodes(append(nodes, returning, leaving)...)
+	if got := route(); got != "leaving" {
+		t.Fatalf("routed to %q while the nearest node is stale, want %q", got, "leaving")
+	}
+
+	// The healthy set keeps its size, so only its IDs tell it changed
+	returning.LastHealthCheck.Time = time.Now().UTC()
+	leaving.LastHealthCheck.Time = staleAt
+	queries.SetNodes(append(nodes, returning, leaving)...)
+	if got := route(); got != "returning" {
+		t.Fatalf("routed to %q after the nearest node reported again, want %q", got, "returning")
+	}
+}
+
+func TestRouteRequestDatabaseDown(t *testing.T) {
+	queries := dbtest.New(dbtest.Node("node", 1, 1))
+	s := NewService(&database.Database{Queries: queries}, testConfig(), 0)
+	down := errors.New("connection refused")
+
+	// Without a snapshot to fall back on, the error is returned
+	queries.Err = down
+	if _, _, err := s.RouteRequest(context.Background(), Request{RequestID: "req-1"}); !errors.Is(err, down) {
+		t.Fatalf("RouteRequest error = %v, want %v", err, down)
+	}
+
+	queries.Err = nil
+	if result, _, err := s.RouteRequest(context.Background(), Request{RequestID: "req-2"}); err != nil || result == nil || result.Stale {
+		t.Fatalf("RouteRequest = %+v, %v, want a fresh route", result, err)
+	}
+
+	// Once nodes were read, routing goes on from them while the database
+	// is down
+	queries.Err = down
+	result, decision, err := s.RouteRequest(context.Background(), Request{RequestID: "req-3"})
+	if err != nil || result == nil {
+		t.Fatalf("RouteRequest = %+v, %v, want a route from the last known nodes", result, err)
+	}
+	if !result.Stale || !decision.Stale {
+		t.Error("route from the last known nodes is not marked stale")
+	}
+}
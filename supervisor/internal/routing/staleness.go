@@ -0,0 +1,41 @@
+package routing
+
+import (
+	"time"
+
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/models"
+)
+
+// statsAge returns how long ago the node's load stats were last refreshed by
+// a health check or heartbeat. Nodes that were never checked report false and
+// are treated as fresh, since their stats are all zero anyway.
+func statsAge(node models.Node, now time.Time) (time.Duration, bool) {
+	if node.LastHealthCheck == nil {
+		return 0, false
+	}
+	return now.Sub(*node.LastHealthCheck), true
+}
+
+// IsStale reports whether the node's load stats are older than
+// cfg.StaleAfter seconds and should no longer be trusted at face value.
+func IsStale(node models.Node, cfg config.RoutingConfig, now time.Time) bool {
+	age, ok := statsAge(node, now)
+	return ok && cfg.StaleAfter > 0 && age > time.Duration(cfg.StaleAfter)*time.Second
+}
+
+// IsExpired reports whether the node has not been checked for
+// cfg.ExcludeAfter seconds and must not receive traffic at all.
+func IsExpired(node models.Node, cfg config.RoutingConfig, now time.Time) bool {
+	age, ok := statsAge(node, now)
+	return ok && cfg.ExcludeAfter > 0 && age > time.Duration(cfg.ExcludeAfter)*time.Second
+}
+
+// stalePenalty is added to the load score of a node with stale stats so
+// nodes reporting fresh load are preferred over it.
+func stalePenalty(node models.Node, cfg config.RoutingConfig, now time.Time) float64 {
+	if IsStale(node, cfg, now) {
+		return cfg.StalePenalty
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package routing
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Selection strategies, chosen with ROUTING_STRATEGY
+const (
+	// StrategyBest routes to the best scored of the k nearest nodes.
+	StrategyBest = "best"
+	// StrategyP2C samples two of the k nearest nodes at random and routes to
+	// the less loaded one, so concurrent requests working from the same
+	// stats do not all pile onto a single node.
+	StrategyP2C = "p2c"
+	// StrategyLeastConn routes to the one of the k nearest nodes with the
+	// fewest active connections for its capacity and weight, ignoring CPU
+	// and memory, which suits long-lived connections.
+	StrategyLeastConn = "least-conn"
+)
+
+// twoChoices moves the less loaded of two randomly sampled candidates to the
+// front of ranked, keeping the others best first. The sample is drawn from a
+// source seeded by seed, typically the request ID, so a request always makes
+// the same choice for the same candidates.
+func twoChoices(ranked []ScoredNode, seed string) []ScoredNode {
+	if len(ranked) < 2 {
+		return ranked
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	rng := rand.New(rand.NewPCG(h.Sum64(), 0))
+
+	first := rng.IntN(len(ranked))
+	second := rng.IntN(len(ranked) - 1)
+	if second >= first {
+		second++
+	}
+
+	chosen := first
+	a, b := ranked[first], ranked[second]
+	if b.LoadScore < a.LoadScore || (b.LoadScore == a.LoadScore && second < first) {
+		chosen = second
+	}
+
+	reordered := make([]ScoredNode, 0, len(ranked))
+	reordered = append(reordered, ranked[chosen])
+	reordered = append(reordered, ranked[:chosen]...)
+	return append(reordered, ranked[chosen+1:]...)
+}
+
+// leastConnections reorders ranked by active connections per unit of
+// capacity, divided by the node's weight. pending, when set, adds the
+// requests routed to a node but not yet reported in its stats. Nodes with
+// equal ratios keep their ranked order.
+func leastConnections(ranked []ScoredNode, pending func(uuid.UUID) int) []ScoredNode {
+	ratio := func(node ScoredNode) float64 {
+		if node.Node.Capacity <= 0 {
+			return math.Inf(1)
+		}
+		connections := node.Node.ActiveConnections
+		if pending != nil {
+			connections += pending(node.Node.ID)
+		}
+		return applyWeight(float64(connections)/float64(node.Node.Capacity), node.Node)
+	}
+
+	reordered := append([]ScoredNode(nil), ranked...)
+	sort.SliceStable(reordered, func(i, j int) bool {
+		return ratio(reordered[i]) < ratio(reordered[j])
+	})
+	return reordered
+}
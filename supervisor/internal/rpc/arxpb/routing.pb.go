@@ -0,0 +1,986 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: proto/routing.proto
+
+package arxpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Location is a point given as X and Y, or as longitude and latitude when
+// the supervisor uses haversine distances.
+type Location struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float64                `protobuf:"fixed64,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,2,opt,name=y,proto3" json:"y,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Location) Reset() {
+	*x = Location{}
+	mi := &file_proto_routing_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Location) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Location) ProtoMessage() {}
+
+func (x *Location) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_routing_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Location.ProtoReflect.Descriptor instead.
+func (*Location) Descriptor() ([]byte, []int) {
+	return file_proto_routing_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Location) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Location) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+type RouteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Recorded with the request and used to rotate ties between nodes
+	RequestId   string    `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Coordinates *Location `protobuf:"bytes,2,opt,name=coordinates,proto3" json:"coordinates,omitempty"`
+	// Requests with the same client ID stick to the same node
+	ClientId string `protobuf:"bytes,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	// high, normal or low; empty means normal
+	Priority string `protobuf:"bytes,4,opt,name=priority,proto3" json:"priority,omitempty"`
+	// Route within this zone unless none of its nodes can take the request
+	PreferredZone string `protobuf:"bytes,5,opt,name=preferred_zone,json=preferredZone,proto3" json:"preferred_zone,omitempty"`
+	// Only route to nodes carrying all of these labels
+	RequiredLabels map[string]string `protobuf:"bytes,6,rep,name=required_labels,json=requiredLabels,proto3" json:"required_labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Only route to nodes of the cluster with this ID
+	Cluster       string `protobuf:"bytes,7,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouteRequest) Reset() {
+	*x = RouteRequest{}
+	mi := &file_proto_routing_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteRequest) ProtoMessage() {}
+
+func (x *RouteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_routing_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteRequest.ProtoReflect.Descriptor instead.
+func (*RouteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_routing_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RouteRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RouteRequest) GetCoordinates() *Location {
+	if x != nil {
+		return x.Coordinates
+	}
+	return nil
+}
+
+func (x *RouteRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *RouteRequest) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
+func (x *RouteRequest) GetPreferredZone() string {
+	if x != nil {
+		return x.PreferredZone
+	}
+	return ""
+}
+
+func (x *RouteRequest) GetRequiredLabels() map[string]string {
+	if x != nil {
+		return x.RequiredLabels
+	}
+	return nil
+}
+
+func (x *RouteRequest) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+// NodeInfo is a node selected for a request.
+type NodeInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Endpoint      string                 `protobuf:"bytes,3,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	Zone          string                 `protobuf:"bytes,4,opt,name=zone,proto3" json:"zone,omitempty"`
+	Distance      float64                `protobuf:"fixed64,5,opt,name=distance,proto3" json:"distance,omitempty"`
+	DistanceUnit  string                 `protobuf:"bytes,6,opt,name=distance_unit,json=distanceUnit,proto3" json:"distance_unit,omitempty"`
+	LoadScore     float64                `protobuf:"fixed64,7,opt,name=load_score,json=loadScore,proto3" json:"load_score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeInfo) Reset() {
+	*x = NodeInfo{}
+	mi := &file_proto_routing_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeInfo) ProtoMessage() {}
+
+func (x *NodeInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_routing_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeInfo.ProtoReflect.Descriptor instead.
+func (*NodeInfo) Descriptor() ([]byte, []int) {
+	return file_proto_routing_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *NodeInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetDistance() float64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *NodeInfo) GetDistanceUnit() string {
+	if x != nil {
+		return x.DistanceUnit
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetLoadScore() float64 {
+	if x != nil {
+		return x.LoadScore
+	}
+	return 0
+}
+
+type RouteResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	RequestId string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	RoutedTo  *NodeInfo              `protobuf:"bytes,2,opt,name=routed_to,json=routedTo,proto3" json:"routed_to,omitempty"`
+	// Backup nodes, best first
+	Fallbacks   []*NodeInfo `protobuf:"bytes,3,rep,name=fallbacks,proto3" json:"fallbacks,omitempty"`
+	RoutingMode string      `protobuf:"bytes,4,opt,name=routing_mode,json=routingMode,proto3" json:"routing_mode,omitempty"`
+	// Set when the database was unreachable and the node was chosen from the
+	// last known healthy nodes
+	Stale         bool `protobuf:"varint,5,opt,name=stale,proto3" json:"stale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RouteResponse) Reset() {
+	*x = RouteResponse{}
+	mi := &file_proto_routing_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RouteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouteResponse) ProtoMessage() {}
+
+func (x *RouteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_routing_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouteResponse.ProtoReflect.Descriptor instead.
+func (*RouteResponse) Descriptor() ([]byte, []int) {
+	return file_proto_routing_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RouteResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RouteResponse) GetRoutedTo() *NodeInfo {
+	if x != nil {
+		return x.RoutedTo
+	}
+	return nil
+}
+
+func (x *RouteResponse) GetFallbacks() []*NodeInfo {
+	if x != nil {
+		return x.Fallbacks
+	}
+	return nil
+}
+
+func (x *RouteResponse) GetRoutingMode() string {
+	if x != nil {
+		return x.RoutingMode
+	}
+	return ""
+}
+
+func (x *RouteResponse) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+type Node struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name              string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	LocationX         float64                `protobuf:"fixed64,3,opt,name=location_x,json=locationX,proto3" json:"location_x,omitempty"`
+	LocationY         float64                `protobuf:"fixed64,4,opt,name=location_y,json=locationY,proto3" json:"location_y,omitempty"`
+	Endpoint          string                 `protobuf:"bytes,5,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	Capacity          int32                  `protobuf:"varint,6,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	Weight            int32                  `protobuf:"varint,7,opt,name=weight,proto3" json:"weight,omitempty"`
+	Zone              string                 `protobuf:"bytes,8,opt,name=zone,proto3" json:"zone,omitempty"`
+	HealthPath        string                 `protobuf:"bytes,9,opt,name=health_path,json=healthPath,proto3" json:"health_path,omitempty"`
+	HealthProtocol    string                 `protobuf:"bytes,10,opt,name=health_protocol,json=healthProtocol,proto3" json:"health_protocol,omitempty"`
+	Status            string                 `protobuf:"bytes,11,opt,name=status,proto3" json:"status,omitempty"`
+	CpuUsage          float64                `protobuf:"fixed64,12,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"`
+	MemoryUsage       float64                `protobuf:"fixed64,13,opt,name=memory_usage,json=memoryUsage,proto3" json:"memory_usage,omitempty"`
+	ActiveConnections int32                  `protobuf:"varint,14,opt,name=active_connections,json=activeConnections,proto3" json:"active_connections,omitempty"`
+	// Unset until the node is first checked
+	LastHealthCheck *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=last_health_check,json=lastHealthCheck,proto3" json:"last_health_check,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,16,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt       *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Nodes in maintenance are health-checked but not routed to
+	Maintenance bool              `protobuf:"varint,18,opt,name=maintenance,proto3" json:"maintenance,omitempty"`
+	Labels      map[string]string `protobuf:"bytes,19,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Empty when the node belongs to no cluster
+	ClusterId     string `protobuf:"bytes,20,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Node) Reset() {
+	*x = Node{}
+	mi := &file_proto_routing_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Node) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Node) ProtoMessage() {}
+
+func (x *Node) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_routing_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Node.ProtoReflect.Descriptor instead.
+func (*Node) Descriptor() ([]byte, []int) {
+	return file_proto_routing_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Node) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Node) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Node) GetLocationX() float64 {
+	if x != nil {
+		return x.LocationX
+	}
+	return 0
+}
+
+func (x *Node) GetLocationY() float64 {
+	if x != nil {
+		return x.LocationY
+	}
+	return 0
+}
+
+func (x *Node) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *Node) GetCapacity() int32 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *Node) GetWeight() int32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *Node) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *Node) GetHealthPath() string {
+	if x != nil {
+		return x.HealthPath
+	}
+	return ""
+}
+
+func (x *Node) GetHealthProtocol() string {
+	if x != nil {
+		return x.HealthProtocol
+	}
+	return ""
+}
+
+func (x *Node) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Node) GetCpuUsage() float64 {
+	if x != nil {
+		return x.CpuUsage
+	}
+	return 0
+}
+
+func (x *Node) GetMemoryUsage() float64 {
+	if x != nil {
+		return x.MemoryUsage
+	}
+	return 0
+}
+
+func (x *Node) GetActiveConnections() int32 {
+	if x != nil {
+		return x.ActiveConnections
+	}
+	return 0
+}
+
+func (x *Node) GetLastHealthCheck() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastHealthCheck
+	}
+	return nil
+}
+
+func (x *Node) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Node) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Node) GetMaintenance() bool {
+	if x != nil {
+		return x.Maintenance
+	}
+	return false
+}
+
+func (x *Node) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Node) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+type GetNodesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Defaults to 100, at most 500
+	Limit  int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset int32 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Only nodes with this status when set
+	Status string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// Only nodes in this zone when set
+	Zone string `protobuf:"bytes,4,opt,name=zone,proto3" json:"zone,omitempty"`
+	// Only nodes carrying all of these labels when set
+	Labels map[string]string `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Only nodes of the cluster with this ID when set
+	Cluster       string `protobuf:"bytes,6,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNodesRequest) Reset() {
+	*x = GetNodesRequest{}
+	mi := &file_proto_routing_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNodesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNodesRequest) ProtoMessage() {}
+
+func (x *GetNodesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_routing_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNodesRequest.ProtoReflect.Descriptor instead.
+func (*GetNodesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_routing_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetNodesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetNodesRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *GetNodesRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GetNodesRequest) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *GetNodesRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *GetNodesRequest) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+type GetNodesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Items []*Node                `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	// Nodes matching the filters before paging
+	Total         int64 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNodesResponse) Reset() {
+	*x = GetNodesResponse{}
+	mi := &file_proto_routing_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNodesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNodesResponse) ProtoMessage() {}
+
+func (x *GetNodesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_routing_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNodesResponse.ProtoReflect.Descriptor instead.
+func (*GetNodesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_routing_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetNodesResponse) GetItems() []*Node {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *GetNodesResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *GetNodesResponse) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetNodesResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type RegisterNodeRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Name     string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Location *Location              `protobuf:"bytes,2,opt,name=location,proto3" json:"location,omitempty"`
+	Endpoint string                 `protobuf:"bytes,3,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	Zone     string                 `protobuf:"bytes,4,opt,name=zone,proto3" json:"zone,omitempty"`
+	// Defaults to /health
+	HealthPath string `protobuf:"bytes,5,opt,name=health_path,json=healthPath,proto3" json:"health_path,omitempty"`
+	// http, https or tcp; defaults to the endpoint's scheme
+	HealthProtocol string `protobuf:"bytes,6,opt,name=health_protocol,json=healthProtocol,proto3" json:"health_protocol,omitempty"`
+	// Accept the node without probing its health check
+	SkipProbe     bool              `protobuf:"varint,7,opt,name=skip_probe,json=skipProbe,proto3" json:"skip_probe,omitempty"`
+	Labels        map[string]string `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterNodeRequest) Reset() {
+	*x = RegisterNodeRequest{}
+	mi := &file_proto_routing_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterNodeRequest) ProtoMessage() {}
+
+func (x *RegisterNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_routing_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterNodeRequest.ProtoReflect.Descriptor instead.
+func (*RegisterNodeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_routing_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RegisterNodeRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetLocation() *Location {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *RegisterNodeRequest) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetHealthPath() string {
+	if x != nil {
+		return x.HealthPath
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetHealthProtocol() string {
+	if x != nil {
+		return x.HealthProtocol
+	}
+	return ""
+}
+
+func (x *RegisterNodeRequest) GetSkipProbe() bool {
+	if x != nil {
+		return x.SkipProbe
+	}
+	return false
+}
+
+func (x *RegisterNodeRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+var File_proto_routing_proto protoreflect.FileDescriptor
+
+const file_proto_routing_proto_rawDesc = "" +
+	"\n" +
+	"\x13proto/routing.proto\x12\x06arx.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"&\n" +
+	"\bLocation\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x01R\x01y\"\xf1\x02\n" +
+	"\fRouteRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x122\n" +
+	"\vcoordinates\x18\x02 \x01(\v2\x10.arx.v1.LocationR\vcoordinates\x12\x1b\n" +
+	"\tclient_id\x18\x03 \x01(\tR\bclientId\x12\x1a\n" +
+	"\bpriority\x18\x04 \x01(\tR\bpriority\x12%\n" +
+	"\x0epreferred_zone\x18\x05 \x01(\tR\rpreferredZone\x12Q\n" +
+	"\x0frequired_labels\x18\x06 \x03(\v2(.arx.v1.RouteRequest.RequiredLabelsEntryR\x0erequiredLabels\x12\x18\n" +
+	"\acluster\x18\a \x01(\tR\acluster\x1aA\n" +
+	"\x13RequiredLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xbe\x01\n" +
+	"\bNodeInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bendpoint\x18\x03 \x01(\tR\bendpoint\x12\x12\n" +
+	"\x04zone\x18\x04 \x01(\tR\x04zone\x12\x1a\n" +
+	"\bdistance\x18\x05 \x01(\x01R\bdistance\x12#\n" +
+	"\rdistance_unit\x18\x06 \x01(\tR\fdistanceUnit\x12\x1d\n" +
+	"\n" +
+	"load_score\x18\a \x01(\x01R\tloadScore\"\xc6\x01\n" +
+	"\rRouteResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12-\n" +
+	"\trouted_to\x18\x02 \x01(\v2\x10.arx.v1.NodeInfoR\broutedTo\x12.\n" +
+	"\tfallbacks\x18\x03 \x03(\v2\x10.arx.v1.NodeInfoR\tfallbacks\x12!\n" +
+	"\frouting_mode\x18\x04 \x01(\tR\vroutingMode\x12\x14\n" +
+	"\x05stale\x18\x05 \x01(\bR\x05stale\"\x89\x06\n" +
+	"\x04Node\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"location_x\x18\x03 \x01(\x01R\tlocationX\x12\x1d\n" +
+	"\n" +
+	"location_y\x18\x04 \x01(\x01R\tlocationY\x12\x1a\n" +
+	"\bendpoint\x18\x05 \x01(\tR\bendpoint\x12\x1a\n" +
+	"\bcapacity\x18\x06 \x01(\x05R\bcapacity\x12\x16\n" +
+	"\x06weight\x18\a \x01(\x05R\x06weight\x12\x12\n" +
+	"\x04zone\x18\b \x01(\tR\x04zone\x12\x1f\n" +
+	"\vhealth_path\x18\t \x01(\tR\n" +
+	"healthPath\x12'\n" +
+	"\x0fhealth_protocol\x18\n" +
+	" \x01(\tR\x0ehealthProtocol\x12\x16\n" +
+	"\x06status\x18\v \x01(\tR\x06status\x12\x1b\n" +
+	"\tcpu_usage\x18\f \x01(\x01R\bcpuUsage\x12!\n" +
+	"\fmemory_usage\x18\r \x01(\x01R\vmemoryUsage\x12-\n" +
+	"\x12active_connections\x18\x0e \x01(\x05R\x11activeConnections\x12F\n" +
+	"\x11last_health_check\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\x0flastHealthCheck\x129\n" +
+	"\n" +
+	"created_at\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12 \n" +
+	"\vmaintenance\x18\x12 \x01(\bR\vmaintenance\x120\n" +
+	"\x06labels\x18\x13 \x03(\v2\x18.arx.v1.Node.LabelsEntryR\x06labels\x12\x1d\n" +
+	"\n" +
+	"cluster_id\x18\x14 \x01(\tR\tclusterId\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xfd\x01\n" +
+	"\x0fGetNodesRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x12\n" +
+	"\x04zone\x18\x04 \x01(\tR\x04zone\x12;\n" +
+	"\x06labels\x18\x05 \x03(\v2#.arx.v1.GetNodesRequest.LabelsEntryR\x06labels\x12\x18\n" +
+	"\acluster\x18\x06 \x01(\tR\acluster\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"z\n" +
+	"\x10GetNodesResponse\x12\"\n" +
+	"\x05items\x18\x01 \x03(\v2\f.arx.v1.NodeR\x05items\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x05R\x06offset\"\xec\x02\n" +
+	"\x13RegisterNodeRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12,\n" +
+	"\blocation\x18\x02 \x01(\v2\x10.arx.v1.LocationR\blocation\x12\x1a\n" +
+	"\bendpoint\x18\x03 \x01(\tR\bendpoint\x12\x12\n" +
+	"\x04zone\x18\x04 \x01(\tR\x04zone\x12\x1f\n" +
+	"\vhealth_path\x18\x05 \x01(\tR\n" +
+	"healthPath\x12'\n" +
+	"\x0fhealth_protocol\x18\x06 \x01(\tR\x0ehealthProtocol\x12\x1d\n" +
+	"\n" +
+	"skip_probe\x18\a \x01(\bR\tskipProbe\x12?\n" +
+	"\x06labels\x18\b \x03(\v2'.arx.v1.RegisterNodeRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\xb9\x01\n" +
+	"\aRouting\x124\n" +
+	"\x05Route\x12\x14.arx.v1.RouteRequest\x1a\x15.arx.v1.RouteResponse\x12=\n" +
+	"\bGetNodes\x12\x17.arx.v1.GetNodesRequest\x1a\x18.arx.v1.GetNodesResponse\x129\n" +
+	"\fRegisterNode\x12\x1b.arx.v1.RegisterNodeRequest\x1a\f.arx.v1.NodeB#Z!arx-supervisor/internal/rpc/arxpbb\x06proto3"
+
+var (
+	file_proto_routing_proto_rawDescOnce sync.Once
+	file_proto_routing_proto_rawDescData []byte
+)
+
+func file_proto_routing_proto_rawDescGZIP() []byte {
+	file_proto_routing_proto_rawDescOnce.Do(func() {
+		file_proto_routing_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_routing_proto_rawDesc), len(file_proto_routing_proto_rawDesc)))
+	})
+	return file_proto_routing_proto_rawDescData
+}
+
+var file_proto_routing_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_proto_routing_proto_goTypes = []any{
+	(*Location)(nil),              // 0: arx.v1.Location
+	(*RouteRequest)(nil),          // 1: arx.v1.RouteRequest
+	(*NodeInfo)(nil),              // 2: arx.v1.NodeInfo
+	(*RouteResponse)(nil),         // 3: arx.v1.RouteResponse
+	(*Node)(nil),                  // 4: arx.v1.Node
+	(*GetNodesRequest)(nil),       // 5: arx.v1.GetNodesRequest
+	(*GetNodesResponse)(nil),      // 6: arx.v1.GetNodesResponse
+	(*RegisterNodeRequest)(nil),   // 7: arx.v1.RegisterNodeRequest
+	nil,                           // 8: arx.v1.RouteRequest.RequiredLabelsEntry
+	nil,                           // 9: arx.v1.Node.LabelsEntry
+	nil,                           // 10: arx.v1.GetNodesRequest.LabelsEntry
+	nil,                           // 11: arx.v1.RegisterNodeRequest.LabelsEntry
+	(*timestamppb.Timestamp)(nil), // 12: google.protobuf.Timestamp
+}
+var file_proto_routing_proto_depIdxs = []int32{
+	0,  // 0: arx.v1.RouteRequest.coordinates:type_name -> arx.v1.Location
+	8,  // 1: arx.v1.RouteRequest.required_labels:type_name -> arx.v1.RouteRequest.RequiredLabelsEntry
+	2,  // 2: arx.v1.RouteResponse.routed_to:type_name -> arx.v1.NodeInfo
+	2,  // 3: arx.v1.RouteResponse.fallbacks:type_name -> arx.v1.NodeInfo
+	12, // 4: arx.v1.Node.last_health_check:type_name -> google.protobuf.Timestamp
+	12, // 5: arx.v1.Node.created_at:type_name -> google.protobuf.Timestamp
+	12, // 6: arx.v1.Node.updated_at:type_name -> google.protobuf.Timestamp
+	9,  // 7: arx.v1.Node.labels:type_name -> arx.v1.Node.LabelsEntry
+	10, // 8: arx.v1.GetNodesRequest.labels:type_name -> arx.v1.GetNodesRequest.LabelsEntry
+	4,  // 9: arx.v1.GetNodesResponse.items:type_name -> arx.v1.Node
+	0,  // 10: arx.v1.RegisterNodeRequest.location:type_name -> arx.v1.Location
+	11, // 11: arx.v1.RegisterNodeRequest.labels:type_name -> arx.v1.RegisterNodeRequest.LabelsEntry
+	1,  // 12: arx.v1.Routing.Route:input_type -> arx.v1.RouteRequest
+	5,  // 13: arx.v1.Routing.GetNodes:input_type -> arx.v1.GetNodesRequest
+	7,  // 14: arx.v1.Routing.RegisterNode:input_type -> arx.v1.RegisterNodeRequest
+	3,  // 15: arx.v1.Routing.Route:output_type -> arx.v1.RouteResponse
+	6,  // 16: arx.v1.Routing.GetNodes:output_type -> arx.v1.GetNodesResponse
+	4,  // 17: arx.v1.Routing.RegisterNode:output_type -> arx.v1.Node
+	15, // [15:18] is the sub-list for method output_type
+	12, // [12:15] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_proto_routing_proto_init() }
+func file_proto_routing_proto_init() {
+	if File_proto_routing_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_routing_proto_rawDesc), len(file_proto_routing_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_routing_proto_goTypes,
+		DependencyIndexes: file_proto_routing_proto_depIdxs,
+		MessageInfos:      file_proto_routing_proto_msgTypes,
+	}.Build()
+	File_proto_routing_proto = out.File
+	file_proto_routing_proto_goTypes = nil
+	file_proto_routing_proto_depIdxs = nil
+}
@@ -0,0 +1,211 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: proto/routing.proto
+
+package arxpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Routing_Route_FullMethodName        = "/arx.v1.Routing/Route"
+	Routing_GetNodes_FullMethodName     = "/arx.v1.Routing/GetNodes"
+	Routing_RegisterNode_FullMethodName = "/arx.v1.Routing/RegisterNode"
+)
+
+// RoutingClient is the client API for Routing service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Routing mirrors the public HTTP routing API for internal callers. It
+// shares the routing service and database with the HTTP server, and errors
+// carry the same codes, as the reason of an ErrorInfo detail.
+type RoutingClient interface {
+	// Route selects the best node for a request, like POST /api/v1/route.
+	Route(ctx context.Context, in *RouteRequest, opts ...grpc.CallOption) (*RouteResponse, error)
+	// GetNodes lists registered nodes a page at a time, like GET /api/v1/nodes.
+	GetNodes(ctx context.Context, in *GetNodesRequest, opts ...grpc.CallOption) (*GetNodesResponse, error)
+	// RegisterNode adds a node, like POST /api/v1/nodes/register.
+	RegisterNode(ctx context.Context, in *RegisterNodeRequest, opts ...grpc.CallOption) (*Node, error)
+}
+
+type routingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRoutingClient(cc grpc.ClientConnInterface) RoutingClient {
+	return &routingClient{cc}
+}
+
+func (c *routingClient) Route(ctx context.Context, in *RouteRequest, opts ...grpc.CallOption) (*RouteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RouteResponse)
+	err := c.cc.Invoke(ctx, Routing_Route_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) GetNodes(ctx context.Context, in *GetNodesRequest, opts ...grpc.CallOption) (*GetNodesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetNodesResponse)
+	err := c.cc.Invoke(ctx, Routing_GetNodes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routingClient) RegisterNode(ctx context.Context, in *RegisterNodeRequest, opts ...grpc.CallOption) (*Node, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Node)
+	err := c.cc.Invoke(ctx, Routing_RegisterNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RoutingServer is the server API for Routing service.
+// All implementations must embed UnimplementedRoutingServer
+// for forward compatibility.
+//
+// Routing mirrors the public HTTP routing API for internal callers. It
+// shares the routing service and database with the HTTP server, and errors
+// carry the same codes, as the reason of an ErrorInfo detail.
+type RoutingServer interface {
+	// Route selects the best node for a request, like POST /api/v1/route.
+	Route(context.Context, *RouteRequest) (*RouteResponse, error)
+	// GetNodes lists registered nodes a page at a time, like GET /api/v1/nodes.
+	GetNodes(context.Context, *GetNodesRequest) (*GetNodesResponse, error)
+	// RegisterNode adds a node, like POST /api/v1/nodes/register.
+	RegisterNode(context.Context, *RegisterNodeRequest) (*Node, error)
+	mustEmbedUnimplementedRoutingServer()
+}
+
+// UnimplementedRoutingServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRoutingServer struct{}
+
+func (UnimplementedRoutingServer) Route(context.Context, *RouteRequest) (*RouteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Route not implemented")
+}
+func (UnimplementedRoutingServer) GetNodes(context.Context, *GetNodesRequest) (*GetNodesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNodes not implemented")
+}
+func (UnimplementedRoutingServer) RegisterNode(context.Context, *RegisterNodeRequest) (*Node, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterNode not implemented")
+}
+func (UnimplementedRoutingServer) mustEmbedUnimplementedRoutingServer() {}
+func (UnimplementedRoutingServer) testEmbeddedByValue()                 {}
+
+// UnsafeRoutingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RoutingServer will
+// result in compilation errors.
+type UnsafeRoutingServer interface {
+	mustEmbedUnimplementedRoutingServer()
+}
+
+func RegisterRoutingServer(s grpc.ServiceRegistrar, srv RoutingServer) {
+	// If the following call pancis, it indicates UnimplementedRoutingServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Routing_ServiceDesc, srv)
+}
+
+func _Routing_Route_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RouteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).Route(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_Route_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).Route(ctx, req.(*RouteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_GetNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).GetNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_GetNodes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).GetNodes(ctx, req.(*GetNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Routing_RegisterNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoutingServer).RegisterNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Routing_RegisterNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoutingServer).RegisterNode(ctx, req.(*RegisterNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Routing_ServiceDesc is the grpc.ServiceDesc for Routing service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Routing_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "arx.v1.Routing",
+	HandlerType: (*RoutingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Route",
+			Handler:    _Routing_Route_Handler,
+		},
+		{
+			MethodName: "GetNodes",
+			Handler:    _Routing_GetNodes_Handler,
+		},
+		{
+			MethodName: "RegisterNode",
+			Handler:    _Routing_RegisterNode_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/routing.proto",
+}
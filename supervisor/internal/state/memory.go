@@ -0,0 +1,119 @@
+package state
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	shardCount = 32
+
+	// idleTTL is how long a bucket is kept after its last use. A bucket
+	// past it is only dropped once it has refilled, so a slow rate or a
+	// batch charge still in debt is not forgiven by the sweep.
+	idleTTL       = 3 * time.Minute
+	sweepInterval = time.Minute
+)
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type shard struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// Memory keeps state in the process. Keys are spread over shards so
+// concurrent requests from different clients rarely contend.
+type Memory struct {
+	shards [shardCount]shard
+}
+
+func NewMemory() *Memory {
+	m := &Memory{}
+	for i := range m.shards {
+		m.shards[i].buckets = make(map[string]*bucket)
+	}
+	return m
+}
+
+func (m *Memory) shardFor(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &m.shards[h.Sum32()%shardCount]
+}
+
+// Take implements Backend with a token bucket per key.
+func (m *Memory) Take(_ context.Context, key string, n int, rps float64, burst int) (bool, time.Duration, error) {
+	now := time.Now()
+	s := m.shardFor(key)
+
+	s.mu.Lock()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+		s.buckets[key] = b
+	}
+	b.lastSeen = now
+	s.mu.Unlock()
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second, nil
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay, nil
+	}
+	// A reservation holds at most burst tokens, so larger charges are
+	// reserved in parts; the parts past the bucket's tokens become debt
+	for remaining := n - 1; remaining > 0; {
+		part := min(remaining, burst)
+		b.limiter.ReserveN(now, part)
+		remaining -= part
+	}
+	return true, 0, nil
+}
+
+// Ping implements Backend; memory is always reachable.
+func (m *Memory) Ping(context.Context) error {
+	return nil
+}
+
+// Run evicts idle buckets until ctx is cancelled.
+func (m *Memory) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.evictIdle(now)
+		}
+	}
+}
+
+func (m *Memory) evictIdle(now time.Time) {
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		for key, b := range s.buckets {
+			if now.Sub(b.lastSeen) > idleTTL && b.limiter.TokensAt(now) >= float64(b.limiter.Burst()) {
+				delete(s.buckets, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"arx-supervisor/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the supervisor's keys in a shared Redis.
+const keyPrefix = "arx:"
+
+// takeScript is the generic cell rate algorithm: the key holds the
+// theoretical arrival time of the next request in microseconds, and a
+// request is allowed while that is at most burst intervals ahead of now.
+// An allowed request of n tokens pushes it n intervals further. Redis' clock
+// is used so replicas with skewed clocks agree. It returns whether the
+// request is allowed and, if not, the wait in microseconds.
+var takeScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local tolerance = interval * tonumber(ARGV[2])
+local count = tonumber(ARGV[3])
+
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+  tat = now
+end
+local wait = tat + interval - tolerance - now
+if wait > 0 then
+  return {0, math.ceil(wait)}
+end
+local next_tat = tat + interval * count
+
+redis.call('SET', KEYS[1], string.format('%.0f', next_tat), 'PX', math.ceil((next_tat - now) / 1000) + 1)
+return {1, 0}
+`)
+
+// Redis keeps state in a Redis server shared by every replica.
+type Redis struct {
+	client *redis.Client
+}
+
+func NewRedis(cfg config.StateConfig) *Redis {
+	return &Redis{client: redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})}
+}
+
+// Take implements Backend with a GCRA bucket per key, updated atomically in
+// a script so concurrent replicas never both take the last token.
+func (r *Redis) Take(ctx context.Context, key string, n int, rps float64, burst int) (bool, time.Duration, error) {
+	if rps <= 0 {
+		return false, time.Second, nil
+	}
+	interval := float64(time.Second/time.Microsecond) / rps
+	result, err := takeScript.Run(ctx, r.client, []string{keyPrefix + "ratelimit:" + key}, interval, burst, n).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take token: %w", err)
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit reply %v", result)
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Microsecond, nil
+}
+
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Run implements Backend; Redis expires keys itself.
+func (r *Redis) Run(context.Context) {}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,36 @@
+// Package state holds runtime state that replicas of the supervisor must
+// share to behave as one, such as per-client rate limits. The in-memory
+// backend suits a single instance; the Redis backend shares state between
+// instances behind a load balancer.
+package state
+
+import (
+	"context"
+	"time"
+
+	"arx-supervisor/internal/config"
+)
+
+// Backend stores shared state. Implementations are safe for concurrent use.
+type Backend interface {
+	// Take removes n tokens from the bucket named key, which holds up to
+	// burst tokens and refills at rps tokens per second. When the bucket is
+	// empty it returns false and how long until a token is available. Once
+	// a token is available all n are taken, running the bucket into debt
+	// that later requests wait out when it holds fewer.
+	Take(ctx context.Context, key string, n int, rps float64, burst int) (bool, time.Duration, error)
+	// Ping checks that the backend can be reached.
+	Ping(ctx context.Context) error
+	// Run does background upkeep until ctx is cancelled.
+	Run(ctx context.Context)
+	Close() error
+}
+
+// New returns the Redis backend when REDIS_ADDR is set and the in-memory
+// backend otherwise.
+func New(cfg config.StateConfig) Backend {
+	if cfg.RedisAddr == "" {
+		return NewMemory()
+	}
+	return NewRedis(cfg)
+}
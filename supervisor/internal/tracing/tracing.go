@@ -0,0 +1,81 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are exported over
+// OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT (or the traces-specific
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) is set; the exporter reads the other
+// standard OTEL_* variables itself. Without an endpoint spans are not
+// recorded, but incoming trace context is still propagated.
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the supervisor's spans unless OTEL_SERVICE_NAME
+// overrides it.
+const ServiceName = "arx-supervisor"
+
+const instrumentation = "arx-supervisor"
+
+// Setup installs the global tracer provider and W3C trace context
+// propagator. It reports whether spans are exported, and the returned
+// function flushes pending spans on shutdown.
+func Setup(ctx context.Context) (func(context.Context) error, bool, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, false, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Merge so OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(ServiceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, true, nil
+}
+
+// Middleware starts a server span for every request, continuing the trace
+// from the request's traceparent header when present.
+func Middleware() gin.HandlerFunc {
+	return otelgin.Middleware(ServiceName)
+}
+
+// Start starts a child span of the span in ctx. Callers must end it.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, opts...)
+}
+
+// RecordError marks the span as failed with err, if err is not nil.
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
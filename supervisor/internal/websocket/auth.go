@@ -0,0 +1,68 @@
+package websocket
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"arx-supervisor/internal/apierror"
+	"arx-supervisor/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// authProtocol is the subprotocol browsers offer ahead of the token, as in
+// "Sec-WebSocket-Protocol: bearer, <token>", since they cannot set an
+// Authorization header on an upgrade. The hub echoes it back on success.
+const authProtocol = "bearer"
+
+// authenticate validates the JWT on an upgrade request and writes a 401 or
+// 403 before any upgrade happens if it is missing, invalid or lacks the admin
+// role. It returns the claims and the subprotocol to select, if any.
+func (h *Hub) authenticate(c *gin.Context) (*auth.Claims, string, bool) {
+	if h.jwtSecret == "" {
+		apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authentication is not configured")
+		return nil, "", false
+	}
+
+	token, protocol := upgradeToken(c.Request)
+	claims, err := auth.ParseToken(token, h.jwtSecret)
+	if err != nil {
+		message := "Invalid token"
+		switch {
+		case errors.Is(err, auth.ErrMissingToken):
+			message = "Missing token"
+		case errors.Is(err, auth.ErrTokenExpired):
+			message = "Token has expired"
+		}
+		apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, message)
+		return nil, "", false
+	}
+
+	if claims.Role != auth.RoleAdmin {
+		apierror.Abort(c, http.StatusForbidden, apierror.CodeForbidden, "Admin role required")
+		return nil, "", false
+	}
+
+	return claims, protocol, true
+}
+
+// upgradeToken reads the token from ?token= or, failing that, from the
+// subprotocol following "bearer" in Sec-WebSocket-Protocol.
+func upgradeToken(r *http.Request) (token, protocol string) {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token, ""
+	}
+
+	var offered []string
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(header, ",") {
+			offered = append(offered, strings.TrimSpace(p))
+		}
+	}
+	for i := 0; i+1 < len(offered); i++ {
+		if strings.EqualFold(offered[i], authProtocol) {
+			return offered[i+1], authProtocol
+		}
+	}
+	return "", ""
+}
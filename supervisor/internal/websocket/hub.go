@@ -1,115 +1,597 @@
 package websocket
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"arx-supervisor/internal/auth"
+	"arx-supervisor/internal/config"
+	"arx-supervisor/internal/metrics"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
-	"net/http"
 )
 
+// closeWriteWait bounds how long sending a close frame may take.
+const closeWriteWait = time.Second
+
+// clientBuffer is how many messages may wait for a client's connection
+// before the client is disconnected, so a slow client never holds up the hub.
+const clientBuffer = 256
+
+// Overflow policies, chosen with WS_OVERFLOW_POLICY, decide what publishing
+// does when the hub has fallen WS_BROADCAST_BUFFER broadcasts behind.
+const (
+	// OverflowDropNewest drops the broadcast being published
+	OverflowDropNewest = "drop-newest"
+	// OverflowDropOldest drops the longest queued broadcast to make room
+	OverflowDropOldest = "drop-oldest"
+	// OverflowBlock waits for room, holding up the publisher
+	OverflowBlock = "block"
+)
+
+// ValidateConfig rejects an unknown overflow policy or a broadcast buffer
+// that cannot hold a single message.
+func ValidateConfig(cfg config.WebSocketConfig) error {
+	switch cfg.OverflowPolicy {
+	case OverflowDropNewest, OverflowDropOldest, OverflowBlock:
+	default:
+		return fmt.Errorf("overflow policy must be %q, %q or %q", OverflowDropNewest, OverflowDropOldest, OverflowBlock)
+	}
+	if cfg.BroadcastBuffer < 1 {
+		return errors.New("broadcast buffer must be at least 1")
+	}
+	return nil
+}
+
+// heartbeatInterval is how often every client, whatever its subscriptions,
+// receives a heartbeat message.
+const heartbeatInterval = 30 * time.Second
+
+// Topics clients can subscribe to
+const (
+	TopicHealth  = "health"
+	TopicRouting = "routing"
+	TopicNodes   = "nodes"
+)
+
+// MessageTypeHeartbeat is delivered to all clients regardless of subscriptions.
+const MessageTypeHeartbeat = "heartbeat"
+
+// messageTopics maps broadcast message types onto subscription topics.
+var messageTopics = map[string]string{
+	"node_health_updated":      TopicHealth,
+	"node_status_changed":      TopicHealth,
+	"route_request":            TopicRouting,
+	"route_batch":              TopicRouting,
+	"routing_config_updated":   TopicRouting,
+	"node_created":             TopicNodes,
+	"node_updated":             TopicNodes,
+	"node_deleted":             TopicNodes,
+	"node_draining":            TopicNodes,
+	"nodes_bulk_created":       TopicNodes,
+	"node_registered":          TopicNodes,
+	"node_deregistered":        TopicNodes,
+	"node_maintenance_changed": TopicNodes,
+	"cluster_stats":            TopicHealth,
+	"node_capacity_warning":    TopicHealth,
+}
+
 type Message struct {
+	// Seq increases by one for every broadcast so clients can dedupe after a
+	// replay. Heartbeats and replies to a single client carry no sequence.
+	Seq  uint64      `json:"seq,omitempty"`
 	Type string      `json:"type"`
 	Data interface{} `json:"data"`
+
+	// remote marks messages published on behalf of a peer supervisor, which
+	// are not relayed back.
+	remote bool
+	// transient messages are periodic snapshots that are delivered as they
+	// are, without a sequence number, replay or relay to peers.
+	transient bool
 }
 
 type Hub struct {
-	clients    map[*Client]bool
+	clients map[*Client]bool
+	// connected mirrors len(clients) for readers outside the Run goroutine
+	connected  atomic.Int64
 	Broadcast  chan Message
 	register   chan *Client
 	unregister chan *Client
+	subscribe  chan subscription
+	replay     chan replayRequest
+
+	seq     uint64
+	history *replayBuffer
+
+	// overflow is the policy Publish applies when Broadcast is full
+	overflow string
+
+	// relay, when set, is handed every local broadcast so it can be shared
+	// with peer supervisors. Publish calls it before queueing, from any
+	// goroutine, so it must be safe for concurrent use and must not block.
+	relay func(Message)
+
+	pingInterval time.Duration
+	pongWait     time.Duration
+	writeWait    time.Duration
+
+	// jwtSecret validates the token presented on upgrade, the same secret
+	// the admin API uses.
+	jwtSecret string
+
+	// done is closed once Run starts shutting down; pumps wait on it instead
+	// of blocking on channels nobody reads any more.
+	done    chan struct{}
+	writers sync.WaitGroup
 }
 
 type Client struct {
 	hub  *Hub
 	conn *websocket.Conn
 	send chan Message
+
+	// identity holds the claims the client authenticated with, for
+	// authorizing what it may subscribe to.
+	identity *auth.Claims
+
+	// topics is the client's subscription set, owned by the Run goroutine.
+	// A nil set means the client has not subscribed and receives everything.
+	topics map[string]bool
 }
 
-func NewHub() *Hub {
+// clientMessage is an inbound control message, e.g.
+// {"action":"subscribe","topics":["health","nodes"]} or
+// {"action":"replay","since":42}.
+type clientMessage struct {
+	Action string   `json:"action"`
+	Topics []string `json:"topics"`
+	Since  uint64   `json:"since"`
+}
+
+type replayRequest struct {
+	client *Client
+	since  uint64
+}
+
+type subscription struct {
+	client    *Client
+	topics    []string
+	subscribe bool
+}
+
+func NewHub(cfg config.WebSocketConfig, jwtSecret string) *Hub {
+	pingInterval := time.Duration(cfg.PingInterval) * time.Second
+	pongWait := time.Duration(cfg.PongWait) * time.Second
+	if pingInterval <= 0 {
+		pingInterval = 30 * time.Second
+	}
+	// A pong can only arrive after its ping, so the wait must outlast the interval
+	if pongWait <= pingInterval {
+		pongWait = 2 * pingInterval
+	}
+	writeWait := time.Duration(cfg.WriteWait) * time.Second
+	if writeWait <= 0 {
+		writeWait = 10 * time.Second
+	}
+	replaySize := cfg.ReplayBufferSize
+	if replaySize < 0 {
+		replaySize = 0
+	}
+	broadcastSize := cfg.BroadcastBuffer
+	if broadcastSize < 1 {
+		broadcastSize = 256
+	}
+	overflow := cfg.OverflowPolicy
+	if overflow == "" {
+		overflow = OverflowDropNewest
+	}
+
 	return &Hub{
-		clients:    make(map[*Client]bool),
-		Broadcast:  make(chan Message),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		clients:      make(map[*Client]bool),
+		Broadcast:    make(chan Message, broadcastSize),
+		register:     make(chan *Client),
+		unregister:   make(chan *Client),
+		subscribe:    make(chan subscription),
+		replay:       make(chan replayRequest),
+		history:      newReplayBuffer(replaySize),
+		overflow:     overflow,
+		pingInterval: pingInterval,
+		pongWait:     pongWait,
+		writeWait:    writeWait,
+		jwtSecret:    jwtSecret,
+		done:         make(chan struct{}),
 	}
 }
 
-func (h *Hub) Run() {
+// Run dispatches broadcasts to clients until ctx is cancelled. On shutdown it
+// sends every client a close frame, discards pending broadcasts and waits for
+// the client writers to finish before returning.
+func (h *Hub) Run(ctx context.Context) {
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
 	for {
 		select {
 		case client := <-h.register:
 			h.clients[client] = true
+			h.connected.Store(int64(len(h.clients)))
 
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
 				close(client.send)
+				h.connected.Store(int64(len(h.clients)))
+			}
+
+		case sub := <-h.subscribe:
+			if _, ok := h.clients[sub.client]; ok {
+				h.updateSubscription(sub)
+			}
+
+		case req := <-h.replay:
+			if _, ok := h.clients[req.client]; ok {
+				h.replayTo(req.client, req.since)
 			}
 
 		case message := <-h.Broadcast:
-			for client := range h.clients {
+			metrics.WebSocketQueueDepth.Set(float64(len(h.Broadcast)))
+			if message.transient {
+				h.deliver(message)
+				continue
+			}
+			h.seq++
+			message.Seq = h.seq
+			h.history.add(message)
+			h.deliver(message)
+
+		case now := <-heartbeat.C:
+			h.deliver(Message{
+				Type: MessageTypeHeartbeat,
+				Data: map[string]interface{}{"timestamp": now.UTC()},
+			})
+
+		case <-ctx.Done():
+			h.shutdown()
+			return
+		}
+	}
+}
+
+// deliver sends a message to every client subscribed to its topic,
+// disconnecting clients whose send buffer is full.
+func (h *Hub) deliver(message Message) {
+	for client := range h.clients {
+		if !client.wants(message) {
+			continue
+		}
+
+		select {
+		case client.send <- message:
+		default:
+			h.dropSlowClient(client)
+		}
+	}
+}
+
+// dropSlowClient disconnects a client that has fallen clientBuffer messages
+// behind. Closing its send channel makes its writer send a close frame.
+func (h *Hub) dropSlowClient(client *Client) {
+	close(client.send)
+	delete(h.clients, client)
+	h.connected.Store(int64(len(h.clients)))
+	metrics.WebSocketSlowClients.Inc()
+}
+
+// replayTo sends a client the buffered broadcasts after since that match its
+// subscriptions, ahead of any new live broadcast.
+func (h *Hub) replayTo(client *Client, since uint64) {
+	for _, message := range h.history.since(since) {
+		if !client.wants(message) {
+			continue
+		}
+
+		select {
+		case client.send <- message:
+		default:
+			h.dropSlowClient(client)
+			return
+		}
+	}
+}
+
+func (h *Hub) updateSubscription(sub subscription) {
+	client := sub.client
+	if client.topics == nil {
+		client.topics = make(map[string]bool)
+	}
+
+	for _, topic := range sub.topics {
+		if !isTopic(topic) {
+			continue
+		}
+		if sub.subscribe {
+			client.topics[topic] = true
+		} else {
+			delete(client.topics, topic)
+		}
+	}
+
+	topics := make([]string, 0, len(client.topics))
+	for topic := range client.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	select {
+	case client.send <- Message{Type: "subscriptions", Data: map[string]interface{}{"topics": topics}}:
+	default:
+	}
+}
+
+func (h *Hub) shutdown() {
+	close(h.done)
+
+	for client := range h.clients {
+		close(client.send)
+		delete(h.clients, client)
+	}
+
+	// Drain broadcasts already queued by senders
+	for {
+		select {
+		case <-h.Broadcast:
+		default:
+			metrics.WebSocketQueueDepth.Set(0)
+			h.writers.Wait()
+			return
+		}
+	}
+}
+
+// Publish queues a message for all clients. When the hub has fallen
+// WS_BROADCAST_BUFFER messages behind, the overflow policy either drops the
+// message, drops the oldest queued message in its favour, or waits for room;
+// dropped messages are counted. Local messages are handed to the relay first,
+// so peers learn of node changes even when clients miss them. Messages
+// published after the hub has shut down are discarded, and a blocked
+// publisher is released by shutdown.
+func (h *Hub) Publish(message Message) {
+	select {
+	case <-h.done:
+		return
+	default:
+	}
+
+	if h.relay != nil && !message.remote && !message.transient {
+		h.relay(message)
+	}
+
+	switch h.overflow {
+	case OverflowBlock:
+		select {
+		case h.Broadcast <- message:
+		case <-h.done:
+			return
+		}
+
+	case OverflowDropOldest:
+		for queued := false; !queued; {
+			select {
+			case h.Broadcast <- message:
+				queued = true
+			default:
+				// Another publisher or the hub may take the slot first, so
+				// only count what this call actually discards
 				select {
-				case client.send <- message:
+				case <-h.Broadcast:
+					metrics.WebSocketDroppedMessages.Inc()
 				default:
-					close(client.send)
-					delete(h.clients, client)
 				}
 			}
 		}
+
+	default:
+		select {
+		case h.Broadcast <- message:
+		default:
+			metrics.WebSocketDroppedMessages.Inc()
+			return
+		}
 	}
+	metrics.WebSocketQueueDepth.Set(float64(len(h.Broadcast)))
+}
+
+// PublishRemote queues a message received from a peer supervisor. It is
+// delivered like Publish but not handed to the relay again.
+func (h *Hub) PublishRemote(message Message) {
+	message.remote = true
+	h.Publish(message)
+}
+
+// PublishTransient queues a periodic snapshot such as cluster stats. It is
+// delivered to subscribed clients like Publish, but gets no sequence number,
+// is not kept for replay and is not relayed to peers, since the next snapshot
+// supersedes it.
+func (h *Hub) PublishTransient(message Message) {
+	message.transient = true
+	h.Publish(message)
+}
+
+// ClientCount returns how many clients are connected.
+func (h *Hub) ClientCount() int {
+	return int(h.connected.Load())
+}
+
+// SetRelay registers fn to receive every local broadcast. It must be called
+// before anything is published.
+func (h *Hub) SetRelay(fn func(Message)) {
+	h.relay = fn
 }
 
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
+// HandleWebSocket authenticates the request and upgrades it. The token is
+// checked before the upgrade so unauthorized clients get a plain 401.
 func (h *Hub) HandleWebSocket(c *gin.Context) {
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	claims, protocol, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+
+	var header http.Header
+	if protocol != "" {
+		header = http.Header{"Sec-WebSocket-Protocol": {protocol}}
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, header)
 	if err != nil {
 		return
 	}
 
 	client := &Client{
-		hub:  h,
-		conn: conn,
-		send: make(chan Message, 256),
+		hub:      h,
+		conn:     conn,
+		send:     make(chan Message, clientBuffer),
+		identity: claims,
 	}
 
-	client.hub.register <- client
+	select {
+	case client.hub.register <- client:
+	case <-h.done:
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+			time.Now().Add(closeWriteWait))
+		conn.Close()
+		return
+	}
 
+	h.writers.Add(1)
 	go client.writePump()
 	go client.readPump()
 }
 
 func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.done:
+		}
 		c.conn.Close()
 	}()
 
+	// Clients that stop answering pings hit the read deadline and are dropped
+	c.conn.SetReadDeadline(time.Now().Add(c.hub.pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(c.hub.pongWait))
+	})
+
 	for {
-		_, _, err := c.conn.ReadMessage()
+		_, data, err := c.conn.ReadMessage()
 		if err != nil {
 			break
 		}
+
+		var msg clientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		switch msg.Action {
+		case "subscribe", "unsubscribe":
+			select {
+			case c.hub.subscribe <- subscription{client: c, topics: msg.Topics, subscribe: msg.Action == "subscribe"}:
+			case <-c.hub.done:
+				return
+			}
+		case "replay":
+			select {
+			case c.hub.replay <- replayRequest{client: c, since: msg.Since}:
+			case <-c.hub.done:
+				return
+			}
+		}
 	}
 }
 
+// wants reports whether the client should receive the message. Heartbeats
+// always go through; everything else is filtered once the client subscribes.
+func (c *Client) wants(message Message) bool {
+	if c.topics == nil || message.Type == MessageTypeHeartbeat {
+		return true
+	}
+	return c.topics[messageTopics[message.Type]]
+}
+
+func isTopic(topic string) bool {
+	switch topic {
+	case TopicHealth, TopicRouting, TopicNodes:
+		return true
+	}
+	return false
+}
+
 func (c *Client) writePump() {
+	ticker := time.NewTicker(c.hub.pingInterval)
+
+	// The session ends when the token does
+	var expired <-chan time.Time
+	if c.identity != nil && c.identity.ExpiresAt != nil {
+		expiry := time.NewTimer(time.Until(c.identity.ExpiresAt.Time))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
 	defer func() {
+		ticker.Stop()
 		c.conn.Close()
+		c.hub.writers.Done()
 	}()
 
 	for {
 		select {
 		case message, ok := <-c.send:
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteControl(websocket.CloseMessage, c.closeMessage(), time.Now().Add(closeWriteWait))
 				return
 			}
 
+			// A write deadline keeps a half-open connection from stalling
+			// this client forever
+			c.conn.SetWriteDeadline(time.Now().Add(c.hub.writeWait))
 			if err := c.conn.WriteJSON(message); err != nil {
 				return
 			}
+
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.hub.writeWait)); err != nil {
+				return
+			}
+
+		case <-expired:
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "token expired"),
+				time.Now().Add(closeWriteWait))
+			return
 		}
 	}
 }
+
+// closeMessage tells the client whether the server is going away or just
+// dropping this connection.
+func (c *Client) closeMessage() []byte {
+	select {
+	case <-c.hub.done:
+		return websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	default:
+		return websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	}
+}
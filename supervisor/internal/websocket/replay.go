@@ -0,0 +1,43 @@
+package websocket
+
+// replayBuffer keeps the most recent broadcasts so reconnecting clients can
+// catch up on what they missed. It is owned by the hub's Run goroutine.
+type replayBuffer struct {
+	messages []Message
+	next     int
+	full     bool
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{messages: make([]Message, size)}
+}
+
+func (b *replayBuffer) add(message Message) {
+	if len(b.messages) == 0 {
+		return
+	}
+
+	b.messages[b.next] = message
+	b.next = (b.next + 1) % len(b.messages)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// since returns the buffered messages with a sequence number greater than
+// seq, oldest first.
+func (b *replayBuffer) since(seq uint64) []Message {
+	start, count := 0, b.next
+	if b.full {
+		start, count = b.next, len(b.messages)
+	}
+
+	result := make([]Message, 0, count)
+	for i := 0; i < count; i++ {
+		message := b.messages[(start+i)%len(b.messages)]
+		if message.Seq > seq {
+			result = append(result, message)
+		}
+	}
+	return result
+}